@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/DB/migrate"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/k1v4/avito_shop/pkg/scheduler"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	usage        = "usage: app set-role <username> <role> | app reconcile | app migrate up|down [steps]|status"
+	migrateUsage = "usage: app migrate up | app migrate down [steps] | app migrate status"
+)
+
+// runCommand выполняет подкоманду из аргументов командной строки
+func runCommand(ctx context.Context, t usecase.IShopService, args []string) error {
+	switch args[0] {
+	case "set-role":
+		if len(args) != 3 {
+			return errors.New(usage)
+		}
+
+		return t.SetRole(ctx, args[1], args[2])
+	case "reconcile":
+		report, err := t.Reconcile(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err = json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			return err
+		}
+
+		if !report.OK() {
+			return errLedgerDrift(report)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q, %s", args[0], usage)
+	}
+}
+
+// runMigrate выполняет подкоманду migrate. down по умолчанию откатывает одну последнюю миграцию
+func runMigrate(ctx context.Context, l logger.Logger, m *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+
+		applied, err := m.Up(ctx)
+		logMigrations(ctx, l, "applied", applied)
+
+		return err
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid steps %q, %s", args[1], migrateUsage)
+			}
+
+			steps = n
+		} else if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+
+		reverted, err := m.Down(ctx, steps)
+		logMigrations(ctx, l, "reverted", reverted)
+
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(os.Stdout).Encode(statuses)
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+}
+
+func logMigrations(ctx context.Context, l logger.Logger, action string, done []migrate.Migration) {
+	for _, m := range done {
+		l.Info(ctx, fmt.Sprintf("migration %d_%s %s", m.Version, m.Name, action))
+	}
+
+	if len(done) == 0 {
+		l.Info(ctx, "migrations: nothing to do")
+	}
+}
+
+// allowanceJob задача планировщика, которая начисляет allowance за текущий период, если его ещё никто не начислил
+func allowanceJob(l logger.Logger, t usecase.IShopService, allowance entity.Allowance) scheduler.Job {
+	return func(ctx context.Context) error {
+		granted, err := t.RunAllowance(ctx, allowance, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if granted > 0 {
+			l.Info(ctx, fmt.Sprintf("allowance %s: granted %d coins to %d users", allowance.Period, allowance.Amount, granted))
+		}
+
+		return nil
+	}
+}
+
+// reconcileJob задача планировщика, которая сверяет балансы с журналом и сообщает о расхождениях как об ошибке
+func reconcileJob(t usecase.IShopService) scheduler.Job {
+	return func(ctx context.Context) error {
+		report, err := t.Reconcile(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !report.OK() {
+			return errLedgerDrift(report)
+		}
+
+		return nil
+	}
+}
+
+func errLedgerDrift(report entity.Reconciliation) error {
+	return fmt.Errorf("ledger drift: %d of %d users, unbalanced entries %v, drifts %+v",
+		len(report.Drifts), report.Users, report.UnbalancedEntries, report.Drifts)
+}
@@ -2,42 +2,62 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/k1v4/avito_shop/db/migrations"
 	"github.com/k1v4/avito_shop/internal/config"
+	grpcv1 "github.com/k1v4/avito_shop/internal/controller/grpc/v1"
 	v1 "github.com/k1v4/avito_shop/internal/controller/http/v1"
+	"github.com/k1v4/avito_shop/internal/entity"
 	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/internal/usecase/cache"
+	"github.com/k1v4/avito_shop/internal/usecase/metrics"
 	"github.com/k1v4/avito_shop/internal/usecase/repository"
+	"github.com/k1v4/avito_shop/internal/usecase/tracing"
+	"github.com/k1v4/avito_shop/pkg/DB/migrate"
 	"github.com/k1v4/avito_shop/pkg/DB/postgres"
 	"github.com/k1v4/avito_shop/pkg/DB/redis"
+	"github.com/k1v4/avito_shop/pkg/grpcserver"
+	"github.com/k1v4/avito_shop/pkg/health"
 	"github.com/k1v4/avito_shop/pkg/httpserver"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
 	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/k1v4/avito_shop/pkg/scheduler"
+	"github.com/k1v4/avito_shop/pkg/telemetry"
 	"github.com/labstack/echo/v4"
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 )
 
+// tracingShutdownTimeout сколько при остановке ждать отправки накопленных спанов
+const tracingShutdownTimeout = 5 * time.Second
+
 func main() {
 	ctx := context.Background()
 	loggerBack := logger.NewLogger()
 	ctx = context.WithValue(ctx, logger.LoggerKey, loggerBack)
 
+	// os.Exit не выполняет отложенные вызовы, поэтому всё, что закрывается через defer, живёт в run
+	if err := run(ctx, loggerBack, os.Args[1:]); err != nil {
+		loggerBack.Error(ctx, err.Error())
+		os.Exit(1)
+	}
+}
+
+// run запускает сервер, а с аргументами выполняет подкоманду и завершается
+func run(ctx context.Context, loggerBack logger.Logger, args []string) error {
 	loggerBack.Info(ctx, "starting backend")
 
 	cfg := config.MustLoadConfig()
 	if cfg == nil {
-		loggerBack.Error(ctx, "config is nil")
-		return
-	}
-
-	clientRedis, err := redis.NewClient(ctx, cfg.RedisConfig)
-	if err != nil {
-		loggerBack.Error(ctx, "redis client init fail")
+		return errors.New("app - Run - config is nil")
 	}
 
-	_ = clientRedis
-
 	url := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		cfg.DBConfig.UserName,
 		cfg.DBConfig.Password,
@@ -48,25 +68,158 @@ func main() {
 
 	pg, err := postgres.New(url, postgres.MaxPoolSize(cfg.DBConfig.PoolMax))
 	if err != nil {
-		loggerBack.Error(ctx, fmt.Sprintf("app - Run - postgres.New: %s", err))
+		return fmt.Errorf("app - Run - postgres.New: %w", err)
 	}
 	defer pg.Close()
 
 	loggerBack.Info(ctx, "connected to database successfully")
 
-	containerUseCase := usecase.NewShopUseCase(
-		repository.NewShopRepository(pg),
-		clientRedis,
+	migrator, err := migrate.New(pg.Pool, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("app - Run - migrate.New: %w", err)
+	}
+
+	// подкомандам нужна только база, поэтому ни redis, ни ключи токенов, ни трассировка для них не настраиваются
+	if len(args) > 0 {
+		if args[0] == "migrate" {
+			if err = runMigrate(ctx, loggerBack, migrator, args[1:]); err != nil {
+				return fmt.Errorf("app - Run - runMigrate: %w", err)
+			}
+
+			return nil
+		}
+
+		commands := usecase.NewShopUseCase(repository.NewShopRepository(pg), cache.NewNop(), nil)
+		if err = runCommand(ctx, commands, args); err != nil {
+			return fmt.Errorf("app - Run - runCommand: %w", err)
+		}
+
+		return nil
+	}
+
+	// с несколькими репликами миграции применяет первая, остальные ждут её на advisory lock
+	if cfg.MigrateOnStart {
+		applied, err := migrator.Up(ctx)
+		logMigrations(ctx, loggerBack, "applied", applied)
+		if err != nil {
+			return fmt.Errorf("app - Run - migrator.Up: %w", err)
+		}
+	}
+
+	// трассировка настраивается до подключения к redis, чтобы его клиент писал спаны,
+	// спаны SQL-запросов берут провайдер при каждом запросе
+	shutdownTracing, err := telemetry.New(ctx, cfg.TracingConfig)
+	if err != nil {
+		return fmt.Errorf("app - Run - telemetry.New: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			loggerBack.Error(ctx, fmt.Sprintf("app - Run - shutdownTracing: %s", err))
+		}
+	}()
+
+	// без redis сервис работает на локальном кэше и переподключается в фоне
+	var fallbackCache usecase.Cache = cache.NewNop()
+	if cfg.RedisConfig.FallbackSize > 0 {
+		fallbackCache = cache.NewLRU(cfg.RedisConfig.FallbackSize)
+	}
+
+	infoCache := cache.NewResilient(ctx, func(ctx context.Context) (*goredis.Client, error) {
+		return redis.NewClient(ctx, cfg.RedisConfig)
+	}, fallbackCache, loggerBack, cfg.RedisConfig.CheckInterval)
+	defer infoCache.Close()
+
+	// отозванные access-токены хранятся только в redis: в локальном fallback отзыв не увидят другие реплики
+	// и его может вытеснить LRU, поэтому без redis токены не принимаются
+	tokens, err := jwtPkg.NewManager(
+		cfg.JWTConfig,
+		jwtPkg.WithDenylist(cache.NewDenylist(infoCache.Strict(), cfg.RedisConfig.Namespace)),
+	)
+	if err != nil {
+		return fmt.Errorf("app - Run - jwtPkg.NewManager: %w", err)
+	}
+
+	// метрики и спаны пишут декораторы вокруг репозитория, кэша и сервиса, сам usecase о них не знает
+	appMetrics := metrics.New()
+	appMetrics.MustRegister(metrics.NewPoolCollector(pg.Pool))
+
+	tracerProvider := otel.GetTracerProvider()
+
+	shopRepository := tracing.NewRepository(
+		metrics.NewRepository(repository.NewShopRepository(pg), appMetrics),
+		tracerProvider,
 	)
 
+	containerUseCase := metrics.NewService(tracing.NewService(usecase.NewShopUseCase(
+		shopRepository,
+		metrics.NewCache(infoCache, "info", appMetrics),
+		tokens,
+		usecase.CacheNamespace(cfg.RedisConfig.Namespace),
+		usecase.InfoTTL(cfg.RedisConfig.InfoTTL),
+		usecase.TokenTTL(cfg.JWTConfig.TokenTTL),
+		usecase.RefreshTTL(cfg.JWTConfig.RefreshTokenTTL),
+		usecase.IdempotencyTTL(cfg.IdempotencyTTL),
+		usecase.RefundWindow(cfg.RefundWindow),
+	), tracerProvider), appMetrics)
+
+	if cfg.AllowanceAmount > 0 {
+		allowance := entity.Allowance{
+			Period: cfg.AllowancePeriod,
+			Amount: cfg.AllowanceAmount,
+			Reason: cfg.AllowanceReason,
+		}
+		if _, ok := allowance.PeriodKey(time.Now()); !ok {
+			return fmt.Errorf("app - Run - unknown ALLOWANCE_PERIOD %q", cfg.AllowancePeriod)
+		}
+
+		// запускается на каждой реплике, за период начисляет только одна из них
+		allowances := scheduler.New(ctx, "allowance",
+			allowanceJob(loggerBack, containerUseCase, allowance),
+			loggerBack,
+			scheduler.Interval(cfg.AllowanceCheckInterval),
+		)
+		defer allowances.Close()
+	}
+
+	if cfg.ReconcileInterval > 0 {
+		reconciler := scheduler.New(ctx, "reconcile", reconcileJob(containerUseCase), loggerBack,
+			scheduler.Interval(cfg.ReconcileInterval))
+		defer reconciler.Close()
+	}
+
 	handler := echo.New()
 	//handler.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 	//	AllowOrigins: []string{"http://localhost:3000", "http://10.255.196.171:3000"},
 	//	AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
 	//}))
-	v1.NewRouter(handler, loggerBack, containerUseCase)
+	// обязательна только postgres: без redis кэш работает из памяти, поэтому его состояние
+	// видно в /readyz, но под из балансировки не убирает
+	readiness := health.New(
+		health.Timeout(cfg.HealthCheckTimeout),
+		health.WithCheck("postgres", pg.Pool.Ping),
+		health.WithOptionalCheck("redis", infoCache.Ping),
+	)
+
+	v1.NewRouter(handler, loggerBack, containerUseCase, tokens,
+		v1.WithMetrics(appMetrics),
+		v1.WithTracerProvider(tracerProvider),
+		v1.WithHealth(readiness),
+	)
 
-	httpServer := httpserver.New(handler, httpserver.Port(strconv.Itoa(cfg.RestServerPort)))
+	// при остановке /readyz сразу начинает отвечать 503, а соединения закрываются только через ShutdownDrainDelay
+	httpServer := httpserver.New(handler,
+		httpserver.Port(strconv.Itoa(cfg.RestServerPort)),
+		httpserver.OnShutdown(readiness.Drain),
+		httpserver.DrainDelay(cfg.ShutdownDrainDelay),
+	)
+
+	grpcServer := grpcserver.New(
+		grpcv1.NewServer(loggerBack, containerUseCase, tokens),
+		grpcserver.Port(strconv.Itoa(cfg.GrpcServerPort)),
+	)
 
 	// signal for graceful shutdown
 	interrupt := make(chan os.Signal, 1)
@@ -77,6 +230,8 @@ func main() {
 		loggerBack.Info(ctx, "app-Run-signal: "+s.String())
 	case err = <-httpServer.Notify():
 		loggerBack.Error(ctx, fmt.Sprintf("app-Run-httpServer.Notify: %s", err))
+	case err = <-grpcServer.Notify():
+		loggerBack.Error(ctx, fmt.Sprintf("app-Run-grpcServer.Notify: %s", err))
 	}
 
 	// shutdown
@@ -84,4 +239,11 @@ func main() {
 	if err != nil {
 		loggerBack.Error(ctx, fmt.Sprintf("app-Run-httpServer.Shutdown: %s", err))
 	}
+
+	err = grpcServer.Shutdown()
+	if err != nil {
+		loggerBack.Error(ctx, fmt.Sprintf("app-Run-grpcServer.Shutdown: %s", err))
+	}
+
+	return nil
 }
@@ -1,5 +1,8 @@
+-- Исходная схема, с которой раньше создавалась база из db/init.sql. Объекты создаются с IF NOT EXISTS,
+-- чтобы на такой базе миграция только записалась в schema_migrations, а следующие миграции её обновили
+
 -- Создание таблицы Users
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
                        id SERIAL PRIMARY KEY,
                        username VARCHAR(255) UNIQUE NOT NULL,
                        password VARCHAR(255) NOT NULL,
@@ -9,7 +12,7 @@ CREATE INDEX IF NOT EXISTS idx_username ON users (username);
 CREATE INDEX IF NOT EXISTS idx_id ON users (id);
 
 -- Создание таблицы Items
-CREATE TABLE items (
+CREATE TABLE IF NOT EXISTS items (
                        id SERIAL PRIMARY KEY,
                        name VARCHAR(255) NOT NULL,
                        price INT NOT NULL
@@ -18,7 +21,7 @@ CREATE INDEX IF NOT EXISTS idx_items_id ON items (id);
 CREATE INDEX IF NOT EXISTS idx_items_name ON items (name);
 
 -- Создание таблицы Inventory
-CREATE TABLE inventory (
+CREATE TABLE IF NOT EXISTS inventory (
                            id SERIAL PRIMARY KEY,
                            user_id INT NOT NULL,
                            item_id INT NOT NULL,
@@ -26,11 +29,11 @@ CREATE TABLE inventory (
                            FOREIGN KEY (user_id) REFERENCES users(id),
                            FOREIGN KEY (item_id) REFERENCES items(id)
 );
-CREATE UNIQUE INDEX idx_user_item ON inventory (user_id, item_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_item ON inventory (user_id, item_id);
 
 
 -- Создание таблицы CoinHistory
-CREATE TABLE coin_history (
+CREATE TABLE IF NOT EXISTS coin_history (
                              id SERIAL PRIMARY KEY,
                              from_user INT,
                              to_user INT,
@@ -40,16 +43,3 @@ CREATE TABLE coin_history (
 );
 CREATE INDEX IF NOT EXISTS idx_from_user_coin_history ON coin_history (from_user);
 CREATE INDEX IF NOT EXISTS idx_to_user_coin_history ON coin_history (to_user);
-
-
-INSERT INTO items(name, price) VALUES ('t-shirt', 80);
-INSERT INTO items(name, price) VALUES ('cup', 20);
-INSERT INTO items(name, price) VALUES ('book', 50);
-INSERT INTO items(name, price) VALUES ('pen', 10);
-INSERT INTO items(name, price) VALUES ('powerbank', 200);
-INSERT INTO items(name, price) VALUES ('hoody', 300);
-INSERT INTO items(name, price) VALUES ('umbrella', 200);
-INSERT INTO items(name, price) VALUES ('socks', 10);
-INSERT INTO items(name, price) VALUES ('wallet', 50);
-INSERT INTO items(name, price) VALUES ('pink-hoody', 500);
-
@@ -0,0 +1,4 @@
+-- Удаляются только товары стартового каталога, которые никто не покупал: на остальные ссылается инвентарь
+DELETE FROM items i
+WHERE i.name IN ('t-shirt', 'cup', 'book', 'pen', 'powerbank', 'hoody', 'umbrella', 'socks', 'wallet', 'pink-hoody')
+  AND NOT EXISTS (SELECT 1 FROM inventory inv WHERE inv.item_id = i.id);
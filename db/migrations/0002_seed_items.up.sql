@@ -0,0 +1,17 @@
+-- Стартовый каталог мерча. Товар, который уже есть в каталоге под тем же именем, не добавляется повторно,
+-- поэтому на базе из db/init.sql миграция ничего не меняет
+INSERT INTO items(name, price)
+SELECT v.name, v.price
+FROM (VALUES
+    ('t-shirt', 80),
+    ('cup', 20),
+    ('book', 50),
+    ('pen', 10),
+    ('powerbank', 200),
+    ('hoody', 300),
+    ('umbrella', 200),
+    ('socks', 10),
+    ('wallet', 50),
+    ('pink-hoody', 500)
+) AS v(name, price)
+WHERE NOT EXISTS (SELECT 1 FROM items i WHERE i.name = v.name);
@@ -0,0 +1,3 @@
+-- Баланс не может уйти в минус даже при гонке параллельных списаний
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_amount_non_negative;
+ALTER TABLE users ADD CONSTRAINT users_amount_non_negative CHECK (amount >= 0);
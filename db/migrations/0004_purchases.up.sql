@@ -0,0 +1,12 @@
+-- Создание таблицы Purchases: каждая покупка с ценой на момент покупки
+CREATE TABLE IF NOT EXISTS purchases (
+                           id SERIAL PRIMARY KEY,
+                           user_id INT NOT NULL,
+                           item_id INT NOT NULL,
+                           price INT NOT NULL,
+                           quantity INT NOT NULL,
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                           FOREIGN KEY (user_id) REFERENCES users(id),
+                           FOREIGN KEY (item_id) REFERENCES items(id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_purchases ON purchases (user_id, id);
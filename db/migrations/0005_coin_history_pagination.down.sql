@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_from_user_coin_history;
+DROP INDEX IF EXISTS idx_to_user_coin_history;
+CREATE INDEX idx_from_user_coin_history ON coin_history (from_user);
+CREATE INDEX idx_to_user_coin_history ON coin_history (to_user);
+
+ALTER TABLE coin_history DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,8 @@
+-- Время перевода и индексы для keyset-пагинации истории: WHERE from_user = ? AND id < ? ORDER BY id DESC.
+-- Переводы, сделанные до миграции, получают время её применения
+ALTER TABLE coin_history ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+DROP INDEX IF EXISTS idx_from_user_coin_history;
+DROP INDEX IF EXISTS idx_to_user_coin_history;
+CREATE INDEX idx_from_user_coin_history ON coin_history (from_user, id);
+CREATE INDEX idx_to_user_coin_history ON coin_history (to_user, id);
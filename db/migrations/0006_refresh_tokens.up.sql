@@ -0,0 +1,13 @@
+-- Создание таблицы RefreshTokens: хранится только sha256 токена.
+-- Все токены, полученные ротацией от одного логина, образуют семейство family_id
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+                                id SERIAL PRIMARY KEY,
+                                user_id INT NOT NULL,
+                                token_hash BYTEA NOT NULL UNIQUE,
+                                family_id VARCHAR(64) NOT NULL,
+                                expires_at TIMESTAMPTZ NOT NULL,
+                                revoked_at TIMESTAMPTZ,
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
@@ -0,0 +1,13 @@
+-- Создание таблицы IdempotencyKeys: первый успешный ответ на запрос с заголовком Idempotency-Key.
+-- Запись вставляется в той же транзакции, что и само изменение, поэтому повтор либо видит готовый ответ,
+-- либо ждёт на первичном ключе, пока первый запрос не завершится
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+                                  user_id INT NOT NULL,
+                                  idempotency_key VARCHAR(255) NOT NULL,
+                                  request_hash BYTEA NOT NULL,
+                                  status_code INT,
+                                  response_body BYTEA,
+                                  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                  PRIMARY KEY (user_id, idempotency_key),
+                                  FOREIGN KEY (user_id) REFERENCES users(id)
+);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_items_name;
+CREATE INDEX idx_items_name ON items (name);
+
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_price_positive;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS image_url,
+    DROP COLUMN IF EXISTS active,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,16 @@
+-- Каталог товаров. Товары не удаляются, а помечаются deleted_at: на них ссылаются покупки и инвентарь.
+-- Имя уникально среди неудалённых товаров, так что имя удалённого товара можно занять снова
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS image_url VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_price_positive;
+ALTER TABLE items ADD CONSTRAINT items_price_positive CHECK (price > 0);
+
+-- в исходной схеме idx_items_name был неуникальным, поэтому индекс пересоздаётся, а не создаётся с IF NOT EXISTS
+DROP INDEX IF EXISTS idx_items_name;
+CREATE UNIQUE INDEX idx_items_name ON items (name) WHERE deleted_at IS NULL;
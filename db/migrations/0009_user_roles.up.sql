@@ -0,0 +1,5 @@
+-- Роль пользователя: employee, hr или admin. Существующие пользователи получают employee
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'employee';
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_valid;
+ALTER TABLE users ADD CONSTRAINT users_role_valid CHECK (role IN ('employee', 'hr', 'admin'));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS allowance_runs;
+DROP TABLE IF EXISTS coin_grants;
@@ -0,0 +1,23 @@
+-- Создание таблицы CoinGrants: начисления монет от системы (премии от HR и регулярные начисления).
+-- В отличие от coin_history у начисления нет отправителя, granted_by - кто его оформил, NULL - планировщик
+CREATE TABLE IF NOT EXISTS coin_grants (
+                             id SERIAL PRIMARY KEY,
+                             user_id INT NOT NULL,
+                             amount INT NOT NULL,
+                             reason TEXT NOT NULL,
+                             granted_by INT,
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                             CONSTRAINT coin_grants_amount_positive CHECK (amount > 0),
+                             FOREIGN KEY (user_id) REFERENCES users(id),
+                             FOREIGN KEY (granted_by) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_coin_grants ON coin_grants (user_id, id);
+
+
+-- Создание таблицы AllowanceRuns: отметка о выполненном регулярном начислении за период.
+-- Отметка вставляется в одной транзакции с начислением, так что при нескольких репликах
+-- за период начисляет ровно одна, остальные ждут на первичном ключе и пропускают период
+CREATE TABLE IF NOT EXISTS allowance_runs (
+                                period VARCHAR(32) PRIMARY KEY,
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
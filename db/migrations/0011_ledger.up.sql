@@ -0,0 +1,40 @@
+-- Двойная запись. Счета: кошелёк пользователя, выручка магазина и эмиссия, откуда монеты приходят в систему.
+-- Каждая проводка journal_entries состоит из postings с нулевой суммой, баланс счёта - сумма его postings.
+-- users.amount остаётся быстрым балансом кошелька, сверка проверяет, что он равен сумме postings
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+                                 id SERIAL PRIMARY KEY,
+                                 kind VARCHAR(16) NOT NULL,
+                                 user_id INT UNIQUE,
+                                 created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                 CONSTRAINT ledger_accounts_kind_valid CHECK (kind IN ('wallet', 'revenue', 'issuance')),
+                                 CONSTRAINT ledger_accounts_wallet_user CHECK ((kind = 'wallet') = (user_id IS NOT NULL)),
+                                 FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_system ON ledger_accounts (kind) WHERE user_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+                                 id SERIAL PRIMARY KEY,
+                                 kind VARCHAR(16) NOT NULL,
+                                 reference_id INT,
+                                 description TEXT NOT NULL DEFAULT '',
+                                 created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_kind_valid;
+ALTER TABLE journal_entries ADD CONSTRAINT journal_entries_kind_valid
+    CHECK (kind IN ('opening', 'transfer', 'purchase', 'grant', 'allowance'));
+
+CREATE TABLE IF NOT EXISTS postings (
+                          id SERIAL PRIMARY KEY,
+                          entry_id INT NOT NULL,
+                          account_id INT NOT NULL,
+                          amount INT NOT NULL,
+                          CONSTRAINT postings_amount_non_zero CHECK (amount <> 0),
+                          FOREIGN KEY (entry_id) REFERENCES journal_entries(id),
+                          FOREIGN KEY (account_id) REFERENCES ledger_accounts(id)
+);
+CREATE INDEX IF NOT EXISTS idx_postings_entry ON postings (entry_id);
+CREATE INDEX IF NOT EXISTS idx_postings_account ON postings (account_id);
+
+-- системные счета, у каждого вида ровно один счёт без пользователя
+INSERT INTO ledger_accounts(kind) VALUES ('issuance'), ('revenue')
+ON CONFLICT (kind) WHERE user_id IS NULL DO NOTHING;
@@ -0,0 +1,6 @@
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_per_user_limit_positive;
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_stock_non_negative;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS stock,
+    DROP COLUMN IF EXISTS per_user_limit;
@@ -0,0 +1,9 @@
+-- Остаток на складе и лимит покупок на пользователя, NULL - без ограничения
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS stock INT,
+    ADD COLUMN IF NOT EXISTS per_user_limit INT;
+
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_stock_non_negative;
+ALTER TABLE items ADD CONSTRAINT items_stock_non_negative CHECK (stock >= 0);
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_per_user_limit_positive;
+ALTER TABLE items ADD CONSTRAINT items_per_user_limit_positive CHECK (per_user_limit > 0);
@@ -0,0 +1,10 @@
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_kind_valid;
+ALTER TABLE journal_entries ADD CONSTRAINT journal_entries_kind_valid
+    CHECK (kind IN ('opening', 'transfer', 'purchase', 'grant', 'allowance'));
+
+DROP TABLE IF EXISTS cart_items;
+
+DROP INDEX IF EXISTS idx_order_purchases;
+ALTER TABLE purchases DROP COLUMN IF EXISTS order_id;
+
+DROP TABLE IF EXISTS orders;
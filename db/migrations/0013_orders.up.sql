@@ -0,0 +1,31 @@
+-- Создание таблицы Orders: заказ, оформленный из корзины, оплачивается одним списанием total
+CREATE TABLE IF NOT EXISTS orders (
+                        id SERIAL PRIMARY KEY,
+                        user_id INT NOT NULL,
+                        total INT NOT NULL,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                        CONSTRAINT orders_total_positive CHECK (total > 0),
+                        FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_orders ON orders (user_id, id);
+
+-- Строки заказа - тоже покупки, у них задан order_id
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS order_id INT REFERENCES orders(id);
+CREATE INDEX IF NOT EXISTS idx_order_purchases ON purchases (order_id) WHERE order_id IS NOT NULL;
+
+
+-- Создание таблицы CartItems: корзина пользователя, по строке на товар
+CREATE TABLE IF NOT EXISTS cart_items (
+                            user_id INT NOT NULL,
+                            item_id INT NOT NULL,
+                            quantity INT NOT NULL,
+                            created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                            PRIMARY KEY (user_id, item_id),
+                            CONSTRAINT cart_items_quantity_positive CHECK (quantity > 0),
+                            FOREIGN KEY (user_id) REFERENCES users(id),
+                            FOREIGN KEY (item_id) REFERENCES items(id)
+);
+
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_kind_valid;
+ALTER TABLE journal_entries ADD CONSTRAINT journal_entries_kind_valid
+    CHECK (kind IN ('opening', 'transfer', 'purchase', 'order', 'grant', 'allowance'));
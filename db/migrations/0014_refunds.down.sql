@@ -0,0 +1,8 @@
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_kind_valid;
+ALTER TABLE journal_entries ADD CONSTRAINT journal_entries_kind_valid
+    CHECK (kind IN ('opening', 'transfer', 'purchase', 'order', 'grant', 'allowance'));
+
+DROP TABLE IF EXISTS refunds;
+
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_refunded_quantity_valid;
+ALTER TABLE purchases DROP COLUMN IF EXISTS refunded_quantity;
@@ -0,0 +1,26 @@
+-- refunded_quantity - сколько штук покупки уже возвращено
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS refunded_quantity INT NOT NULL DEFAULT 0;
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_refunded_quantity_valid;
+ALTER TABLE purchases ADD CONSTRAINT purchases_refunded_quantity_valid
+    CHECK (refunded_quantity >= 0 AND refunded_quantity <= quantity);
+
+
+-- Создание таблицы Refunds: возвраты покупок, возврат может быть частичным
+CREATE TABLE IF NOT EXISTS refunds (
+                         id SERIAL PRIMARY KEY,
+                         purchase_id INT NOT NULL,
+                         quantity INT NOT NULL,
+                         amount INT NOT NULL,
+                         reason TEXT NOT NULL,
+                         refunded_by INT NOT NULL,
+                         created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                         CONSTRAINT refunds_quantity_positive CHECK (quantity > 0),
+                         CONSTRAINT refunds_amount_positive CHECK (amount > 0),
+                         FOREIGN KEY (purchase_id) REFERENCES purchases(id),
+                         FOREIGN KEY (refunded_by) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_purchase_refunds ON refunds (purchase_id);
+
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_kind_valid;
+ALTER TABLE journal_entries ADD CONSTRAINT journal_entries_kind_valid
+    CHECK (kind IN ('opening', 'transfer', 'purchase', 'order', 'refund', 'grant', 'allowance'));
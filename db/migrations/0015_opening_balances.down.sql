@@ -0,0 +1,4 @@
+DELETE FROM postings
+WHERE entry_id IN (SELECT id FROM journal_entries WHERE kind = 'opening' AND description = 'opening balance (migration)');
+
+DELETE FROM journal_entries WHERE kind = 'opening' AND description = 'opening balance (migration)';
@@ -0,0 +1,27 @@
+-- Проводки opening пишутся при регистрации только с появлением журнала, у пользователей, зарегистрированных
+-- раньше, есть users.amount, но нет ни одного posting, и сверка показала бы расхождение по каждому из них.
+-- Таким пользователям проводится opening из эмиссии на текущий баланс. Таблица users блокируется,
+-- чтобы между расчётом и записью проводок баланс никто не изменил
+LOCK TABLE users IN SHARE MODE;
+
+INSERT INTO ledger_accounts(kind, user_id)
+SELECT 'wallet', u.id FROM users u
+ON CONFLICT DO NOTHING;
+
+WITH pending AS (
+    SELECT u.id AS user_id, u.amount, a.id AS account_id
+    FROM users u
+    JOIN ledger_accounts a ON a.user_id = u.id
+    WHERE u.amount > 0
+      AND NOT EXISTS (SELECT 1 FROM postings p WHERE p.account_id = a.id)
+), entries AS (
+    INSERT INTO journal_entries(kind, reference_id, description)
+    SELECT 'opening', user_id, 'opening balance (migration)' FROM pending
+    RETURNING id, reference_id
+)
+INSERT INTO postings(entry_id, account_id, amount)
+SELECT e.id, (SELECT id FROM ledger_accounts WHERE kind = 'issuance' AND user_id IS NULL), -p.amount
+FROM entries e JOIN pending p ON p.user_id = e.reference_id
+UNION ALL
+SELECT e.id, p.account_id, p.amount
+FROM entries e JOIN pending p ON p.user_id = e.reference_id;
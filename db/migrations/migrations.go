@@ -0,0 +1,8 @@
+// Package migrations схема базы в виде версионированных миграций, они встраиваются в бинарник.
+// Файл миграции называется <версия>_<название>.up.sql, откат - <версия>_<название>.down.sql
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -96,15 +96,13 @@ func Test_SendCoins(t *testing.T) {
 
 	// Проверяем таблицу операций
 	sendInfoUser1 := infoResp1.CoinHistory.Sent.SentItems[0]
-	assert.Equal(t, sendInfoUser1, entity.SentItem{
-		ToUser: "user_2",
-		Amount: 100,
-	})
+	assert.Equal(t, "user_2", sendInfoUser1.ToUser)
+	assert.Equal(t, 100, sendInfoUser1.Amount)
+	assert.NotZero(t, sendInfoUser1.Id)
 
 	sendInfoUser2 := infoResp2.CoinHistory.Received.ReceivedItems[0]
-	assert.Equal(t, sendInfoUser2, entity.ReceivedItem{
-		FromUser: "user_1",
-		Amount:   100,
-	})
+	assert.Equal(t, "user_1", sendInfoUser2.FromUser)
+	assert.Equal(t, 100, sendInfoUser2.Amount)
+	assert.Equal(t, sendInfoUser1.Id, sendInfoUser2.Id)
 
 }
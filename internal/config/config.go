@@ -4,13 +4,49 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/k1v4/avito_shop/pkg/DB/postgres"
 	"github.com/k1v4/avito_shop/pkg/DB/redis"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	"github.com/k1v4/avito_shop/pkg/telemetry"
+	"time"
 )
 
 type Config struct {
 	postgres.DBConfig
 	redis.RedisConfig
+	jwtPkg.JWTConfig
+	telemetry.TracingConfig
 
 	RestServerPort int `env:"REST_SERVER_PORT" env-description:"rest server port" env-default:"8080"`
+	GrpcServerPort int `env:"GRPC_SERVER_PORT" env-description:"grpc server port" env-default:"50051"`
+
+	// MigrateOnStart применять миграции из db/migrations при старте. Без него схему обновляет подкоманда migrate up
+	MigrateOnStart bool `env:"MIGRATE_ON_START" env-description:"apply pending schema migrations on startup" env-default:"false"`
+
+	AllowanceConfig
+
+	HealthConfig
+
+	// ReconcileInterval как часто сверять балансы с журналом двойной записи, 0 - только подкомандой reconcile
+	ReconcileInterval time.Duration `env:"RECONCILE_INTERVAL" env-description:"how often balances are reconciled with the ledger, 0 disables the job" env-default:"0"`
+
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" env-description:"how long responses to Idempotency-Key requests are kept" env-default:"24h"`
+
+	// RefundWindow сколько после покупки пользователь может сам её вернуть, 0 - возвращает только администратор
+	RefundWindow time.Duration `env:"REFUND_WINDOW" env-description:"how long after a purchase users can refund it themselves, 0 allows only admins" env-default:"24h"`
+}
+
+// AllowanceConfig регулярное начисление монет всем пользователям. Amount = 0 выключает начисление
+type AllowanceConfig struct {
+	AllowanceAmount        int           `env:"ALLOWANCE_AMOUNT" env-description:"coins granted to every user each period, 0 disables allowances" env-default:"0"`
+	AllowancePeriod        string        `env:"ALLOWANCE_PERIOD" env-description:"allowance period: daily, weekly or monthly" env-default:"monthly"`
+	AllowanceReason        string        `env:"ALLOWANCE_REASON" env-description:"reason recorded for allowance grants" env-default:"allowance"`
+	AllowanceCheckInterval time.Duration `env:"ALLOWANCE_CHECK_INTERVAL" env-description:"how often the scheduler checks whether the current period is granted" env-default:"1m"`
+}
+
+// HealthConfig проверки /readyz и плавная остановка HTTP-сервера
+type HealthConfig struct {
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" env-description:"how long /readyz waits for each dependency" env-default:"1s"`
+	// ShutdownDrainDelay сколько после сигнала остановки /readyz отвечает 503, а сервер ещё принимает запросы
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" env-description:"how long the server keeps serving as not ready before it stops" env-default:"5s"`
 }
 
 func MustLoadConfig() *Config {
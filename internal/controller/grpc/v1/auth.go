@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"context"
+	"github.com/k1v4/avito_shop/internal/entity"
+	shopv1 "github.com/k1v4/avito_shop/pkg/api/shop/v1"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+)
+
+const authorizationKey = "authorization"
+
+// publicMethods методы ShopService, доступные без токена
+var publicMethods = map[string]struct{}{
+	shopv1.ShopService_Auth_FullMethodName:    {},
+	shopv1.ShopService_Refresh_FullMethodName: {},
+}
+
+// requiresAuth сообщает, нужен ли методу токен. Health и reflection открыты,
+// как и /.well-known/jwks.json в REST
+func requiresAuth(fullMethod string) bool {
+	if !strings.HasPrefix(fullMethod, "/"+shopv1.ShopService_ServiceDesc.ServiceName+"/") {
+		return false
+	}
+
+	_, ok := publicMethods[fullMethod]
+
+	return !ok
+}
+
+// authenticate проверяет bearer-токен из метаданных authorization и кладёт пользователя в контекст
+func authenticate(ctx context.Context, tokens *jwtPkg.Manager) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get(authorizationKey)
+	if len(values) == 0 || values[0] == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	claims, err := tokens.ValidateToken(ctx, strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	return entity.WithPrincipal(ctx, toPrincipal(claims)), nil
+}
+
+// toPrincipal пользователь из claims проверенного токена
+func toPrincipal(claims jwtPkg.Claims) entity.Principal {
+	return entity.Principal{
+		Id:       claims.UserId,
+		Username: claims.Username,
+		Roles:    claims.Roles,
+	}
+}
+
+func authUnaryInterceptor(tokens *jwtPkg.Manager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !requiresAuth(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(tokens *jwtPkg.Manager) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !requiresAuth(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), tokens)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream подменяет контекст потока на контекст с пользователем
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// principal пользователь, которого положил перехватчик аутентификации
+func principal(ctx context.Context) entity.Principal {
+	p, _ := entity.PrincipalFromContext(ctx)
+
+	return p
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// errorDomain домен в ErrorInfo, Reason в нём - код ошибки, тот же, что в поле code ответов REST
+const errorDomain = "avito_shop"
+
+var (
+	ErrInvalidArgument = usecase.NewError(usecase.CodeBadRequest, http.StatusBadRequest, "bad request")
+	ErrUnauthenticated = usecase.NewError(usecase.CodeUnauthorized, http.StatusUnauthorized, "unauthorized")
+)
+
+// grpcCodes соответствие HTTP-статусов ошибок usecase кодам gRPC
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusUnprocessableEntity: codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+// toStatus переводит ошибку в статус gRPC по тому же HTTP-статусу, что отдаёт REST, и кладёт код ошибки
+// в ErrorInfo. Ошибки, которые уже несут статус, не меняются, а подробности внутренних ошибок клиенту не отдаются
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	var e *usecase.Error
+	if !errors.As(err, &e) {
+		e = usecase.NewError(usecase.CodeInternal, http.StatusInternalServerError, "internal error")
+	}
+
+	code, ok := grpcCodes[e.Status]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st, detErr := status.New(code, e.Message).WithDetails(&errdetails.ErrorInfo{
+		Reason: e.Code,
+		Domain: errorDomain,
+	})
+	if detErr != nil {
+		return status.Error(code, e.Message)
+	}
+
+	return st.Err()
+}
+
+func errorsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+
+		return resp, toStatus(err)
+	}
+}
+
+func errorsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(handler(srv, ss))
+	}
+}
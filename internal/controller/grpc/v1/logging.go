@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"context"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+func loggingUnaryInterceptor(l logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, l, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+func loggingStreamInterceptor(l logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		logCall(ss.Context(), l, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// logCall пишет одну строку на вызов, как middleware.Logger в REST. Код ответа считается
+// тем же toStatus, который потом применит errorsUnaryInterceptor. Ошибки клиента пишутся в Warn,
+// в Error попадают только сбои сервера
+func logCall(ctx context.Context, l logger.Logger, method string, start time.Time, err error) {
+	code := status.Code(toStatus(err))
+
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("latency", time.Since(start)),
+	}
+
+	switch {
+	case isServerFault(code):
+		l.Error(ctx, "grpc call failed", append(fields, zap.Error(err))...)
+	case err != nil:
+		l.Warn(ctx, "grpc call rejected", append(fields, zap.Error(err))...)
+	default:
+		l.Info(ctx, "grpc call", fields...)
+	}
+}
+
+// isServerFault сообщает, что код означает сбой на стороне сервера, аналог 5xx в REST
+func isServerFault(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
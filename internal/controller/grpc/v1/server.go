@@ -0,0 +1,30 @@
+package v1
+
+import (
+	"github.com/k1v4/avito_shop/internal/usecase"
+	shopv1 "github.com/k1v4/avito_shop/pkg/api/shop/v1"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"google.golang.org/grpc"
+)
+
+// NewServer собирает gRPC-сервер с ShopService. Ошибки переводятся в статусы самым внешним
+// перехватчиком, чтобы в лог попадала исходная ошибка, а не её обезличенный статус
+func NewServer(l logger.Logger, t usecase.IShopService, tokens *jwtPkg.Manager) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			errorsUnaryInterceptor(),
+			loggingUnaryInterceptor(l),
+			authUnaryInterceptor(tokens),
+		),
+		grpc.ChainStreamInterceptor(
+			errorsStreamInterceptor(),
+			loggingStreamInterceptor(l),
+			authStreamInterceptor(tokens),
+		),
+	)
+
+	shopv1.RegisterShopServiceServer(server, &shopServer{t: t, l: l})
+
+	return server
+}
@@ -0,0 +1,242 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	shopv1 "github.com/k1v4/avito_shop/pkg/api/shop/v1"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strings"
+)
+
+type shopServer struct {
+	shopv1.UnimplementedShopServiceServer
+
+	t usecase.IShopService
+	l logger.Logger
+}
+
+func (s *shopServer) Auth(ctx context.Context, req *shopv1.AuthRequest) (*shopv1.AuthResponse, error) {
+	const op = "grpc.Auth"
+
+	if len(strings.TrimSpace(req.GetUsername())) == 0 || len(strings.TrimSpace(req.GetPassword())) == 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	tokens, err := s.t.Login(ctx, req.GetUsername(), req.GetPassword())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toAuthResponse(tokens), nil
+}
+
+func (s *shopServer) Refresh(ctx context.Context, req *shopv1.RefreshRequest) (*shopv1.AuthResponse, error) {
+	const op = "grpc.Refresh"
+
+	if len(strings.TrimSpace(req.GetRefreshToken())) == 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	tokens, err := s.t.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return toAuthResponse(tokens), nil
+}
+
+func (s *shopServer) Buy(ctx context.Context, req *shopv1.BuyRequest) (*shopv1.BuyResponse, error) {
+	const op = "grpc.Buy"
+
+	if len(strings.TrimSpace(req.GetItem())) == 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	quantity := int(req.GetQuantity())
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	err := s.t.BuyItem(ctx, principal(ctx).Id, req.GetItem(), quantity)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &shopv1.BuyResponse{}, nil
+}
+
+func (s *shopServer) SendCoins(ctx context.Context, req *shopv1.SendCoinsRequest) (*shopv1.SendCoinsResponse, error) {
+	const op = "grpc.SendCoins"
+
+	if len(strings.TrimSpace(req.GetToUser())) == 0 || req.GetAmount() <= 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	err := s.t.SendCoins(ctx, req.GetToUser(), principal(ctx).Id, int(req.GetAmount()))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &shopv1.SendCoinsResponse{}, nil
+}
+
+func (s *shopServer) GetInfo(ctx context.Context, _ *shopv1.GetInfoRequest) (*shopv1.GetInfoResponse, error) {
+	const op = "grpc.GetInfo"
+
+	info, err := s.t.GetInfo(ctx, principal(ctx).Id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp := &shopv1.GetInfoResponse{
+		Coins:               int64(info.Coins),
+		CoinHistory:         &shopv1.CoinHistory{},
+		NextPurchasesBefore: int64(info.Purchases.NextBefore),
+		NextOrdersBefore:    int64(info.Orders.NextBefore),
+	}
+
+	for _, item := range info.Inventory.Items {
+		resp.Inventory = append(resp.Inventory, &shopv1.InventoryItem{
+			Type:     item.Type,
+			Quantity: int64(item.Quantity),
+		})
+	}
+
+	for _, item := range info.CoinHistory.Received.ReceivedItems {
+		resp.CoinHistory.Received = append(resp.CoinHistory.Received, receivedItem(item))
+	}
+
+	for _, item := range info.CoinHistory.Sent.SentItems {
+		resp.CoinHistory.Sent = append(resp.CoinHistory.Sent, sentItem(item))
+	}
+
+	for _, p := range info.Purchases.Items {
+		resp.Purchases = append(resp.Purchases, &shopv1.Purchase{
+			Id:        int64(p.Id),
+			Type:      p.Type,
+			Price:     int64(p.Price),
+			Quantity:  int64(p.Quantity),
+			CreatedAt: timestamppb.New(p.CreatedAt),
+		})
+	}
+
+	for _, o := range info.Orders.Items {
+		resp.Orders = append(resp.Orders, order(o))
+	}
+
+	return resp, nil
+}
+
+// StreamHistory читает историю теми же страницами, что и /api/coinHistory, и отправляет
+// переводы по одному, пока страницы не кончатся или клиент не отменит вызов
+func (s *shopServer) StreamHistory(req *shopv1.StreamHistoryRequest, stream grpc.ServerStreamingServer[shopv1.HistoryItem]) error {
+	const op = "grpc.StreamHistory"
+
+	ctx := stream.Context()
+
+	direction := req.GetDirection()
+	if direction != shopv1.Direction_DIRECTION_SENT && direction != shopv1.Direction_DIRECTION_RECEIVED {
+		return ErrInvalidArgument
+	}
+
+	if req.GetBefore() < 0 || req.GetPageSize() < 0 {
+		return ErrInvalidArgument
+	}
+
+	userId := principal(ctx).Id
+	before, limit := int(req.GetBefore()), int(req.GetPageSize())
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		var (
+			items      []*shopv1.HistoryItem
+			nextBefore int
+		)
+
+		if direction == shopv1.Direction_DIRECTION_SENT {
+			page, err := s.t.GetSentHistory(ctx, userId, before, limit)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			for _, item := range page.Items {
+				items = append(items, sentItem(item))
+			}
+			nextBefore = page.NextBefore
+		} else {
+			page, err := s.t.GetReceivedHistory(ctx, userId, before, limit)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			for _, item := range page.Items {
+				items = append(items, receivedItem(item))
+			}
+			nextBefore = page.NextBefore
+		}
+
+		for _, item := range items {
+			if err := stream.Send(item); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if nextBefore == 0 {
+			return nil
+		}
+
+		before = nextBefore
+	}
+}
+
+func toAuthResponse(tokens entity.AuthResponse) *shopv1.AuthResponse {
+	return &shopv1.AuthResponse{
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+	}
+}
+
+func sentItem(item entity.SentItem) *shopv1.HistoryItem {
+	return &shopv1.HistoryItem{
+		Id:        int64(item.Id),
+		Direction: shopv1.Direction_DIRECTION_SENT,
+		User:      item.ToUser,
+		Amount:    int64(item.Amount),
+		CreatedAt: timestamppb.New(item.CreatedAt),
+	}
+}
+
+func receivedItem(item entity.ReceivedItem) *shopv1.HistoryItem {
+	return &shopv1.HistoryItem{
+		Id:        int64(item.Id),
+		Direction: shopv1.Direction_DIRECTION_RECEIVED,
+		User:      item.FromUser,
+		Amount:    int64(item.Amount),
+		CreatedAt: timestamppb.New(item.CreatedAt),
+	}
+}
+
+func order(o entity.Order) *shopv1.Order {
+	res := &shopv1.Order{
+		Id:        int64(o.Id),
+		Total:     int64(o.Total),
+		CreatedAt: timestamppb.New(o.CreatedAt),
+	}
+
+	for _, line := range o.Lines {
+		res.Lines = append(res.Lines, &shopv1.OrderLine{
+			Type:     line.Type,
+			Price:    int64(line.Price),
+			Quantity: int64(line.Quantity),
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,440 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/internal/usecase/mocks"
+	shopv1 "github.com/k1v4/avito_shop/pkg/api/shop/v1"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	loggermocks "github.com/k1v4/avito_shop/pkg/logger/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	testTokens, _ = jwtPkg.NewManager(jwtPkg.JWTConfig{Algorithm: jwtPkg.AlgorithmHS256, Secret: "secret"})
+	validToken, _ = testTokens.NewToken(jwtPkg.Claims{UserId: 12212, Username: "Trevor68"}, time.Hour)
+)
+
+// newTestClient поднимает сервер на bufconn и возвращает клиента к нему
+func newTestClient(t *testing.T, svc usecase.IShopService) shopv1.ShopServiceClient {
+	t.Helper()
+
+	l := loggermocks.NewLogger(t)
+	l.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	l.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	l.On("Warn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+	lis := bufconn.Listen(1024 * 1024)
+
+	server := NewServer(l, svc, testTokens)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return shopv1.NewShopServiceClient(conn)
+}
+
+// errorReason код ошибки из ErrorInfo в деталях статуса
+func errorReason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, authorizationKey, "Bearer "+token)
+}
+
+func TestAuth(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        *shopv1.AuthRequest
+		mockTokens entity.AuthResponse
+		mockErr    error
+		code       codes.Code
+		isMock     bool
+	}{
+		{
+			name:       "success",
+			req:        &shopv1.AuthRequest{Username: "user", Password: "password"},
+			mockTokens: entity.AuthResponse{Token: "token", RefreshToken: "refresh"},
+			code:       codes.OK,
+			isMock:     true,
+		},
+		{
+			name: "empty password",
+			req:  &shopv1.AuthRequest{Username: "user"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:    "wrong password",
+			req:     &shopv1.AuthRequest{Username: "user", Password: "password"},
+			mockErr: usecase.ErrWrongPassword,
+			code:    codes.Unauthenticated,
+			isMock:  true,
+		},
+		{
+			name:    "internal error",
+			req:     &shopv1.AuthRequest{Username: "user", Password: "password"},
+			mockErr: errors.New("db is down"),
+			code:    codes.Internal,
+			isMock:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := mocks.NewIShopService(t)
+			if tc.isMock {
+				svc.On("Login", mock.Anything, tc.req.Username, tc.req.Password).Return(tc.mockTokens, tc.mockErr)
+			}
+
+			client := newTestClient(t, svc)
+
+			resp, err := client.Auth(context.Background(), tc.req)
+			assert.Equal(t, tc.code, status.Code(err))
+
+			if tc.code == codes.OK {
+				assert.Equal(t, tc.mockTokens.Token, resp.GetToken())
+				assert.Equal(t, tc.mockTokens.RefreshToken, resp.GetRefreshToken())
+			}
+
+			if tc.code == codes.Internal {
+				// подробности внутренних ошибок клиенту не отдаются
+				assert.Equal(t, "internal error", status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestBuy(t *testing.T) {
+	cases := []struct {
+		name    string
+		token   string
+		item    string
+		mockErr error
+		code    codes.Code
+		isMock  bool
+	}{
+		{
+			name:   "success",
+			token:  validToken,
+			item:   "t-shirt",
+			code:   codes.OK,
+			isMock: true,
+		},
+		{
+			name: "no token",
+			item: "t-shirt",
+			code: codes.Unauthenticated,
+		},
+		{
+			name:  "invalid token",
+			token: "invalid",
+			item:  "t-shirt",
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:  "empty item",
+			token: validToken,
+			code:  codes.InvalidArgument,
+		},
+		{
+			name:    "not enough coins",
+			token:   validToken,
+			item:    "t-shirt",
+			mockErr: usecase.ErrNoCoins,
+			code:    codes.FailedPrecondition,
+			isMock:  true,
+		},
+		{
+			name:    "no item",
+			token:   validToken,
+			item:    "t-shirt",
+			mockErr: fmt.Errorf("ShopUseCase.BuyItem: %w", usecase.ErrNoItem),
+			code:    codes.NotFound,
+			isMock:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := mocks.NewIShopService(t)
+			if tc.isMock {
+				svc.On("BuyItem", mock.Anything, 12212, tc.item, 1).Return(tc.mockErr)
+			}
+
+			client := newTestClient(t, svc)
+
+			ctx := context.Background()
+			if tc.token != "" {
+				ctx = withToken(ctx, tc.token)
+			}
+
+			_, err := client.Buy(ctx, &shopv1.BuyRequest{Item: tc.item})
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
+
+func TestSendCoins(t *testing.T) {
+	cases := []struct {
+		name    string
+		req     *shopv1.SendCoinsRequest
+		mockErr error
+		code    codes.Code
+		reason  string
+		isMock  bool
+	}{
+		{
+			name:   "success",
+			req:    &shopv1.SendCoinsRequest{ToUser: "user2", Amount: 10},
+			code:   codes.OK,
+			isMock: true,
+		},
+		{
+			name:   "non-positive amount",
+			req:    &shopv1.SendCoinsRequest{ToUser: "user2", Amount: 0},
+			code:   codes.InvalidArgument,
+			reason: usecase.CodeBadRequest,
+		},
+		{
+			name:    "no user",
+			req:     &shopv1.SendCoinsRequest{ToUser: "user2", Amount: 10},
+			mockErr: usecase.ErrNoUser,
+			code:    codes.NotFound,
+			reason:  usecase.CodeUserNotFound,
+			isMock:  true,
+		},
+		{
+			name:    "self transfer",
+			req:     &shopv1.SendCoinsRequest{ToUser: "Trevor68", Amount: 10},
+			mockErr: usecase.ErrSelfTransfer,
+			code:    codes.InvalidArgument,
+			reason:  usecase.CodeSelfTransfer,
+			isMock:  true,
+		},
+		{
+			name:    "not enough coins",
+			req:     &shopv1.SendCoinsRequest{ToUser: "user2", Amount: 10},
+			mockErr: usecase.ErrNoCoins,
+			code:    codes.FailedPrecondition,
+			reason:  usecase.CodeInsufficientFunds,
+			isMock:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := mocks.NewIShopService(t)
+			if tc.isMock {
+				svc.On("SendCoins", mock.Anything, tc.req.ToUser, 12212, int(tc.req.Amount)).Return(tc.mockErr)
+			}
+
+			client := newTestClient(t, svc)
+
+			_, err := client.SendCoins(withToken(context.Background(), validToken), tc.req)
+			assert.Equal(t, tc.code, status.Code(err))
+			assert.Equal(t, tc.reason, errorReason(err))
+		})
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	createdAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	svc := mocks.NewIShopService(t)
+	svc.On("GetInfo", mock.Anything, 12212).Return(entity.ResponseInfo{
+		Coins: 100,
+		Inventory: entity.Inventory{
+			Items: []entity.InventoryItem{{ItemId: 1, Type: "cup", Quantity: 2}},
+		},
+		CoinHistory: entity.CoinHistory{
+			Received: entity.Received{
+				ReceivedItems: []entity.ReceivedItem{{Id: 2, FromUser: "user2", Amount: 50, CreatedAt: createdAt}},
+			},
+			Sent: entity.Sent{
+				SentItems: []entity.SentItem{{Id: 1, ToUser: "user3", Amount: 30, CreatedAt: createdAt}},
+			},
+		},
+		Purchases: entity.PurchasePage{
+			Items:      []entity.Purchase{{Id: 7, Type: "cup", Price: 20, Quantity: 1, CreatedAt: createdAt}},
+			NextBefore: 7,
+		},
+		Orders: entity.OrderPage{
+			Items: []entity.Order{{
+				Id:        3,
+				Total:     60,
+				Lines:     []entity.OrderLine{{ItemId: 2, Type: "cup", Price: 20, Quantity: 3}},
+				CreatedAt: createdAt,
+			}},
+			NextBefore: 3,
+		},
+	}, nil)
+
+	client := newTestClient(t, svc)
+
+	resp, err := client.GetInfo(withToken(context.Background(), validToken), &shopv1.GetInfoRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(100), resp.GetCoins())
+	require.Len(t, resp.GetInventory(), 1)
+	assert.Equal(t, "cup", resp.GetInventory()[0].GetType())
+	assert.Equal(t, int64(2), resp.GetInventory()[0].GetQuantity())
+
+	require.Len(t, resp.GetCoinHistory().GetReceived(), 1)
+	assert.Equal(t, "user2", resp.GetCoinHistory().GetReceived()[0].GetUser())
+	assert.Equal(t, shopv1.Direction_DIRECTION_RECEIVED, resp.GetCoinHistory().GetReceived()[0].GetDirection())
+	require.Len(t, resp.GetCoinHistory().GetSent(), 1)
+	assert.Equal(t, "user3", resp.GetCoinHistory().GetSent()[0].GetUser())
+	assert.Equal(t, createdAt, resp.GetCoinHistory().GetSent()[0].GetCreatedAt().AsTime())
+
+	require.Len(t, resp.GetPurchases(), 1)
+	assert.Equal(t, int64(7), resp.GetPurchases()[0].GetId())
+	assert.Equal(t, int64(7), resp.GetNextPurchasesBefore())
+
+	require.Len(t, resp.GetOrders(), 1)
+	assert.Equal(t, int64(60), resp.GetOrders()[0].GetTotal())
+	require.Len(t, resp.GetOrders()[0].GetLines(), 1)
+	assert.Equal(t, "cup", resp.GetOrders()[0].GetLines()[0].GetType())
+	assert.Equal(t, int64(3), resp.GetOrders()[0].GetLines()[0].GetQuantity())
+	assert.Equal(t, createdAt, resp.GetOrders()[0].GetCreatedAt().AsTime())
+	assert.Equal(t, int64(3), resp.GetNextOrdersBefore())
+}
+
+func TestStreamHistory(t *testing.T) {
+	t.Run("pages", func(t *testing.T) {
+		svc := mocks.NewIShopService(t)
+		svc.On("GetSentHistory", mock.Anything, 12212, 0, 2).Return(entity.Page[entity.SentItem]{
+			Items:      []entity.SentItem{{Id: 5, ToUser: "a", Amount: 1}, {Id: 4, ToUser: "b", Amount: 2}},
+			NextBefore: 4,
+		}, nil)
+		svc.On("GetSentHistory", mock.Anything, 12212, 4, 2).Return(entity.Page[entity.SentItem]{
+			Items: []entity.SentItem{{Id: 1, ToUser: "c", Amount: 3}},
+		}, nil)
+
+		client := newTestClient(t, svc)
+
+		stream, err := client.StreamHistory(withToken(context.Background(), validToken), &shopv1.StreamHistoryRequest{
+			Direction: shopv1.Direction_DIRECTION_SENT,
+			PageSize:  2,
+		})
+		require.NoError(t, err)
+
+		var ids []int64
+		for {
+			item, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, item.GetId())
+		}
+
+		assert.Equal(t, []int64{5, 4, 1}, ids)
+	})
+
+	t.Run("no direction", func(t *testing.T) {
+		client := newTestClient(t, mocks.NewIShopService(t))
+
+		stream, err := client.StreamHistory(withToken(context.Background(), validToken), &shopv1.StreamHistoryRequest{})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		client := newTestClient(t, mocks.NewIShopService(t))
+
+		stream, err := client.StreamHistory(context.Background(), &shopv1.StreamHistoryRequest{
+			Direction: shopv1.Direction_DIRECTION_RECEIVED,
+		})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		svc := mocks.NewIShopService(t)
+		svc.On("GetReceivedHistory", mock.Anything, 12212, 0, 0).
+			Return(entity.Page[entity.ReceivedItem]{}, errors.New("db is down"))
+
+		client := newTestClient(t, svc)
+
+		stream, err := client.StreamHistory(withToken(context.Background(), validToken), &shopv1.StreamHistoryRequest{
+			Direction: shopv1.Direction_DIRECTION_RECEIVED,
+		})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestRequiresAuth(t *testing.T) {
+	assert.False(t, requiresAuth(shopv1.ShopService_Auth_FullMethodName))
+	assert.False(t, requiresAuth(shopv1.ShopService_Refresh_FullMethodName))
+	assert.False(t, requiresAuth("/grpc.health.v1.Health/Check"))
+	assert.True(t, requiresAuth(shopv1.ShopService_Buy_FullMethodName))
+	assert.True(t, requiresAuth(shopv1.ShopService_StreamHistory_FullMethodName))
+}
+
+func TestLogCall(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level string
+	}{
+		{name: "ok", err: nil, level: "Info"},
+		{name: "not_found", err: usecase.ErrNoUser, level: "Warn"},
+		{name: "no_coins", err: usecase.ErrNoCoins, level: "Warn"},
+		{name: "unauthenticated", err: status.Error(codes.Unauthenticated, "no token"), level: "Warn"},
+		{name: "canceled", err: context.Canceled, level: "Warn"},
+		{name: "unavailable", err: status.Error(codes.Unavailable, "down"), level: "Error"},
+		{name: "internal", err: errors.New("db is down"), level: "Error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := loggermocks.NewLogger(t)
+			if tc.err == nil {
+				l.On(tc.level, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Once()
+			} else {
+				l.On(tc.level, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Once()
+			}
+
+			logCall(context.Background(), l, "/shop.v1.ShopService/GetInfo", time.Now(), tc.err)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	principalKey = "principal"
+	authRealm    = "avito_shop"
+)
+
+// publicRoutes маршруты /api, доступные без токена
+var publicRoutes = map[string]struct{}{
+	"/api/auth":         {},
+	"/api/auth/refresh": {},
+	"/api/items":        {},
+}
+
+// authMiddleware проверяет bearer-токен и кладёт пользователя в контекст echo и в контекст запроса.
+// Без токена или с невалидным токеном отвечает 401 с заголовком WWW-Authenticate по RFC 6750
+func authMiddleware(tokens *jwtPkg.Manager) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = "middleware.Auth"
+
+			if _, ok := publicRoutes[c.Path()]; ok {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+
+			token := jwtPkg.ExtractToken(c)
+			if token == "" {
+				challenge(c, fmt.Sprintf(`Bearer realm=%q`, authRealm))
+
+				return fmt.Errorf("%s: %w", op, ErrNoToken)
+			}
+
+			claims, err := tokens.ValidateToken(ctx, token)
+			if err != nil {
+				challenge(c, fmt.Sprintf(`Bearer realm=%q, error="invalid_token"`, authRealm))
+
+				return fmt.Errorf("%s: %w: %s", op, ErrInvalidToken, err)
+			}
+
+			p := toPrincipal(claims)
+			c.Set(principalKey, p)
+
+			// дальше логгер и спан запроса пишут и пользователя
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Int("user.id", p.Id))
+			ctx = logger.WithLogger(ctx, logger.GetLoggerFromContext(ctx).With(zap.Int("user_id", p.Id)))
+			c.SetRequest(c.Request().WithContext(entity.WithPrincipal(ctx, p)))
+
+			return next(c)
+		}
+	}
+}
+
+// requirePermission пускает только пользователей, чьи роли дают право perm. Должен стоять после authMiddleware
+func requirePermission(perm string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = "middleware.RequirePermission"
+
+			if !principal(c).Can(perm) {
+				return fmt.Errorf("%s: %w: %s", op, ErrForbidden, perm)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// principal пользователь, которого положил authMiddleware
+func principal(c echo.Context) entity.Principal {
+	p, _ := c.Get(principalKey).(entity.Principal)
+
+	return p
+}
+
+// toPrincipal пользователь из claims проверенного токена
+func toPrincipal(claims jwtPkg.Claims) entity.Principal {
+	return entity.Principal{
+		Id:       claims.UserId,
+		Username: claims.Username,
+		Roles:    claims.Roles,
+	}
+}
+
+// challenge выставляет WWW-Authenticate, сам ответ 401 пишет httpErrorHandler
+func challenge(c echo.Context, value string) {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, value)
+}
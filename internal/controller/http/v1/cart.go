@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+type cartRoutes struct {
+	t usecase.IShopService
+	l logger.Logger
+}
+
+func newCartRoutes(handler *echo.Group, t usecase.IShopService, l logger.Logger) {
+	r := &cartRoutes{t, l}
+
+	// GET /api/cart
+	handler.GET("/cart", r.Cart)
+
+	// POST /api/cart
+	handler.POST("/cart", r.AddToCart)
+
+	// DELETE /api/cart/{item}
+	handler.DELETE("/cart/:item", r.RemoveFromCart)
+
+	// POST /api/checkout
+	handler.POST("/checkout", r.Checkout, idempotencyMiddleware(t))
+
+	// GET /api/orders?before={id}&limit={n}
+	handler.GET("/orders", r.Orders)
+}
+
+func (r *cartRoutes) Cart(c echo.Context) error {
+	const op = "handler.Cart"
+
+	cart, err := r.t.GetCart(c.Request().Context(), principal(c).Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+func (r *cartRoutes) AddToCart(c echo.Context) error {
+	const op = "handler.AddToCart"
+
+	var req entity.CartRequest
+	if err := c.Bind(&req); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
+
+	if len(strings.TrimSpace(req.Item)) == 0 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidBody.WithMessage("item is required"))
+	}
+
+	// без quantity добавляется одна штука
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	cart, err := r.t.AddToCart(c.Request().Context(), principal(c).Id, req.Item, req.Quantity)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+func (r *cartRoutes) RemoveFromCart(c echo.Context) error {
+	const op = "handler.RemoveFromCart"
+
+	err := r.t.RemoveFromCart(c.Request().Context(), principal(c).Id, c.Param("item"))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Checkout оформляет заказ из корзины и возвращает его чек
+func (r *cartRoutes) Checkout(c echo.Context) error {
+	const op = "handler.Checkout"
+
+	order, err := r.t.Checkout(c.Request().Context(), principal(c).Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+func (r *cartRoutes) Orders(c echo.Context) error {
+	const op = "handler.Orders"
+
+	before, err := queryInt(c, "before")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	page, err := r.t.GetOrders(c.Request().Context(), principal(c).Id, before, limit)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
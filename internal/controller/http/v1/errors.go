@@ -2,14 +2,90 @@ package v1
 
 import (
 	"errors"
+	"fmt"
 	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
 	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+const (
+	mimeProblemJSON  = "application/problem+json"
+	problemTypeBlank = "about:blank"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidCredentials = usecase.NewError(usecase.CodeBadRequest, http.StatusBadRequest, "username and password are required")
+	ErrInvalidQueryParam  = usecase.NewError(usecase.CodeBadRequest, http.StatusBadRequest, "invalid query parameter")
+	ErrInvalidBody        = usecase.NewError(usecase.CodeBadRequest, http.StatusBadRequest, "invalid request body")
+	ErrNoToken            = usecase.NewError(usecase.CodeUnauthorized, http.StatusUnauthorized, "token is required")
+	ErrInvalidToken       = usecase.NewError(usecase.CodeUnauthorized, http.StatusUnauthorized, "invalid token")
+	ErrForbidden          = usecase.NewError(usecase.CodeForbidden, http.StatusForbidden, "forbidden")
+	ErrInvalidPathParam   = usecase.NewError(usecase.CodeBadRequest, http.StatusBadRequest, "invalid path parameter")
+
+	ErrInvalidIdempotencyKey = usecase.NewError(usecase.CodeBadRequest, http.StatusBadRequest, "invalid idempotency key")
+	ErrRequestTooLarge       = usecase.NewError(usecase.CodeRequestTooLarge, http.StatusRequestEntityTooLarge, "request body is too large")
 )
 
-func errorResponse(c echo.Context, code int, msg string) error {
-	return c.JSON(code, entity.ErrorResponse{Error: msg})
+// httpErrorHandler единственное место, где ошибки превращаются в ответ. Обработчики только возвращают ошибку:
+// ошибки usecase.Error отдаются со своим кодом и статусом, ошибки echo - со своим статусом,
+// остальные - как 500 без подробностей
+func httpErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	p := problem(err)
+	p.Instance = c.Request().URL.Path
+
+	if c.Request().Method == http.MethodHead {
+		c.NoContent(p.Status)
+
+		return
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, mimeProblemJSON)
+	c.JSON(p.Status, p)
+}
+
+func problem(err error) entity.Problem {
+	var (
+		e  *usecase.Error
+		he *echo.HTTPError
+	)
+
+	switch {
+	case errors.As(err, &e):
+		return newProblem(e.Status, e.Code, e.Message)
+	case errors.As(err, &he):
+		detail := http.StatusText(he.Code)
+		if msg, ok := he.Message.(string); ok {
+			detail = msg
+		}
+
+		return newProblem(he.Code, codeForStatus(he.Code), detail)
+	}
+
+	return newProblem(http.StatusInternalServerError, usecase.CodeInternal, "internal error")
+}
+
+func newProblem(status int, code, detail string) entity.Problem {
+	return entity.Problem{
+		Type:   problemTypeBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// codeForStatus код для ошибок без своего кода, например 404 на неизвестный маршрут: NOT_FOUND
+func codeForStatus(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return fmt.Sprintf("HTTP_%d", status)
+	}
+
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
 }
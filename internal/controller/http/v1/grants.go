@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	grantsFileField   = "file"
+	grantsReasonField = "reason"
+)
+
+type grantRoutes struct {
+	t usecase.IShopService
+	l logger.Logger
+}
+
+func newAdminGrantRoutes(handler *echo.Group, t usecase.IShopService, l logger.Logger) {
+	r := &grantRoutes{t, l}
+
+	// POST /api/admin/grants
+	handler.POST("/grants", r.Grant, idempotencyMiddleware(t))
+}
+
+// Grant начисляет монеты. Принимает JSON {"reason": ..., "grants": [{"toUser": ..., "amount": ...}]}
+// или multipart/form-data с полем reason и CSV-файлом file из строк username,amount
+func (r *grantRoutes) Grant(c echo.Context) error {
+	const op = "handler.Grant"
+
+	var req entity.GrantRequest
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		lines, err := grantLinesFromCSV(c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		req = entity.GrantRequest{Reason: c.FormValue(grantsReasonField), Grants: lines}
+	} else if err := c.Bind(&req); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
+
+	grants, err := r.t.GrantCoins(c.Request().Context(), principal(c).Id, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusCreated, entity.Page[entity.Grant]{Items: grants})
+}
+
+// grantLinesFromCSV читает строки username,amount из загруженного файла. Первая строка
+// пропускается, если это заголовок, то есть amount в ней не число
+func grantLinesFromCSV(c echo.Context) ([]entity.GrantLine, error) {
+	fh, err := c.FormFile(grantsFileField)
+	if err != nil {
+		return nil, ErrInvalidBody.WithMessage(fmt.Sprintf("%s is required", grantsFileField))
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var lines []entity.GrantLine
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidBody.WithMessage(fmt.Sprintf("invalid csv: %s", err))
+		}
+
+		amount, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			if n == 1 {
+				continue
+			}
+
+			return nil, ErrInvalidBody.WithMessage(fmt.Sprintf("csv line %d: amount must be an integer", n))
+		}
+
+		lines = append(lines, entity.GrantLine{ToUser: strings.TrimSpace(record[0]), Amount: amount})
+	}
+
+	return lines, nil
+}
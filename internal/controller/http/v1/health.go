@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"github.com/k1v4/avito_shop/pkg/health"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+const (
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+)
+
+type healthRoutes struct {
+	checker *health.Checker
+}
+
+func newHealthRoutes(handler *echo.Echo, checker *health.Checker) {
+	r := &healthRoutes{checker}
+
+	// GET /healthz
+	handler.GET(livenessPath, r.Liveness)
+	// GET /readyz
+	handler.GET(readinessPath, r.Readiness)
+}
+
+// Liveness отвечает, пока процесс жив и обслуживает запросы, зависимости не проверяются,
+// чтобы оркестратор не перезапускал под из-за недоступной базы
+func (r *healthRoutes) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": health.StatusUp})
+}
+
+// Readiness проверяет зависимости и отвечает 503, если недоступна хоть одна обязательная или сервис останавливается
+func (r *healthRoutes) Readiness(c echo.Context) error {
+	report := r.checker.Check(c.Request().Context())
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+
+	return c.JSON(status, report)
+}
+
+// isProbePath запросы к /metrics и пробам шлёт инфраструктура каждые несколько секунд,
+// они не считаются в метриках и не трассируются
+func isProbePath(path string) bool {
+	return path == metricsPath || path == livenessPath || path == readinessPath
+}
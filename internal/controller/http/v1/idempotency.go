@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+)
+
+const (
+	headerIdempotencyKey      = "Idempotency-Key"
+	headerIdempotentReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// idempotencyMiddleware делает изменяющий маршрут идемпотентным по заголовку Idempotency-Key.
+// Ответ обработчика буферизуется и сохраняется в той же транзакции, что и изменения, повтор с тем же ключом
+// получает сохранённый ответ, а тот же ключ с другим запросом - IDEMPOTENCY_KEY_REUSED. Запросы без заголовка проходят как есть.
+// Должен стоять после authMiddleware: ключи хранятся отдельно для каждого пользователя
+func idempotencyMiddleware(t usecase.IShopService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = "middleware.Idempotency"
+
+			key := c.Request().Header.Get(headerIdempotencyKey)
+			if key == "" {
+				return next(c)
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				return fmt.Errorf("%s: %w", op, ErrInvalidIdempotencyKey)
+			}
+
+			hash, err := requestHash(c)
+			if errors.Is(err, errBodyTooLarge) {
+				return fmt.Errorf("%s: %w", op, ErrRequestTooLarge)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+			}
+
+			res := c.Response()
+			buf := &responseBuffer{ResponseWriter: res.Writer, status: http.StatusOK}
+			res.Writer = buf
+
+			stored, replayed, err := t.Idempotent(c.Request().Context(), principal(c).Id, key, hash,
+				func(ctx context.Context) (entity.IdempotentResponse, error) {
+					c.SetRequest(c.Request().WithContext(ctx))
+
+					err := next(c)
+					if err != nil {
+						return entity.IdempotentResponse{}, err
+					}
+
+					return entity.IdempotentResponse{StatusCode: buf.status, Body: buf.body.Bytes()}, nil
+				})
+
+			res.Writer = buf.ResponseWriter
+
+			switch {
+			case err != nil:
+				// ответ, который обработчик успел записать, отдаём как есть, остальное допишет httpErrorHandler
+				if res.Committed {
+					res.Size = buf.flush()
+				}
+
+				return fmt.Errorf("%s: %w", op, err)
+			case replayed:
+				res.Header().Set(headerIdempotentReplayed, "true")
+
+				return c.JSONBlob(stored.StatusCode, stored.Body)
+			default:
+				res.Size = buf.flush()
+
+				return nil
+			}
+		}
+	}
+}
+
+// errBodyTooLarge тело запроса с Idempotency-Key больше maxIdempotentRequestBytes
+var errBodyTooLarge = errors.New("request body is too large")
+
+// requestHash хэш метода, пути и тела запроса. Тело после чтения возвращается в запрос для обработчика.
+// Тело больше лимита не обрезается, а отвергается: иначе обработчик получил бы только его начало
+func requestHash(c echo.Context) ([]byte, error) {
+	req := c.Request()
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxIdempotentRequestBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > maxIdempotentRequestBytes {
+		return nil, errBodyTooLarge
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	h.Write(body)
+
+	return h.Sum(nil), nil
+}
+
+// responseBuffer копит ответ обработчика, пока не станет ясно, закоммичена ли транзакция
+type responseBuffer struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) flush() int64 {
+	b.ResponseWriter.WriteHeader(b.status)
+	n, _ := b.ResponseWriter.Write(b.body.Bytes())
+
+	return int64(n)
+}
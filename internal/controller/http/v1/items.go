@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type itemRoutes struct {
+	t usecase.IShopService
+	l logger.Logger
+}
+
+func newItemRoutes(handler *echo.Group, t usecase.IShopService, l logger.Logger) {
+	r := &itemRoutes{t, l}
+
+	// GET /api/items
+	handler.GET("/items", r.List)
+}
+
+func newAdminItemRoutes(handler *echo.Group, t usecase.IShopService, l logger.Logger) {
+	r := &itemRoutes{t, l}
+
+	// GET /api/admin/items
+	handler.GET("/items", r.AdminList)
+
+	// POST /api/admin/items
+	handler.POST("/items", r.Create)
+
+	// GET /api/admin/items/{id}
+	handler.GET("/items/:id", r.Get)
+
+	// PATCH /api/admin/items/{id}
+	handler.PATCH("/items/:id", r.Update)
+
+	// DELETE /api/admin/items/{id}
+	handler.DELETE("/items/:id", r.Delete)
+}
+
+// List каталог для покупателей: только активные товары
+func (r *itemRoutes) List(c echo.Context) error {
+	const op = "handler.ListItems"
+
+	items, err := r.t.ListItems(c.Request().Context(), false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusOK, entity.Page[entity.Item]{Items: items})
+}
+
+// AdminList каталог вместе с неактивными товарами
+func (r *itemRoutes) AdminList(c echo.Context) error {
+	const op = "handler.AdminListItems"
+
+	items, err := r.t.ListItems(c.Request().Context(), true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusOK, entity.Page[entity.Item]{Items: items})
+}
+
+func (r *itemRoutes) Get(c echo.Context) error {
+	const op = "handler.GetItem"
+
+	itemId, err := paramId(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	item, err := r.t.GetItem(c.Request().Context(), itemId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusOK, item)
+}
+
+func (r *itemRoutes) Create(c echo.Context) error {
+	const op = "handler.CreateItem"
+
+	req := new(entity.ItemRequest)
+	if err := c.Bind(req); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
+
+	item, err := r.t.CreateItem(c.Request().Context(), *req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusCreated, item)
+}
+
+func (r *itemRoutes) Update(c echo.Context) error {
+	const op = "handler.UpdateItem"
+
+	itemId, err := paramId(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// id берётся только из пути, поэтому тело привязывается отдельно от параметров
+	req := new(entity.ItemRequest)
+	if err = (&echo.DefaultBinder{}).BindBody(c, req); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
+
+	item, err := r.t.UpdateItem(c.Request().Context(), itemId, *req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.JSON(http.StatusOK, item)
+}
+
+func (r *itemRoutes) Delete(c echo.Context) error {
+	const op = "handler.DeleteItem"
+
+	itemId, err := paramId(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.t.DeleteItem(c.Request().Context(), itemId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
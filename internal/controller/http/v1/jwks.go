@@ -0,0 +1,28 @@
+package v1
+
+import (
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// jwksMaxAge сколько другие сервисы могут кэшировать набор ключей.
+// При ротации новый ключ нужно добавить в набор хотя бы на это время раньше, чем начать им подписывать
+const jwksMaxAge = "public, max-age=300"
+
+type jwksRoutes struct {
+	tokens *jwtPkg.Manager
+}
+
+func newJWKSRoutes(handler *echo.Echo, tokens *jwtPkg.Manager) {
+	r := &jwksRoutes{tokens}
+
+	// GET /.well-known/jwks.json
+	handler.GET("/.well-known/jwks.json", r.JWKS)
+}
+
+func (r *jwksRoutes) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", jwksMaxAge)
+
+	return c.JSON(http.StatusOK, r.tokens.JWKS())
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxRequestIDLength длиннее X-Request-ID клиента не принимаем и выдаём свой
+	maxRequestIDLength = 128
+	requestIDSize      = 16
+)
+
+// requestLogger берёт id запроса из X-Request-ID или генерирует новый и возвращает его в ответе.
+// В контекст запроса кладутся id и логгер с маршрутом, которым могут пользоваться обработчики и usecase.
+// По завершении пишется одна строка на запрос; ошибка, которую вернул обработчик, логируется только здесь:
+// 5xx как Error, 4xx как Warn
+func requestLogger(l logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if id == "" || len(id) > maxRequestIDLength {
+				id = newRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			rl := l.With(
+				zap.String("method", req.Method),
+				zap.String("route", c.Path()),
+			)
+
+			ctx := logger.WithRequestID(req.Context(), id)
+			c.SetRequest(req.WithContext(logger.WithLogger(ctx, rl)))
+
+			err := next(c)
+			if err != nil {
+				// ответ пишет httpErrorHandler, после этого статус известен; повторно echo его не вызовет
+				c.Error(err)
+			}
+
+			fields := []zap.Field{
+				zap.Int("status", c.Response().Status),
+				zap.Duration("latency", time.Since(start)),
+			}
+			if p := principal(c); p.Id != 0 {
+				fields = append(fields, zap.Int("user_id", p.Id))
+			}
+
+			// контекст берётся из запроса, а не ctx: authMiddleware мог его дополнить
+			ctx = c.Request().Context()
+
+			switch status := c.Response().Status; {
+			case status >= http.StatusInternalServerError:
+				rl.Error(ctx, "http request failed", append(fields, zap.Error(err))...)
+			case err != nil:
+				rl.Warn(ctx, "http request rejected", append(fields, zap.Error(err))...)
+			default:
+				rl.Info(ctx, "http request", fields...)
+			}
+
+			return nil
+		}
+	}
+}
+
+// newRequestID генерирует id запроса. Если случайные байты получить не удалось, запрос всё равно обслуживается
+func newRequestID() string {
+	b := make([]byte, requestIDSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
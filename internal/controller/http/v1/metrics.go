@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"github.com/k1v4/avito_shop/internal/usecase/metrics"
+	"github.com/labstack/echo/v4"
+	"time"
+)
+
+const (
+	metricsPath = "/metrics"
+
+	// unmatchedRoute метка запросов, не попавших ни в один маршрут, чтобы произвольные пути не плодили ряды
+	unmatchedRoute = "unmatched"
+)
+
+// metricsMiddleware считает запросы и их время по шаблону маршрута и статусу.
+// Должен стоять перед requestLogger: тот сам отдаёт ошибку клиенту, и здесь статус уже окончательный
+func metricsMiddleware(m *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isProbePath(c.Path()) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			m.ObserveHTTP(route, c.Request().Method, c.Response().Status, time.Since(start))
+
+			return err
+		}
+	}
+}
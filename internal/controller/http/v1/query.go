@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"strconv"
+)
+
+// queryInt читает неотрицательный целый query-параметр, отсутствующий параметр равен 0
+func queryInt(c echo.Context, name string) (int, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return 0, ErrInvalidQueryParam.WithMessage(fmt.Sprintf("invalid query parameter %s", name))
+	}
+
+	return v, nil
+}
+
+// paramId читает положительный id из пути
+func paramId(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidPathParam.WithMessage("id must be a positive integer")
+	}
+
+	return id, nil
+}
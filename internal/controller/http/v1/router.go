@@ -1,19 +1,88 @@
 package v1
 
 import (
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
 	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/internal/usecase/metrics"
+	"github.com/k1v4/avito_shop/pkg/health"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
 	"github.com/k1v4/avito_shop/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewRouter(handler *echo.Echo, l logger.Logger, t usecase.IShopService) {
+// RouterOption необязательная настройка NewRouter
+type RouterOption func(*routerOptions)
+
+type routerOptions struct {
+	metrics        *metrics.Metrics
+	tracerProvider trace.TracerProvider
+	health         *health.Checker
+}
+
+// WithMetrics включает HTTP-метрики и отдаёт все метрики сервиса на /metrics
+func WithMetrics(m *metrics.Metrics) RouterOption {
+	return func(o *routerOptions) {
+		o.metrics = m
+	}
+}
+
+// WithTracerProvider задаёт провайдер спанов запросов, по умолчанию берётся глобальный
+func WithTracerProvider(tp trace.TracerProvider) RouterOption {
+	return func(o *routerOptions) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithHealth задаёт проверки зависимостей для /readyz, без неё /readyz проверяет только то, что сервис не остановлен
+func WithHealth(checker *health.Checker) RouterOption {
+	return func(o *routerOptions) {
+		o.health = checker
+	}
+}
+
+func NewRouter(handler *echo.Echo, l logger.Logger, t usecase.IShopService, tokens *jwtPkg.Manager, opts ...RouterOption) {
+	o := routerOptions{tracerProvider: otel.GetTracerProvider(), health: health.New()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	handler.HTTPErrorHandler = httpErrorHandler
+
 	// Middleware
-	handler.Use(middleware.Logger())
-	handler.Use(middleware.Recover())
+	if o.metrics != nil {
+		handler.Use(metricsMiddleware(o.metrics))
+		handler.GET(metricsPath, echo.WrapHandler(o.metrics.Handler()))
+	}
+	// спан запроса открывается до requestLogger, чтобы id трассы попали в его записи
+	handler.Use(tracingMiddleware(o.tracerProvider))
+	handler.Use(requestLogger(l))
+	// паника превращается в ошибку, которую залогирует и отдаст как 500 requestLogger, стек попадает в её текст
+	handler.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		DisableErrorHandler: true,
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			return fmt.Errorf("panic: %w\n%s", err, stack)
+		},
+	}))
+
+	newHealthRoutes(handler, o.health)
+	newJWKSRoutes(handler, tokens)
 
-	h := handler.Group("/api")
+	h := handler.Group("/api", authMiddleware(tokens))
 	{
 		newShopRoutes(h, t, l)
+		newItemRoutes(h, t, l)
+		newCartRoutes(h, t, l)
+	}
+
+	// группы /api/admin закрыты правами, которые дают роли пользователя, см. entity.rolePermissions
+	a := h.Group("/admin")
+	{
+		newAdminItemRoutes(a.Group("", requirePermission(entity.PermManageCatalog)), t, l)
+		newAdminUserRoutes(a.Group("", requirePermission(entity.PermManageUsers)), t, l)
+		newAdminGrantRoutes(a.Group("", requirePermission(entity.PermGrantCoins)), t, l)
 	}
 }
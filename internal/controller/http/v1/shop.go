@@ -1,7 +1,6 @@
 package v1
 
 import (
-	"errors"
 	"fmt"
 	"github.com/k1v4/avito_shop/internal/entity"
 	"github.com/k1v4/avito_shop/internal/usecase"
@@ -20,17 +19,34 @@ type conatainerRoutes struct {
 func newShopRoutes(handler *echo.Group, t usecase.IShopService, l logger.Logger) {
 	r := &conatainerRoutes{t, l}
 
+	idempotent := idempotencyMiddleware(t)
+
 	// POST /api/auth
 	handler.POST("/auth", r.Auth)
 
-	//GET /api/buy/{item}
-	handler.GET("/buy/:item", r.Buy)
+	// POST /api/auth/refresh
+	handler.POST("/auth/refresh", r.Refresh)
+
+	// POST /api/auth/logout
+	handler.POST("/auth/logout", r.Logout)
+
+	//GET /api/buy/{item}?quantity={n}
+	handler.GET("/buy/:item", r.Buy, idempotent)
 
 	//POST /api/sendCoin"
-	handler.POST("/sendCoin", r.SendCoins)
+	handler.POST("/sendCoin", r.SendCoins, idempotent)
 
 	//GET  /api/info
 	handler.GET("/info", r.Info)
+
+	//GET  /api/purchases?before={id}&limit={n}
+	handler.GET("/purchases", r.Purchases)
+
+	//POST /api/purchases/{id}/refund
+	handler.POST("/purchases/:id/refund", r.Refund, idempotent)
+
+	//GET  /api/coinHistory?direction={sent|received}&before={id}&limit={n}
+	handler.GET("/coinHistory", r.CoinHistory)
 }
 
 func (r *conatainerRoutes) Info(c echo.Context) error {
@@ -38,107 +54,145 @@ func (r *conatainerRoutes) Info(c echo.Context) error {
 
 	ctx := c.Request().Context()
 
-	token := jwtPkg.ExtractToken(c)
-	if token == "" {
-		errorResponse(c, http.StatusBadRequest, "bad request")
+	userId := principal(c).Id
 
-		return fmt.Errorf("%s: %s", op, "token is required")
+	info, err := r.t.GetInfo(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	userId, err := jwtPkg.ValidateTokenAndGetUserId(token)
+	return c.JSON(http.StatusOK, info)
+}
+
+func (r *conatainerRoutes) Purchases(c echo.Context) error {
+	const op = "handler.Purchases"
+
+	ctx := c.Request().Context()
+
+	before, err := queryInt(c, "before")
 	if err != nil {
-		errorResponse(c, http.StatusUnauthorized, "bad request")
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-		return fmt.Errorf("%s: %s", op, err)
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	info, err := r.t.GetInfo(ctx, userId)
+	userId := principal(c).Id
+
+	page, err := r.t.GetPurchases(ctx, userId, before, limit)
 	if err != nil {
-		return fmt.Errorf("%s: %s", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return c.JSON(http.StatusOK, info)
+	return c.JSON(http.StatusOK, page)
 }
 
-func (r *conatainerRoutes) SendCoins(c echo.Context) error {
-	const op = "handler.SendCoins"
+// Refund возвращает покупку. Своя покупка возвращается в пределах окна возврата, чужая - только администратором
+func (r *conatainerRoutes) Refund(c echo.Context) error {
+	const op = "handler.Refund"
 
-	ctx := c.Request().Context()
+	purchaseId, err := paramId(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	u := new(entity.SendCoinRequest)
-	if err := c.Bind(u); err != nil {
-		errorResponse(c, http.StatusBadRequest, "bad request")
+	var req entity.RefundRequest
+	if err = c.Bind(&req); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
 
+	refund, err := r.t.RefundPurchase(c.Request().Context(), principal(c), purchaseId, req)
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if u.Amount <= 0 {
-		errorResponse(c, http.StatusBadRequest, "bad request")
+	return c.JSON(http.StatusCreated, refund)
+}
 
-		return fmt.Errorf("%s: %w", op, errors.New("amount must be greater than 0"))
-	}
+func (r *conatainerRoutes) CoinHistory(c echo.Context) error {
+	const op = "handler.CoinHistory"
 
-	token := jwtPkg.ExtractToken(c)
-	if token == "" {
-		errorResponse(c, http.StatusUnauthorized, "bad request")
+	ctx := c.Request().Context()
 
-		return fmt.Errorf("%s: %s", op, "token is required")
+	direction := c.QueryParam("direction")
+	if direction != entity.DirectionSent && direction != entity.DirectionReceived {
+		return fmt.Errorf("%s: %w", op, ErrInvalidQueryParam.WithMessage("direction must be sent or received"))
 	}
 
-	userId, err := jwtPkg.ValidateTokenAndGetUserId(token)
+	before, err := queryInt(c, "before")
 	if err != nil {
-		errorResponse(c, http.StatusUnauthorized, "bad request")
-
-		return fmt.Errorf("%s: %s", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = r.t.SendCoins(ctx, u.ToUserName, userId, u.Amount)
+	limit, err := queryInt(c, "limit")
 	if err != nil {
-		errorResponse(c, http.StatusInternalServerError, "internal error")
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
+	userId := principal(c).Id
+
+	var page interface{}
+	if direction == entity.DirectionSent {
+		page, err = r.t.GetSentHistory(ctx, userId, before, limit)
+	} else {
+		page, err = r.t.GetReceivedHistory(ctx, userId, before, limit)
+	}
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, page)
 }
 
-func (r *conatainerRoutes) Buy(c echo.Context) error {
-	const op = "handler.Buy"
+func (r *conatainerRoutes) SendCoins(c echo.Context) error {
+	const op = "handler.SendCoins"
+
 	ctx := c.Request().Context()
-	itemName := c.Param("item")
 
-	if len(strings.TrimSpace(itemName)) == 0 {
-		errorResponse(c, http.StatusBadRequest, "bad request")
+	u := new(entity.SendCoinRequest)
+	if err := c.Bind(u); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
 
-		return fmt.Errorf("%s: %s", op, "item name is required")
+	if u.Amount <= 0 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidBody.WithMessage("amount must be greater than 0"))
 	}
 
-	token := jwtPkg.ExtractToken(c)
-	if token == "" {
-		errorResponse(c, http.StatusUnauthorized, "bad request")
+	userId := principal(c).Id
 
-		return fmt.Errorf("%s: %s", op, "token is required")
+	err := r.t.SendCoins(ctx, u.ToUserName, userId, u.Amount)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	// получаем ник
-	userId, err := jwtPkg.ValidateTokenAndGetUserId(token)
-	if err != nil {
-		errorResponse(c, http.StatusUnauthorized, "bad request")
+	return c.JSON(http.StatusOK, map[string]interface{}{})
+}
 
-		return fmt.Errorf("%s: %s", op, err)
-	}
+func (r *conatainerRoutes) Buy(c echo.Context) error {
+	const op = "handler.Buy"
+	ctx := c.Request().Context()
+	itemName := c.Param("item")
 
-	err = r.t.BuyItem(ctx, userId, itemName)
-	if err != nil {
-		if errors.Is(err, usecase.ErrNoCoins) {
-			errorResponse(c, http.StatusBadRequest, usecase.ErrNoCoins.Error())
+	if len(strings.TrimSpace(itemName)) == 0 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidBody.WithMessage("item name is required"))
+	}
 
-			return fmt.Errorf("%s: %s", op, err)
+	// без quantity покупается одна штука
+	quantity := 1
+	if c.QueryParam("quantity") != "" {
+		var err error
+		if quantity, err = queryInt(c, "quantity"); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
 		}
+	}
 
-		errorResponse(c, http.StatusInternalServerError, "internal error")
+	userId := principal(c).Id
 
-		return fmt.Errorf("%s: %s", op, err)
+	err := r.t.BuyItem(ctx, userId, itemName, quantity)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{})
@@ -151,31 +205,59 @@ func (r *conatainerRoutes) Auth(c echo.Context) error {
 
 	u := new(entity.AuthRequest)
 	if err := c.Bind(u); err != nil {
-		errorResponse(c, http.StatusBadRequest, "bad request")
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
+
+	if len(strings.TrimSpace(u.Username)) == 0 || len(strings.TrimSpace(u.Password)) == 0 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
 
+	tokens, err := r.t.Login(ctx, u.Username, u.Password)
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if len(strings.TrimSpace(u.Username)) == 0 || len(strings.TrimSpace(u.Password)) == 0 {
-		errorResponse(c, http.StatusBadRequest, "bad request")
+	return c.JSON(http.StatusOK, tokens)
+}
 
-		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+func (r *conatainerRoutes) Refresh(c echo.Context) error {
+	const op = "handler.Refresh"
+
+	ctx := c.Request().Context()
+
+	u := new(entity.RefreshRequest)
+	if err := c.Bind(u); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
+
+	if len(strings.TrimSpace(u.RefreshToken)) == 0 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidBody.WithMessage("refresh token is required"))
 	}
 
-	token, err := r.t.Login(ctx, u.Username, u.Password)
+	tokens, err := r.t.Refresh(ctx, u.RefreshToken)
 	if err != nil {
-		if errors.Is(err, usecase.ErrWrongPassword) {
-			errorResponse(c, http.StatusUnauthorized, "bad request")
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-			return fmt.Errorf("%s: %w", op, err)
-		}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+func (r *conatainerRoutes) Logout(c echo.Context) error {
+	const op = "handler.Logout"
 
-		errorResponse(c, http.StatusInternalServerError, "internal error")
+	ctx := c.Request().Context()
+
+	// refresh-токен необязателен, без него отзывается только access-токен
+	u := new(entity.RefreshRequest)
+	if err := c.Bind(u); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
 
+	// сам access-токен нужен, чтобы отозвать его, проверил его уже authMiddleware
+	err := r.t.Logout(ctx, principal(c).Id, jwtPkg.ExtractToken(c), u.RefreshToken)
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return c.JSON(http.StatusOK, entity.AuthResponse{
-		Token: token,
-	})
+	return c.JSON(http.StatusOK, map[string]interface{}{})
 }
@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type userRoutes struct {
+	t usecase.IShopService
+	l logger.Logger
+}
+
+func newAdminUserRoutes(handler *echo.Group, t usecase.IShopService, l logger.Logger) {
+	r := &userRoutes{t, l}
+
+	// PUT /api/admin/users/{username}/role
+	handler.PUT("/users/:username/role", r.SetRole)
+}
+
+// SetRole меняет роль пользователя. Токены с прежней ролью действуют до истечения, новая роль
+// приходит с ближайшим входом или обновлением токена
+func (r *userRoutes) SetRole(c echo.Context) error {
+	const op = "handler.SetRole"
+
+	req := new(entity.RoleRequest)
+	if err := (&echo.DefaultBinder{}).BindBody(c, req); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, ErrInvalidBody, err)
+	}
+
+	err := r.t.SetRole(c.Request().Context(), c.Param("username"), req.Role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
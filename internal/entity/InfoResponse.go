@@ -1,11 +1,16 @@
 package entity
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type ResponseInfo struct {
-	Coins       int         `json:"coins"`
-	Inventory   Inventory   `json:"inventory"`
-	CoinHistory CoinHistory `json:"coinHistory"`
+	Coins       int          `json:"coins"`
+	Inventory   Inventory    `json:"inventory"`
+	CoinHistory CoinHistory  `json:"coinHistory"`
+	Purchases   PurchasePage `json:"purchases"`
+	Orders      OrderPage    `json:"orders"`
 }
 
 func (o *ResponseInfo) MarshalBinary() ([]byte, error) {
@@ -36,8 +41,10 @@ type Received struct {
 }
 
 type ReceivedItem struct {
-	FromUser string `json:"fromUser"`
-	Amount   int    `json:"amount"`
+	Id        int       `json:"id"`
+	FromUser  string    `json:"fromUser"`
+	Amount    int       `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 type Sent struct {
@@ -45,6 +52,8 @@ type Sent struct {
 }
 
 type SentItem struct {
-	ToUser string `json:"toUser"`
-	Amount int    `json:"amount"`
+	Id        int       `json:"id"`
+	ToUser    string    `json:"toUser"`
+	Amount    int       `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
 }
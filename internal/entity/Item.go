@@ -1,7 +1,34 @@
 package entity
 
+import "time"
+
 type Item struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	Price int    `json:"price"`
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Price       int    `json:"price"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+	Active      bool   `json:"active"`
+	// Stock остаток на складе, nil - товар не ограничен
+	Stock *int `json:"stock,omitempty"`
+	// PerUserLimit сколько штук может купить один пользователь, nil - без ограничения
+	PerUserLimit *int      `json:"perUserLimit,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ItemRequest тело запросов на создание и изменение товара. При изменении nil-поля остаются как были,
+// при создании обязательны Name и Price, а Active по умолчанию true
+type ItemRequest struct {
+	Name        *string `json:"name"`
+	Price       *int    `json:"price"`
+	Description *string `json:"description"`
+	ImageURL    *string `json:"imageUrl"`
+	Active      *bool   `json:"active"`
+	// Stock и PerUserLimit снимаются значением Unlimited
+	Stock        *int `json:"stock"`
+	PerUserLimit *int `json:"perUserLimit"`
 }
+
+// Unlimited значение Stock и PerUserLimit в ItemRequest, которое снимает ограничение
+const Unlimited = -1
@@ -6,11 +6,18 @@ type AuthRequest struct {
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// Problem тело ответа с ошибкой по RFC 7807. Code - стабильный код ошибки, по которому клиенты её различают
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
 }
 
 type SendCoinRequest struct {
@@ -18,8 +25,12 @@ type SendCoinRequest struct {
 	Amount     int    `json:"amount"`
 }
 
-type BothDirection struct {
-	ToUser   int `json:"toUser"`
-	FromUser int `json:"fromUser"`
-	Amount   int `json:"amount"`
+// RoleRequest тело запроса на смену роли пользователя
+type RoleRequest struct {
+	Role string `json:"role"`
 }
+
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
@@ -2,6 +2,7 @@ package entity
 
 import (
 	"testing"
+	"time"
 )
 
 func TestMarshalBinary(t *testing.T) {
@@ -16,12 +17,12 @@ func TestMarshalBinary(t *testing.T) {
 		CoinHistory: CoinHistory{
 			Received: Received{
 				ReceivedItems: []ReceivedItem{
-					{FromUser: "user1", Amount: 50},
+					{Id: 2, FromUser: "user1", Amount: 50, CreatedAt: time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)},
 				},
 			},
 			Sent: Sent{
 				SentItems: []SentItem{
-					{ToUser: "user2", Amount: 30},
+					{Id: 1, ToUser: "user2", Amount: 30, CreatedAt: time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)},
 				},
 			},
 		},
@@ -32,7 +33,7 @@ func TestMarshalBinary(t *testing.T) {
 		t.Errorf("Failed to marshal ResponseInfo: %v", err)
 	}
 
-	expectedJSON := `{"coins":100,"inventory":{"items":[{"type":"gold","quantity":10},{"type":"silver","quantity":20}]},"coinHistory":{"received":{"items":[{"fromUser":"user1","amount":50}]},"sent":{"items":[{"toUser":"user2","amount":30}]}}}`
+	expectedJSON := `{"coins":100,"inventory":{"items":[{"type":"gold","quantity":10},{"type":"silver","quantity":20}]},"coinHistory":{"received":{"items":[{"id":2,"fromUser":"user1","amount":50,"createdAt":"2025-02-01T10:00:00Z"}]},"sent":{"items":[{"id":1,"toUser":"user2","amount":30,"createdAt":"2025-02-01T09:00:00Z"}]}},"purchases":{"items":null},"orders":{"items":null}}`
 	if string(data) != expectedJSON {
 		t.Errorf("Expected %s but got %s", expectedJSON, string(data))
 	}
@@ -60,3 +61,64 @@ func TestUnmarshalBinary(t *testing.T) {
 		t.Errorf("Expected first sent item amount to be 30, got %d", response.CoinHistory.Sent.SentItems[0].Amount)
 	}
 }
+
+func TestPrincipalPermissions(t *testing.T) {
+	admin := Principal{Id: 1, Roles: []string{RoleAdmin}}
+	hr := Principal{Id: 2, Roles: []string{RoleHR}}
+	employee := Principal{Id: 3, Roles: []string{RoleEmployee}}
+
+	if !admin.Can(PermManageCatalog) || !admin.Can(PermManageUsers) || !admin.Can(PermGrantCoins) || !admin.Can(PermRefundPurchases) {
+		t.Errorf("admin must have every permission")
+	}
+	if !hr.Can(PermGrantCoins) || hr.Can(PermManageCatalog) || hr.Can(PermRefundPurchases) {
+		t.Errorf("hr must only grant coins")
+	}
+	if employee.Can(PermGrantCoins) || (Principal{}).Can(PermGrantCoins) {
+		t.Errorf("employee must have no permissions")
+	}
+	if !hr.HasRole(RoleAdmin, RoleHR) || employee.HasRole(RoleAdmin, RoleHR) {
+		t.Errorf("unexpected HasRole result")
+	}
+	if !ValidRole(RoleHR) || ValidRole("root") {
+		t.Errorf("unexpected ValidRole result")
+	}
+}
+
+func TestAllowancePeriodKey(t *testing.T) {
+	now := time.Date(2026, 1, 1, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+
+	cases := map[string]string{
+		AllowanceDaily:   "daily:2025-12-31",
+		AllowanceWeekly:  "weekly:2026-W01",
+		AllowanceMonthly: "monthly:2025-12",
+	}
+	for period, want := range cases {
+		got, ok := Allowance{Period: period}.PeriodKey(now)
+		if !ok || got != want {
+			t.Errorf("PeriodKey(%s) = %s, %v, want %s", period, got, ok, want)
+		}
+	}
+
+	if _, ok := (Allowance{Period: "yearly"}).PeriodKey(now); ok {
+		t.Errorf("PeriodKey accepted unknown period")
+	}
+}
+
+func TestJournalEntryBalanced(t *testing.T) {
+	balanced := JournalEntry{Postings: []Posting{
+		{Account: IssuanceAccount, Amount: -200},
+		{Account: WalletAccount(1), Amount: 100},
+		{Account: WalletAccount(2), Amount: 100},
+	}}
+	if !balanced.Balanced() {
+		t.Errorf("expected entry to be balanced")
+	}
+
+	if (JournalEntry{Postings: []Posting{{Account: WalletAccount(1), Amount: 100}}}).Balanced() {
+		t.Errorf("expected one-sided entry to be unbalanced")
+	}
+
+	if (JournalEntry{}).Balanced() {
+		t.Errorf("expected empty entry to be unbalanced")
+	}
+}
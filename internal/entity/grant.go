@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Grant начисление монет пользователю от системы, а не перевод от другого пользователя.
+// GrantedBy - кто оформил начисление, 0 - регулярное начисление планировщика
+type Grant struct {
+	Id        int       `json:"id"`
+	UserId    int       `json:"-"`
+	ToUser    string    `json:"toUser"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	GrantedBy int       `json:"grantedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GrantLine struct {
+	ToUser string `json:"toUser"`
+	Amount int    `json:"amount"`
+}
+
+// GrantRequest начисление одной и той же причиной сразу нескольким пользователям
+type GrantRequest struct {
+	Reason string      `json:"reason"`
+	Grants []GrantLine `json:"grants"`
+}
+
+// Периоды регулярного начисления
+const (
+	AllowanceDaily   = "daily"
+	AllowanceWeekly  = "weekly"
+	AllowanceMonthly = "monthly"
+)
+
+// Allowance регулярное начисление Amount монет всем пользователям раз в Period
+type Allowance struct {
+	Period string
+	Amount int
+	Reason string
+}
+
+// PeriodKey ключ периода, в который попадает t, например monthly:2026-10. Время берётся в UTC.
+// Для неизвестного периода возвращает false
+func (a Allowance) PeriodKey(t time.Time) (string, bool) {
+	t = t.UTC()
+
+	switch a.Period {
+	case AllowanceDaily:
+		return a.Period + ":" + t.Format(time.DateOnly), true
+	case AllowanceWeekly:
+		year, week := t.ISOWeek()
+
+		return fmt.Sprintf("%s:%d-W%02d", a.Period, year, week), true
+	case AllowanceMonthly:
+		return a.Period + ":" + t.Format("2006-01"), true
+	default:
+		return "", false
+	}
+}
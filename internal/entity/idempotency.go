@@ -0,0 +1,9 @@
+package entity
+
+// IdempotentResponse сохранённый ответ на запрос с Idempotency-Key
+type IdempotentResponse struct {
+	// RequestHash хэш метода, пути и тела запроса, по нему ловится переиспользование ключа с другим запросом
+	RequestHash []byte
+	StatusCode  int
+	Body        []byte
+}
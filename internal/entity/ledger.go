@@ -0,0 +1,82 @@
+package entity
+
+import "time"
+
+// Виды счетов. Кошелёк есть у каждого пользователя, выручка и эмиссия - по одному на систему
+const (
+	AccountWallet   = "wallet"
+	AccountRevenue  = "revenue"
+	AccountIssuance = "issuance"
+)
+
+// Виды проводок
+const (
+	EntryOpening   = "opening"
+	EntryTransfer  = "transfer"
+	EntryPurchase  = "purchase"
+	EntryOrder     = "order"
+	EntryRefund    = "refund"
+	EntryGrant     = "grant"
+	EntryAllowance = "allowance"
+)
+
+// Account счёт. UserId задан только у кошелька
+type Account struct {
+	Kind   string
+	UserId int
+}
+
+var (
+	RevenueAccount  = Account{Kind: AccountRevenue}
+	IssuanceAccount = Account{Kind: AccountIssuance}
+)
+
+func WalletAccount(userId int) Account {
+	return Account{Kind: AccountWallet, UserId: userId}
+}
+
+// Posting изменение баланса одного счёта: положительное - приход, отрицательное - расход
+type Posting struct {
+	Account Account
+	Amount  int
+}
+
+// JournalEntry проводка. ReferenceId - id записи, породившей проводку: перевода, покупки или начисления
+type JournalEntry struct {
+	Id          int
+	Kind        string
+	ReferenceId int
+	Description string
+	Postings    []Posting
+	CreatedAt   time.Time
+}
+
+// Balanced проверяет, что сумма postings равна нулю, то есть монеты не появились и не пропали
+func (e JournalEntry) Balanced() bool {
+	sum := 0
+	for _, p := range e.Postings {
+		sum += p.Amount
+	}
+
+	return len(e.Postings) > 0 && sum == 0
+}
+
+// BalanceDrift расхождение баланса пользователя с суммой postings его кошелька
+type BalanceDrift struct {
+	UserId   int    `json:"userId"`
+	Username string `json:"username"`
+	Balance  int    `json:"balance"`
+	Ledger   int    `json:"ledger"`
+}
+
+// Reconciliation результат сверки балансов с журналом
+type Reconciliation struct {
+	Users             int            `json:"users"`
+	Drifts            []BalanceDrift `json:"drifts"`
+	UnbalancedEntries []int          `json:"unbalancedEntries"`
+}
+
+// OK расхождений нет
+func (r Reconciliation) OK() bool {
+	return len(r.Drifts) == 0 && len(r.UnbalancedEntries) == 0
+}
@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// CartItem строка корзины с текущей ценой товара
+type CartItem struct {
+	ItemId   int    `json:"-"`
+	Type     string `json:"type"`
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity"`
+	// Available false, если товар сняли с продажи или удалили уже после добавления в корзину
+	Available    bool `json:"available"`
+	PerUserLimit *int `json:"-"`
+}
+
+// Cart корзина пользователя. Total считается по текущим ценам и может измениться до оформления заказа
+type Cart struct {
+	Items []CartItem `json:"items"`
+	Total int        `json:"total"`
+}
+
+// CartRequest тело запроса на добавление товара в корзину, без Quantity добавляется одна штука
+type CartRequest struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// OrderLine строка заказа с ценой на момент оформления
+type OrderLine struct {
+	ItemId   int    `json:"-"`
+	Type     string `json:"type"`
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity"`
+}
+
+// Order заказ, оформленный из корзины, он же чек: все строки оплачены одним списанием Total
+type Order struct {
+	Id        int         `json:"id"`
+	Total     int         `json:"total"`
+	Lines     []OrderLine `json:"lines"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+type OrderPage = Page[Order]
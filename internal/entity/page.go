@@ -0,0 +1,7 @@
+package entity
+
+// Page страница выборки с пагинацией по курсору, NextBefore передаётся в before для следующей страницы
+type Page[T any] struct {
+	Items      []T `json:"items"`
+	NextBefore int `json:"nextBefore,omitempty"`
+}
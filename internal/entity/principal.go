@@ -0,0 +1,24 @@
+package entity
+
+import "context"
+
+// Principal аутентифицированный пользователь, от имени которого выполняется запрос
+type Principal struct {
+	Id       int      `json:"id"`
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal кладёт пользователя в контекст запроса
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext достаёт пользователя, положенного в контекст WithPrincipal
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+
+	return p, ok
+}
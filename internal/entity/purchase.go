@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+type Purchase struct {
+	Id        int       `json:"id"`
+	UserId    int       `json:"-"`
+	ItemId    int       `json:"-"`
+	Type      string    `json:"type"`
+	Price     int       `json:"price"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"createdAt"`
+	// OrderId заказ, в составе которого куплен товар, 0 - покупка через /api/buy
+	OrderId int `json:"orderId,omitempty"`
+	// Refunded сколько штук из Quantity уже возвращено
+	Refunded int `json:"refunded,omitempty"`
+}
+
+type PurchasePage = Page[Purchase]
+
+// Refund возврат части или всей покупки. Amount считается по цене покупки
+type Refund struct {
+	Id         int       `json:"id"`
+	PurchaseId int       `json:"purchaseId"`
+	UserId     int       `json:"-"`
+	Type       string    `json:"type"`
+	Quantity   int       `json:"quantity"`
+	Amount     int       `json:"amount"`
+	Reason     string    `json:"reason"`
+	RefundedBy int       `json:"refundedBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// RefundRequest тело запроса на возврат. Без Quantity возвращается всё, что ещё не возвращено
+type RefundRequest struct {
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
@@ -0,0 +1,57 @@
+package entity
+
+// Роли пользователей. Хранятся в users.role и выдаются в claim roles токена
+const (
+	RoleEmployee = "employee"
+	RoleHR       = "hr"
+	RoleAdmin    = "admin"
+)
+
+// Права, которыми защищаются группы маршрутов
+const (
+	PermManageCatalog = "catalog:manage"
+	PermGrantCoins    = "coins:grant"
+	PermManageUsers   = "users:manage"
+	// PermRefundPurchases возврат любой покупки без ограничения по времени
+	PermRefundPurchases = "purchases:refund"
+)
+
+// rolePermissions права каждой роли. У сотрудника особых прав нет
+var rolePermissions = map[string][]string{
+	RoleEmployee: nil,
+	RoleHR:       {PermGrantCoins},
+	RoleAdmin:    {PermManageCatalog, PermGrantCoins, PermManageUsers, PermRefundPurchases},
+}
+
+// ValidRole проверяет, что роль известна
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+
+	return ok
+}
+
+// HasRole проверяет, что у пользователя есть хотя бы одна из ролей
+func (p Principal) HasRole(roles ...string) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Can проверяет, что хотя бы одна из ролей пользователя даёт право perm
+func (p Principal) Can(perm string) bool {
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// RefreshToken запись о выданном refresh-токене, сам токен не хранится, только его хэш
+type RefreshToken struct {
+	Id        int
+	UserId    int
+	Hash      []byte
+	FamilyId  string
+	ExpiresAt time.Time
+	// Revoked токен уже обменян на новый или отозван, повторное предъявление означает утечку
+	Revoked bool
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
@@ -5,4 +5,5 @@ type User struct {
 	Username string `json:"username" db:"username"`
 	Passhash []byte `json:"password" db:"password"`
 	Coins    int    `json:"coins" db:"coins"`
+	Role     string `json:"role" db:"role"`
 }
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = NewError(CodeInvalidRefreshToken, http.StatusUnauthorized, "invalid refresh token")
+	ErrRefreshTokenReused  = NewError(CodeRefreshTokenReused, http.StatusUnauthorized, "refresh token reused")
+)
+
+// Refresh обменивает refresh-токен на новую пару. Каждый refresh-токен одноразовый: повторное
+// предъявление уже обменянного токена значит, что он утёк, поэтому отзывается всё его семейство,
+// и пользователю придётся залогиниться заново
+func (uc *ShopUseCase) Refresh(ctx context.Context, refreshToken string) (entity.AuthResponse, error) {
+	const op = "ShopUseCase.Refresh"
+
+	var (
+		tokens entity.AuthResponse
+		reused bool
+	)
+
+	err := uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		stored, err := uc.repo.GetRefreshTokenForUpdate(ctx, jwtPkg.HashRefreshToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, ErrNoRefreshToken) {
+				return ErrInvalidRefreshToken
+			}
+
+			return err
+		}
+
+		if stored.Revoked {
+			// отзыв семейства должен закоммититься, поэтому ошибку отдаём уже после транзакции
+			reused = true
+
+			return uc.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyId)
+		}
+
+		if time.Now().After(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		err = uc.repo.RevokeRefreshToken(ctx, stored.Id)
+		if err != nil {
+			return err
+		}
+
+		user, err := uc.repo.GetUserById(ctx, stored.UserId)
+		if err != nil {
+			return err
+		}
+
+		tokens, err = uc.issueTokens(ctx, user, stored.FamilyId)
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			return entity.AuthResponse{}, ErrInvalidRefreshToken
+		}
+
+		return entity.AuthResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if reused {
+		return entity.AuthResponse{}, ErrRefreshTokenReused
+	}
+
+	return tokens, nil
+}
+
+// Logout отзывает access-токен до конца его срока. Если передан refresh-токен этого пользователя,
+// отзывается и всё его семейство, чужой или неизвестный refresh-токен молча игнорируется
+func (uc *ShopUseCase) Logout(ctx context.Context, userId int, accessToken, refreshToken string) error {
+	const op = "ShopUseCase.Logout"
+
+	err := uc.tokens.Revoke(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	err = uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		stored, err := uc.repo.GetRefreshTokenForUpdate(ctx, jwtPkg.HashRefreshToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, ErrNoRefreshToken) {
+				return nil
+			}
+
+			return err
+		}
+
+		if stored.UserId != userId {
+			return nil
+		}
+
+		return uc.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyId)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// issueTokens выпускает access-токен и новый refresh-токен в семействе familyId,
+// пустой familyId начинает новое семейство
+func (uc *ShopUseCase) issueTokens(ctx context.Context, user entity.User, familyId string) (entity.AuthResponse, error) {
+	accessToken, err := uc.tokens.NewToken(tokenClaims(user), uc.tokenTTL)
+	if err != nil {
+		return entity.AuthResponse{}, err
+	}
+
+	if familyId == "" {
+		familyId, err = jwtPkg.NewTokenId()
+		if err != nil {
+			return entity.AuthResponse{}, err
+		}
+	}
+
+	refreshToken, hash, err := jwtPkg.NewRefreshToken()
+	if err != nil {
+		return entity.AuthResponse{}, err
+	}
+
+	err = uc.repo.SaveRefreshToken(ctx, entity.RefreshToken{
+		UserId:    user.Id,
+		Hash:      hash,
+		FamilyId:  familyId,
+		ExpiresAt: time.Now().Add(uc.refreshTTL),
+	})
+	if err != nil {
+		return entity.AuthResponse{}, err
+	}
+
+	return entity.AuthResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// tokenClaims пользователь в claims access-токена, роль попадает в claim roles
+func tokenClaims(user entity.User) jwtPkg.Claims {
+	claims := jwtPkg.Claims{
+		UserId:   user.Id,
+		Username: user.Username,
+	}
+
+	if user.Role != "" {
+		claims.Roles = []string{user.Role}
+	}
+
+	return claims
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redismock/v9"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/logger/mocks"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	assert.NoError(t, c.Set(ctx, "a", &entity.ResponseInfo{Coins: 1}, time.Minute))
+	assert.NoError(t, c.Set(ctx, "b", &entity.ResponseInfo{Coins: 2}, time.Minute))
+
+	var res entity.ResponseInfo
+	assert.NoError(t, c.Get(ctx, "a", &res))
+	assert.Equal(t, 1, res.Coins)
+
+	// "b" читали давнее, чем "a", поэтому вытесняется он
+	assert.NoError(t, c.Set(ctx, "c", &entity.ResponseInfo{Coins: 3}, time.Minute))
+	assert.ErrorIs(t, c.Get(ctx, "b", &res), usecase.ErrCacheMiss)
+	assert.Equal(t, 2, c.Len())
+
+	// перезапись существующего ключа
+	assert.NoError(t, c.Set(ctx, "a", &entity.ResponseInfo{Coins: 10}, time.Minute))
+	assert.NoError(t, c.Get(ctx, "a", &res))
+	assert.Equal(t, 10, res.Coins)
+
+	assert.NoError(t, c.Delete(ctx, "a", "unknown"))
+	assert.ErrorIs(t, c.Get(ctx, "a", &res), usecase.ErrCacheMiss)
+
+	// протухшая запись не отдаётся и удаляется
+	now = now.Add(time.Minute)
+	assert.ErrorIs(t, c.Get(ctx, "c", &res), usecase.ErrCacheMiss)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestNop(t *testing.T) {
+	ctx := context.Background()
+	c := NewNop()
+
+	assert.NoError(t, c.Set(ctx, "a", &entity.ResponseInfo{Coins: 1}, time.Minute))
+
+	var res entity.ResponseInfo
+	assert.ErrorIs(t, c.Get(ctx, "a", &res), usecase.ErrCacheMiss)
+	assert.NoError(t, c.Delete(ctx, "a"))
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	client, redisMock := redismock.NewClientMock()
+	c := NewRedis(client)
+
+	info := &entity.ResponseInfo{Coins: 100}
+	data, err := info.MarshalBinary()
+	assert.NoError(t, err)
+
+	redisMock.ExpectSet("a", info, time.Minute).SetVal("OK")
+	assert.NoError(t, c.Set(ctx, "a", info, time.Minute))
+
+	redisMock.ExpectGet("a").SetVal(string(data))
+	var res entity.ResponseInfo
+	assert.NoError(t, c.Get(ctx, "a", &res))
+	assert.Equal(t, *info, res)
+
+	redisMock.ExpectGet("b").RedisNil()
+	assert.ErrorIs(t, c.Get(ctx, "b", &res), usecase.ErrCacheMiss)
+
+	redisMock.ExpectGet("c").SetErr(errors.New("connection refused"))
+	err = c.Get(ctx, "c", &res)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, usecase.ErrCacheMiss)
+
+	redisMock.ExpectDel("a", "b").SetVal(1)
+	assert.NoError(t, c.Delete(ctx, "a", "b"))
+
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestResilient(t *testing.T) {
+	ctx := context.Background()
+
+	l := new(mocks.Logger)
+	l.On("Error", mock.Anything, mock.Anything).Return()
+	l.On("Info", mock.Anything, mock.Anything).Return()
+
+	client, redisMock := redismock.NewClientMock()
+
+	redisUp := false
+	connect := func(context.Context) (*redis.Client, error) {
+		if !redisUp {
+			return nil, errors.New("connection refused")
+		}
+
+		return client, nil
+	}
+
+	fallback := NewLRU(10)
+
+	// фоновая проверка в тесте не нужна, check вызывается вручную
+	c := NewResilient(ctx, connect, fallback, l, time.Hour)
+	defer c.Close()
+
+	// redis недоступен на старте: сервис работает через fallback
+	assert.True(t, c.Degraded())
+	assert.ErrorIs(t, c.Ping(ctx), ErrNotConnected)
+
+	// strict без redis не пишет в fallback, а возвращает ошибку
+	assert.ErrorIs(t, c.Strict().Set(ctx, "s", &entity.ResponseInfo{}, time.Minute), ErrNotConnected)
+	assert.ErrorIs(t, c.Strict().Get(ctx, "s", &entity.ResponseInfo{}), ErrNotConnected)
+
+	info := &entity.ResponseInfo{Coins: 100}
+	assert.NoError(t, c.Set(ctx, "a", info, time.Minute))
+
+	var res entity.ResponseInfo
+	assert.NoError(t, c.Get(ctx, "a", &res))
+	assert.Equal(t, *info, res)
+
+	assert.NoError(t, c.Delete(ctx, "a", "b"))
+	assert.ErrorIs(t, fallback.Get(ctx, "a", &res), usecase.ErrCacheMiss)
+
+	// redis поднялся: ключи, удалённые во время простоя, удаляются и из него
+	redisUp = true
+	redisMock.ExpectPing().SetVal("PONG")
+	redisMock.MatchExpectationsInOrder(false)
+	redisMock.CustomMatch(func(expected, actual []interface{}) error {
+		assert.ElementsMatch(t, expected, actual)
+		return nil
+	}).ExpectDel("a", "b").SetVal(0)
+
+	c.check(ctx)
+	assert.False(t, c.Degraded())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+
+	redisMock.ExpectPing().SetVal("PONG")
+	assert.NoError(t, c.Ping(ctx))
+
+	redisMock.ExpectGet("s").RedisNil()
+	assert.ErrorIs(t, c.Strict().Get(ctx, "s", &res), usecase.ErrCacheMiss)
+
+	// после восстановления чтение идёт в redis
+	redisMock.ExpectGet("a").RedisNil()
+	assert.ErrorIs(t, c.Get(ctx, "a", &res), usecase.ErrCacheMiss)
+
+	// ошибка redis переключает кэш в деградированный режим
+	redisMock.ExpectSet("a", info, time.Minute).SetErr(errors.New("connection reset"))
+	assert.NoError(t, c.Set(ctx, "a", info, time.Minute))
+	assert.True(t, c.Degraded())
+
+	assert.NoError(t, fallback.Get(ctx, "a", &res))
+	assert.Equal(t, *info, res)
+
+	// в деградированном режиме strict всё равно идёт в redis и возвращает его ошибку, а не ответ fallback
+	redisMock.ExpectGet("a").SetErr(errors.New("connection reset"))
+	assert.Error(t, c.Strict().Get(ctx, "a", &res))
+}
+
+func TestDenylist(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	d := NewDenylist(c, "test")
+
+	revoked, err := d.IsRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, d.Revoke(ctx, "jti", time.Minute))
+
+	revoked, err = d.IsRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = d.IsRevoked(ctx, "other")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	// запись живёт не дольше самого токена
+	now = now.Add(time.Minute)
+	revoked, err = d.IsRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
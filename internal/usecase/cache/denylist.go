@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"time"
+)
+
+// Denylist список отозванных access-токенов поверх кэша. Запись живёт ровно до истечения токена,
+// после этого токен отвергается и без неё. Кэш должен быть общим для всех реплик и не терять записи,
+// поэтому в сервисе это Resilient.Strict: без redis отзыв и проверка токена возвращают ошибку,
+// и токен не принимается
+type Denylist struct {
+	cache     usecase.Cache
+	namespace string
+}
+
+func NewDenylist(c usecase.Cache, namespace string) *Denylist {
+	return &Denylist{
+		cache:     c,
+		namespace: namespace,
+	}
+}
+
+func (d *Denylist) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	const op = "cache.Denylist.Revoke"
+
+	err := d.cache.Set(ctx, d.key(jti), revoked{}, ttl)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (d *Denylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "cache.Denylist.IsRevoked"
+
+	err := d.cache.Get(ctx, d.key(jti), &revoked{})
+	if err != nil {
+		if errors.Is(err, usecase.ErrCacheMiss) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (d *Denylist) key(jti string) string {
+	return fmt.Sprintf("%s:jti:%s", d.namespace, jti)
+}
+
+// revoked значение записи в denylist, важен только факт её наличия
+type revoked struct{}
+
+func (revoked) MarshalBinary() ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (*revoked) UnmarshalBinary([]byte) error {
+	return nil
+}
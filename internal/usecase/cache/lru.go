@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// LRU кэш в памяти процесса на size записей, при переполнении вытесняется давно не читавшаяся запись.
+// Значения хранятся сериализованными, поэтому вызывающий не может изменить закэшированный объект
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+		now:     time.Now,
+	}
+}
+
+func (l *LRU) Get(_ context.Context, key string, dst encoding.BinaryUnmarshaler) error {
+	const op = "cache.LRU.Get"
+
+	l.mu.Lock()
+
+	el, ok := l.entries[key]
+	if !ok {
+		l.mu.Unlock()
+
+		return usecase.ErrCacheMiss
+	}
+
+	entry := el.Value.(*lruEntry)
+	if !l.now().Before(entry.expiresAt) {
+		l.remove(el)
+		l.mu.Unlock()
+
+		return usecase.ErrCacheMiss
+	}
+
+	l.order.MoveToFront(el)
+	data := entry.data
+
+	l.mu.Unlock()
+
+	err := dst.UnmarshalBinary(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (l *LRU) Set(_ context.Context, key string, value encoding.BinaryMarshaler, ttl time.Duration) error {
+	const op = "cache.LRU.Set"
+
+	data, err := value.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := l.now().Add(ttl)
+
+	if el, ok := l.entries[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.data = data
+		entry.expiresAt = expiresAt
+		l.order.MoveToFront(el)
+
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, data: data, expiresAt: expiresAt})
+
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+
+	return nil
+}
+
+func (l *LRU) Delete(_ context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := l.entries[key]; ok {
+			l.remove(el)
+		}
+	}
+
+	return nil
+}
+
+// Len количество записей, включая ещё не удалённые просроченные
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+func (l *LRU) remove(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.entries, el.Value.(*lruEntry).key)
+}
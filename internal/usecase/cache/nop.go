@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"context"
+	"encoding"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"time"
+)
+
+// Nop кэш, который ничего не хранит: каждый Get - промах
+type Nop struct{}
+
+func NewNop() Nop {
+	return Nop{}
+}
+
+func (Nop) Get(context.Context, string, encoding.BinaryUnmarshaler) error {
+	return usecase.ErrCacheMiss
+}
+
+func (Nop) Set(context.Context, string, encoding.BinaryMarshaler, time.Duration) error {
+	return nil
+}
+
+func (Nop) Delete(context.Context, ...string) error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Redis кэш поверх go-redis клиента
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{
+		client: client,
+	}
+}
+
+func (r *Redis) Get(ctx context.Context, key string, dst encoding.BinaryUnmarshaler) error {
+	const op = "cache.Redis.Get"
+
+	data, err := r.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return usecase.ErrCacheMiss
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = dst.UnmarshalBinary(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value encoding.BinaryMarshaler, ttl time.Duration) error {
+	const op = "cache.Redis.Set"
+
+	err := r.client.Set(ctx, key, value, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	const op = "cache.Redis.Delete"
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := r.client.Del(ctx, keys...).Err()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Ping проверяет, что redis отвечает
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,351 @@
+package cache
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCheckInterval = 5 * time.Second
+	pingTimeout          = time.Second
+
+	// maxPendingDeletes ограничивает число ключей, запомненных для удаления после восстановления redis.
+	// Всё, что не влезло, просто доживёт в redis до конца своего TTL
+	maxPendingDeletes = 10000
+)
+
+// ErrNotConnected к redis ещё ни разу не удалось подключиться
+var ErrNotConnected = errors.New("redis is not connected")
+
+// Connect открывает соединение с redis, ошибка означает, что redis сейчас недоступен
+type Connect func(ctx context.Context) (*redis.Client, error)
+
+// Resilient кэш, который работает через redis, а пока redis недоступен - через fallback.
+// В фоне он переподключается к redis и возвращается к нему, как только тот ответит.
+// Ключи, удалённые во время недоступности, удаляются из redis после восстановления,
+// чтобы там не остались устаревшие ответы
+type Resilient struct {
+	connect  Connect
+	fallback usecase.Cache
+	l        logger.Logger
+	interval time.Duration
+
+	mu       sync.RWMutex
+	primary  *Redis
+	degraded bool
+	pending  map[string]struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewResilient пытается сразу подключиться к redis и запускает фоновую проверку соединения раз в interval.
+// Сервис стартует и при недоступном redis, в этом случае кэш сразу работает в деградированном режиме
+func NewResilient(ctx context.Context, connect Connect, fallback usecase.Cache, l logger.Logger, interval time.Duration) *Resilient {
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+
+	r := &Resilient{
+		connect:  connect,
+		fallback: fallback,
+		l:        l,
+		interval: interval,
+		pending:  make(map[string]struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	r.check(ctx)
+
+	go r.run(ctx)
+
+	return r
+}
+
+func (r *Resilient) Get(ctx context.Context, key string, dst encoding.BinaryUnmarshaler) error {
+	if p := r.healthy(); p != nil {
+		err := p.Get(ctx, key, dst)
+		if err == nil || errors.Is(err, usecase.ErrCacheMiss) {
+			return err
+		}
+
+		r.markDegraded(ctx, err)
+	}
+
+	return r.fallback.Get(ctx, key, dst)
+}
+
+func (r *Resilient) Set(ctx context.Context, key string, value encoding.BinaryMarshaler, ttl time.Duration) error {
+	if p := r.healthy(); p != nil {
+		err := p.Set(ctx, key, value, ttl)
+		if err == nil {
+			return nil
+		}
+
+		r.markDegraded(ctx, err)
+	}
+
+	return r.fallback.Set(ctx, key, value, ttl)
+}
+
+// Delete удаляет ключи и из redis, и из fallback, чтобы при следующем переключении не всплыли устаревшие записи
+func (r *Resilient) Delete(ctx context.Context, keys ...string) error {
+	err := r.fallback.Delete(ctx, keys...)
+	if err != nil {
+		return err
+	}
+
+	if p := r.healthy(); p != nil {
+		err = p.Delete(ctx, keys...)
+		if err == nil {
+			return nil
+		}
+
+		r.markDegraded(ctx, err)
+	}
+
+	r.remember(keys)
+
+	return nil
+}
+
+// Degraded сообщает, что redis сейчас недоступен и кэш работает через fallback
+func (r *Resilient) Degraded() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.primary == nil || r.degraded
+}
+
+// Ping пингует redis напрямую, минуя fallback. Нужен проверке готовности: сам кэш продолжает работать и без redis
+func (r *Resilient) Ping(ctx context.Context) error {
+	r.mu.RLock()
+	p := r.primary
+	r.mu.RUnlock()
+
+	if p == nil {
+		return ErrNotConnected
+	}
+
+	return p.Ping(ctx)
+}
+
+// Strict кэш поверх того же соединения с redis, но без fallback: пока redis недоступен, он возвращает ошибку.
+// Нужен данным, которые нельзя держать только в памяти одной реплики, например отозванным токенам
+func (r *Resilient) Strict() usecase.Cache {
+	return strict{r}
+}
+
+// Close останавливает фоновую проверку и закрывает соединение с redis
+func (r *Resilient) Close() error {
+	close(r.stop)
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.primary == nil {
+		return nil
+	}
+
+	return r.primary.Close()
+}
+
+func (r *Resilient) run(ctx context.Context) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+// check подключается к redis, если соединения ещё нет, иначе пингует его и обновляет состояние
+func (r *Resilient) check(ctx context.Context) {
+	r.mu.RLock()
+	p := r.primary
+	r.mu.RUnlock()
+
+	if p == nil {
+		client, err := r.connect(ctx)
+		if err != nil {
+			r.markDegraded(ctx, err)
+
+			return
+		}
+
+		p = NewRedis(client)
+
+		r.mu.Lock()
+		r.primary = p
+		r.mu.Unlock()
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	err := p.Ping(pingCtx)
+	if err != nil {
+		r.markDegraded(ctx, err)
+
+		return
+	}
+
+	r.restore(ctx, p)
+}
+
+// restore удаляет из redis ключи, инвалидированные за время недоступности, и возвращает кэш в нормальный режим.
+// Режим переключается под той же блокировкой, что и проверка пустоты pending, поэтому ключ,
+// удалённый параллельно с восстановлением, тоже не потеряется
+func (r *Resilient) restore(ctx context.Context, p *Redis) {
+	removed := 0
+
+	for {
+		r.mu.Lock()
+		if !r.degraded {
+			r.mu.Unlock()
+
+			return
+		}
+
+		if len(r.pending) == 0 {
+			r.degraded = false
+			r.mu.Unlock()
+
+			r.l.Info(ctx, fmt.Sprintf("cache: redis is available again, %d stale keys removed", removed))
+
+			return
+		}
+
+		keys := make([]string, 0, len(r.pending))
+		for key := range r.pending {
+			keys = append(keys, key)
+		}
+		r.mu.Unlock()
+
+		err := p.Delete(ctx, keys...)
+		if err != nil {
+			r.markDegraded(ctx, err)
+
+			return
+		}
+
+		r.mu.Lock()
+		for _, key := range keys {
+			delete(r.pending, key)
+		}
+		r.mu.Unlock()
+
+		removed += len(keys)
+	}
+}
+
+func (r *Resilient) healthy() *Redis {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.degraded {
+		return nil
+	}
+
+	return r.primary
+}
+
+// markDegraded переключает кэш на fallback, в лог пишется только сам переход
+func (r *Resilient) markDegraded(ctx context.Context, cause error) {
+	r.mu.Lock()
+	wasDegraded := r.degraded
+	r.degraded = true
+	r.mu.Unlock()
+
+	if !wasDegraded {
+		r.l.Error(ctx, fmt.Sprintf("cache: redis is unavailable, working in degraded mode: %s", cause))
+	}
+}
+
+func (r *Resilient) remember(keys []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		if len(r.pending) >= maxPendingDeletes {
+			return
+		}
+
+		r.pending[key] = struct{}{}
+	}
+}
+
+// strict обращается к redis напрямую, минуя fallback. Ошибка redis так же переводит Resilient
+// в деградированный режим, а фоновая проверка возвращает его обратно
+type strict struct {
+	r *Resilient
+}
+
+func (s strict) Get(ctx context.Context, key string, dst encoding.BinaryUnmarshaler) error {
+	p, err := s.primary()
+	if err != nil {
+		return err
+	}
+
+	err = p.Get(ctx, key, dst)
+	if err != nil && !errors.Is(err, usecase.ErrCacheMiss) {
+		s.r.markDegraded(ctx, err)
+	}
+
+	return err
+}
+
+func (s strict) Set(ctx context.Context, key string, value encoding.BinaryMarshaler, ttl time.Duration) error {
+	p, err := s.primary()
+	if err != nil {
+		return err
+	}
+
+	err = p.Set(ctx, key, value, ttl)
+	if err != nil {
+		s.r.markDegraded(ctx, err)
+	}
+
+	return err
+}
+
+func (s strict) Delete(ctx context.Context, keys ...string) error {
+	p, err := s.primary()
+	if err != nil {
+		return err
+	}
+
+	err = p.Delete(ctx, keys...)
+	if err != nil {
+		s.r.markDegraded(ctx, err)
+	}
+
+	return err
+}
+
+func (s strict) primary() (*Redis, error) {
+	s.r.mu.RLock()
+	defer s.r.mu.RUnlock()
+
+	if s.r.primary == nil {
+		return nil, ErrNotConnected
+	}
+
+	return s.r.primary, nil
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+)
+
+func (uc *ShopUseCase) GetCart(ctx context.Context, userId int) (entity.Cart, error) {
+	const op = "ShopUseCase.GetCart"
+
+	items, err := uc.repo.GetCart(ctx, userId)
+	if err != nil {
+		return entity.Cart{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newCart(items), nil
+}
+
+// AddToCart кладёт товар в корзину. Остаток и лимит на пользователя здесь не проверяются,
+// товар не резервируется: всё это проверит Checkout
+func (uc *ShopUseCase) AddToCart(ctx context.Context, userId int, itemName string, quantity int) (entity.Cart, error) {
+	const op = "ShopUseCase.AddToCart"
+
+	if quantity <= 0 || quantity > maxPurchaseQuantity {
+		return entity.Cart{}, ErrInvalidQuantity.WithMessage(fmt.Sprintf("quantity must be 1 to %d", maxPurchaseQuantity))
+	}
+
+	item, err := uc.repo.GetItemByName(ctx, itemName)
+	if err != nil {
+		return entity.Cart{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !item.Active {
+		return entity.Cart{}, ErrItemInactive
+	}
+
+	var items []entity.CartItem
+	err = uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		inCart, err := uc.repo.AddToCart(ctx, userId, item.Id, quantity)
+		if err != nil {
+			return err
+		}
+
+		// за раз нельзя купить больше maxPurchaseQuantity штук, так что и в корзине их столько не нужно
+		if inCart > maxPurchaseQuantity {
+			return ErrInvalidQuantity.WithMessage(fmt.Sprintf("cart can hold at most %d of one item", maxPurchaseQuantity))
+		}
+
+		items, err = uc.repo.GetCart(ctx, userId)
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidQuantity) {
+			return entity.Cart{}, err
+		}
+
+		return entity.Cart{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newCart(items), nil
+}
+
+func (uc *ShopUseCase) RemoveFromCart(ctx context.Context, userId int, itemName string) error {
+	const op = "ShopUseCase.RemoveFromCart"
+
+	err := uc.repo.RemoveFromCart(ctx, userId, itemName)
+	if err != nil {
+		if errors.Is(err, ErrNoItem) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Checkout покупает всё из корзины по текущим ценам. Строки корзины блокируются, поэтому повторный
+// параллельный Checkout дождётся этого и увидит пустую корзину, а товары блокируются по возрастанию id,
+// как их возвращает GetCartForUpdate, чтобы встречные заказы не заблокировали друг друга.
+// Цена, доступность и лимит каждого товара берутся из его заблокированной строки, как в BuyItem
+func (uc *ShopUseCase) Checkout(ctx context.Context, userId int) (entity.Order, error) {
+	const op = "ShopUseCase.Checkout"
+
+	var order entity.Order
+	err := uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		items, err := uc.repo.GetCartForUpdate(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return ErrEmptyCart
+		}
+
+		lines := make([]entity.OrderLine, 0, len(items))
+		total := 0
+		for _, item := range items {
+			// цена и доступность из корзины прочитаны без блокировки товара, берём их из заблокированной строки
+			locked, err := uc.reserve(ctx, userId, item.ItemId, item.Quantity)
+			if err != nil {
+				if errors.Is(err, ErrItemInactive) || errors.Is(err, ErrNoItem) {
+					return ErrItemInactive.WithMessage(fmt.Sprintf("%s is not available", item.Type))
+				}
+
+				if errors.Is(err, ErrOutOfStock) {
+					return ErrOutOfStock.WithMessage(fmt.Sprintf("%s is out of stock", item.Type))
+				}
+
+				return err
+			}
+
+			lines = append(lines, entity.OrderLine{ItemId: item.ItemId, Type: item.Type, Price: locked.Price, Quantity: item.Quantity})
+			total += locked.Price * item.Quantity
+		}
+
+		// весь заказ оплачивается одним списанием
+		err = uc.repo.TakeCoins(ctx, userId, total)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			err = uc.repo.BuyItem(ctx, userId, line.ItemId, line.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		order, err = uc.repo.CreateOrder(ctx, userId, total)
+		if err != nil {
+			return err
+		}
+
+		err = uc.repo.SaveOrderLines(ctx, userId, order.Id, lines)
+		if err != nil {
+			return err
+		}
+
+		order.Lines = lines
+
+		err = uc.post(ctx, entity.JournalEntry{
+			Kind:        entity.EntryOrder,
+			ReferenceId: order.Id,
+			Postings: []entity.Posting{
+				{Account: entity.WalletAccount(userId), Amount: -total},
+				{Account: entity.RevenueAccount, Amount: total},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		return uc.repo.ClearCart(ctx, userId)
+	})
+	if err != nil {
+		if errors.Is(err, ErrEmptyCart) || errors.Is(err, ErrItemInactive) || errors.Is(err, ErrNoCoins) ||
+			errors.Is(err, ErrOutOfStock) || errors.Is(err, ErrPurchaseLimit) {
+			return entity.Order{}, err
+		}
+
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	uc.invalidateInfo(ctx, userId)
+
+	return order, nil
+}
+
+// GetOrders возвращает страницу заказов от новых к старым, начиная с заказов с id < before
+func (uc *ShopUseCase) GetOrders(ctx context.Context, userId, before, limit int) (entity.OrderPage, error) {
+	const op = "ShopUseCase.GetOrders"
+
+	limit = pageLimit(limit)
+
+	orders, err := uc.repo.TakeOrders(ctx, userId, before, limit+1)
+	if err != nil {
+		return entity.OrderPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return makePage(orders, limit, func(o entity.Order) int { return o.Id }), nil
+}
+
+func newCart(items []entity.CartItem) entity.Cart {
+	cart := entity.Cart{Items: items}
+	for _, item := range items {
+		cart.Total += item.Price * item.Quantity
+	}
+
+	return cart
+}
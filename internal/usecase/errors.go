@@ -1,9 +1,102 @@
 package usecase
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
+
+// Коды ошибок, по которым клиенты различают ошибки. Коды стабильны, текст сообщений может меняться
+const (
+	CodeInsufficientFunds    = "INSUFFICIENT_FUNDS"
+	CodeUserNotFound         = "USER_NOT_FOUND"
+	CodeItemNotFound         = "ITEM_NOT_FOUND"
+	CodeItemExists           = "ITEM_EXISTS"
+	CodeItemNotAvailable     = "ITEM_NOT_AVAILABLE"
+	CodeInvalidItem          = "INVALID_ITEM"
+	CodeSelfTransfer         = "SELF_TRANSFER"
+	CodeUserExists           = "USER_EXISTS"
+	CodeInvalidCredentials   = "INVALID_CREDENTIALS"
+	CodeInvalidRefreshToken  = "INVALID_REFRESH_TOKEN"
+	CodeRefreshTokenReused   = "REFRESH_TOKEN_REUSED"
+	CodeIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+	CodeInvalidRole          = "INVALID_ROLE"
+	CodeInvalidGrant         = "INVALID_GRANT"
+	CodeOutOfStock           = "OUT_OF_STOCK"
+	CodePurchaseLimit        = "PURCHASE_LIMIT_REACHED"
+	CodeInvalidQuantity      = "INVALID_QUANTITY"
+	CodeCartEmpty            = "CART_EMPTY"
+	CodePurchaseNotFound     = "PURCHASE_NOT_FOUND"
+	CodeAlreadyRefunded      = "ALREADY_REFUNDED"
+	CodeRefundWindowExpired  = "REFUND_WINDOW_EXPIRED"
+	CodeInvalidRefund        = "INVALID_REFUND"
+	CodeNotInInventory       = "NOT_IN_INVENTORY"
+
+	CodeBadRequest      = "BAD_REQUEST"
+	CodeUnauthorized    = "UNAUTHORIZED"
+	CodeForbidden       = "FORBIDDEN"
+	CodeRequestTooLarge = "REQUEST_TOO_LARGE"
+	CodeInternal        = "INTERNAL_SERVER_ERROR"
+)
+
+// Error ошибка, которую можно показать клиенту: стабильный код, HTTP-статус и сообщение.
+// Ошибки сравниваются через errors.Is с переменными пакета, уточнённая через WithMessage ошибка
+// остаётся равной исходной
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+
+	parent *Error
+}
+
+func NewError(code string, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	if e.parent == nil {
+		return nil
+	}
+
+	return e.parent
+}
+
+// WithMessage возвращает ту же ошибку с более подробным сообщением
+func (e *Error) WithMessage(message string) *Error {
+	return &Error{Code: e.Code, Status: e.Status, Message: message, parent: e}
+}
 
 var (
-	ErrNoUser  = errors.New("user not found")
-	ErrNoItem  = errors.New("item not found")
-	ErrNoCoins = errors.New("not enough coins")
+	ErrNoUser       = NewError(CodeUserNotFound, http.StatusNotFound, "user not found")
+	ErrNoItem       = NewError(CodeItemNotFound, http.StatusNotFound, "item not found")
+	ErrNoCoins      = NewError(CodeInsufficientFunds, http.StatusUnprocessableEntity, "not enough coins")
+	ErrSelfTransfer = NewError(CodeSelfTransfer, http.StatusBadRequest, "cannot send coins to yourself")
+
+	ErrItemExists   = NewError(CodeItemExists, http.StatusConflict, "item with this name already exists")
+	ErrItemInactive = NewError(CodeItemNotAvailable, http.StatusUnprocessableEntity, "item is not available")
+	ErrInvalidItem  = NewError(CodeInvalidItem, http.StatusBadRequest, "invalid item")
+
+	ErrOutOfStock      = NewError(CodeOutOfStock, http.StatusConflict, "item is out of stock")
+	ErrPurchaseLimit   = NewError(CodePurchaseLimit, http.StatusUnprocessableEntity, "purchase limit for this item is reached")
+	ErrInvalidQuantity = NewError(CodeInvalidQuantity, http.StatusBadRequest, "invalid quantity")
+	ErrEmptyCart       = NewError(CodeCartEmpty, http.StatusUnprocessableEntity, "cart is empty")
+
+	ErrNoPurchase          = NewError(CodePurchaseNotFound, http.StatusNotFound, "purchase not found")
+	ErrAlreadyRefunded     = NewError(CodeAlreadyRefunded, http.StatusConflict, "purchase is already refunded")
+	ErrRefundWindowExpired = NewError(CodeRefundWindowExpired, http.StatusUnprocessableEntity, "refund window has expired")
+	ErrInvalidRefund       = NewError(CodeInvalidRefund, http.StatusBadRequest, "invalid refund")
+	// ErrNotInInventory товар уже передан или израсходован, вернуть его нельзя
+	ErrNotInInventory = NewError(CodeNotInInventory, http.StatusConflict, "not enough items in the inventory")
+
+	ErrInvalidRole  = NewError(CodeInvalidRole, http.StatusBadRequest, "unknown role")
+	ErrInvalidGrant = NewError(CodeInvalidGrant, http.StatusBadRequest, "invalid grant")
+
+	ErrNoRefreshToken   = errors.New("refresh token not found")
+	ErrNoIdempotencyKey = errors.New("idempotency key not found")
+
+	ErrCacheMiss = errors.New("cache miss")
 )
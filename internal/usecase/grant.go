@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxGrantReasonLength = 500
+	maxGrantLines        = 1000
+)
+
+// GrantCoins начисляет монеты по списку. Строки одного пользователя складываются в одно начисление,
+// а пользователи блокируются по возрастанию id, как в SendCoins и GrantAll, чтобы начисление
+// не заблокировалось со встречным переводом или другим начислением
+func (uc *ShopUseCase) GrantCoins(ctx context.Context, grantedBy int, req entity.GrantRequest) ([]entity.Grant, error) {
+	const op = "ShopUseCase.GrantCoins"
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := validateGrantRequest(req); err != nil {
+		return nil, err
+	}
+
+	// usernames в порядке первого упоминания, чтобы ошибки о неизвестных пользователях были предсказуемыми
+	usernames := make([]string, 0, len(req.Grants))
+	amounts := make(map[string]int, len(req.Grants))
+	for _, line := range req.Grants {
+		if _, ok := amounts[line.ToUser]; !ok {
+			usernames = append(usernames, line.ToUser)
+		}
+
+		amounts[line.ToUser] += line.Amount
+	}
+
+	grants := make([]entity.Grant, 0, len(usernames))
+	err := uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		users := make([]entity.User, 0, len(usernames))
+		for _, username := range usernames {
+			user, err := uc.repo.FindUser(ctx, username)
+			if err != nil {
+				if errors.Is(err, ErrNoUser) {
+					return ErrNoUser.WithMessage(fmt.Sprintf("user %s not found", username))
+				}
+
+				return err
+			}
+
+			users = append(users, user)
+		}
+
+		sort.Slice(users, func(i, j int) bool {
+			return users[i].Id < users[j].Id
+		})
+
+		for _, user := range users {
+			_, err := uc.repo.GetUserByIdForUpdate(ctx, user.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, user := range users {
+			amount := amounts[user.Username]
+
+			err := uc.repo.TakeGiveCoins(ctx, user.Id, amount)
+			if err != nil {
+				return err
+			}
+
+			grant, err := uc.repo.SaveGrant(ctx, entity.Grant{
+				UserId:    user.Id,
+				ToUser:    user.Username,
+				Amount:    amount,
+				Reason:    req.Reason,
+				GrantedBy: grantedBy,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = uc.post(ctx, issue(entity.EntryGrant, grant.Id, user.Id, amount, req.Reason))
+			if err != nil {
+				return err
+			}
+
+			grants = append(grants, grant)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoUser) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userIds := make([]int, 0, len(grants))
+	for _, grant := range grants {
+		userIds = append(userIds, grant.UserId)
+	}
+
+	uc.invalidateInfo(ctx, userIds...)
+
+	return grants, nil
+}
+
+func (uc *ShopUseCase) RunAllowance(ctx context.Context, allowance entity.Allowance, now time.Time) (int, error) {
+	const op = "ShopUseCase.RunAllowance"
+
+	period, ok := allowance.PeriodKey(now)
+	if !ok || allowance.Amount <= 0 {
+		return 0, ErrInvalidGrant.WithMessage(fmt.Sprintf("invalid allowance %s/%d", allowance.Period, allowance.Amount))
+	}
+
+	var userIds []int
+	err := uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		// отметка периода и начисление коммитятся вместе, поэтому период не начислится ни дважды, ни наполовину
+		claimed, err := uc.repo.ClaimAllowanceRun(ctx, period)
+		if err != nil || !claimed {
+			return err
+		}
+
+		reason := fmt.Sprintf("%s (%s)", allowance.Reason, period)
+
+		userIds, err = uc.repo.GrantAll(ctx, allowance.Amount, reason)
+		if err != nil || len(userIds) == 0 {
+			return err
+		}
+
+		// одна проводка на весь период: эмиссия списывает сумму всех начислений
+		postings := make([]entity.Posting, 0, len(userIds)+1)
+		postings = append(postings, entity.Posting{Account: entity.IssuanceAccount, Amount: -allowance.Amount * len(userIds)})
+		for _, id := range userIds {
+			postings = append(postings, entity.Posting{Account: entity.WalletAccount(id), Amount: allowance.Amount})
+		}
+
+		return uc.post(ctx, entity.JournalEntry{Kind: entity.EntryAllowance, Description: reason, Postings: postings})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(userIds) > 0 {
+		uc.invalidateInfo(ctx, userIds...)
+	}
+
+	return len(userIds), nil
+}
+
+func validateGrantRequest(req entity.GrantRequest) error {
+	if req.Reason == "" || utf8.RuneCountInString(req.Reason) > maxGrantReasonLength {
+		return ErrInvalidGrant.WithMessage(fmt.Sprintf("reason must be 1-%d characters", maxGrantReasonLength))
+	}
+
+	if len(req.Grants) == 0 || len(req.Grants) > maxGrantLines {
+		return ErrInvalidGrant.WithMessage(fmt.Sprintf("grants must contain 1-%d entries", maxGrantLines))
+	}
+
+	for i, line := range req.Grants {
+		if line.ToUser == "" {
+			return ErrInvalidGrant.WithMessage(fmt.Sprintf("grant %d: toUser is required", i+1))
+		}
+
+		if line.Amount <= 0 {
+			return ErrInvalidGrant.WithMessage(fmt.Sprintf("grant %d: amount must be positive", i+1))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"net/http"
+	"sync"
+)
+
+var ErrIdempotencyKeyReused = NewError(CodeIdempotencyKeyReused, http.StatusUnprocessableEntity,
+	"idempotency key reused with a different request")
+
+// Idempotent выполняет fn в транзакции, в которой сначала занимается ключ, а после fn сохраняется её ответ.
+// Повтор с тем же ключом получает сохранённый ответ, а параллельный повтор ждёт на ключе окончания первого запроса.
+// Если fn вернула ошибку, откатываются и её изменения, и ключ: такой запрос можно повторить
+func (uc *ShopUseCase) Idempotent(
+	ctx context.Context,
+	userId int,
+	key string,
+	requestHash []byte,
+	fn func(ctx context.Context) (entity.IdempotentResponse, error),
+) (entity.IdempotentResponse, bool, error) {
+	const op = "ShopUseCase.Idempotent"
+
+	var (
+		res      entity.IdempotentResponse
+		replayed bool
+	)
+
+	// всё, что fn откладывает через AfterCommit, выполняется только после коммита этой транзакции
+	hooks := &afterCommitHooks{}
+	ctx = context.WithValue(ctx, afterCommitKey{}, hooks)
+
+	err := uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		claimed, err := uc.repo.ClaimIdempotencyKey(ctx, userId, key, requestHash, uc.idempotencyTTL)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			stored, err := uc.repo.GetIdempotentResponse(ctx, userId, key)
+			if err != nil {
+				return err
+			}
+
+			if !bytes.Equal(stored.RequestHash, requestHash) {
+				return ErrIdempotencyKeyReused
+			}
+
+			res, replayed = stored, true
+
+			return nil
+		}
+
+		res, err = fn(ctx)
+		if err != nil {
+			return err
+		}
+
+		res.RequestHash = requestHash
+
+		return uc.repo.SaveIdempotentResponse(ctx, userId, key, res)
+	})
+	if err != nil {
+		if errors.Is(err, ErrIdempotencyKeyReused) {
+			return entity.IdempotentResponse{}, false, ErrIdempotencyKeyReused
+		}
+
+		return entity.IdempotentResponse{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hooks.run()
+
+	return res, replayed, nil
+}
+
+type afterCommitKey struct{}
+
+// afterCommitHooks действия, которые нужно выполнить после коммита внешней транзакции
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+func (h *afterCommitHooks) add(fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.hooks = append(h.hooks, fn)
+}
+
+func (h *afterCommitHooks) run() {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// AfterCommit выполняет fn, когда изменения сделанного в ctx вызова точно сохранены. Внутри Idempotent вызов
+// идёт во внешней транзакции, и fn откладывается до её коммита, а при откате не выполняется вовсе.
+// Вне Idempotent методы ShopUseCase коммитят свою транзакцию сами, и fn выполняется сразу
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks); ok {
+		hooks.add(fn)
+
+		return
+	}
+
+	fn()
+}
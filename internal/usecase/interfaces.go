@@ -2,28 +2,134 @@ package usecase
 
 import (
 	"context"
+	"encoding"
 	"github.com/k1v4/avito_shop/internal/entity"
+	"time"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.43.2 --name=IShopRepository
 type IShopRepository interface {
+	// WithTx выполняет fn в одной транзакции, вызовы репозитория должны получать ctx, переданный в fn
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 	SaveUser(ctx context.Context, username string, passhash []byte) (int, error)
 	FindUser(ctx context.Context, username string) (entity.User, error)
 	BuyItem(ctx context.Context, userId, itemId, quantity int) error
-	GetItemUser(ctx context.Context, userId int) (entity.Inventory, error)
+	// GetItemByName ищет среди неудалённых товаров, в том числе неактивных
 	GetItemByName(ctx context.Context, itemId string) (entity.Item, error)
-	GetItemById(ctx context.Context, itemId int) (string, error)
 	GetUserById(ctx context.Context, userId int) (entity.User, error)
+	GetUserByIdForUpdate(ctx context.Context, userId int) (entity.User, error)
+	SetUserRole(ctx context.Context, username, role string) error
+	SaveGrant(ctx context.Context, grant entity.Grant) (entity.Grant, error)
+	ClaimAllowanceRun(ctx context.Context, period string) (bool, error)
+	GrantAll(ctx context.Context, amount int, reason string) ([]int, error)
+	// PostEntry записывает проводку с её postings, кошельки создаются при первой проводке
+	PostEntry(ctx context.Context, entry entity.JournalEntry) (int, error)
+	// Reconcile сверяет балансы пользователей с суммами postings их кошельков и ищет несбалансированные проводки
+	Reconcile(ctx context.Context) (entity.Reconciliation, error)
 	TakeGiveCoins(ctx context.Context, userId, amount int) error
-	MakeRecord(ctx context.Context, fromUserId, toUserId, amount int) error
-	TakeRecords(ctx context.Context, userId int) ([]entity.BothDirection, error)
+	// TakeCoins списывает монеты или возвращает ErrNoCoins, если их не хватает
+	TakeCoins(ctx context.Context, userId, amount int) error
+	MakeRecord(ctx context.Context, fromUserId, toUserId, amount int) (int, error)
+	TakeSentRecords(ctx context.Context, userId, before, limit int) ([]entity.SentItem, error)
+	TakeReceivedRecords(ctx context.Context, userId, before, limit int) ([]entity.ReceivedItem, error)
+	MakePurchase(ctx context.Context, userId, itemId, price, quantity int) (int, error)
+	// TakeStock списывает товар со склада, блокирует строку товара до конца транзакции и возвращает её.
+	// Если товар удалён, возвращает ErrNoItem, если снят с продажи - ErrItemInactive,
+	// если его не хватает - ErrOutOfStock
+	TakeStock(ctx context.Context, itemId, quantity int) (entity.Item, error)
+	PurchasedQuantity(ctx context.Context, userId, itemId int) (int, error)
+	// ReturnStock возвращает товар на склад, товары без остатка не меняются
+	ReturnStock(ctx context.Context, itemId, quantity int) error
+	// GetPurchaseForUpdate возвращает ErrNoPurchase, если покупки нет
+	GetPurchaseForUpdate(ctx context.Context, purchaseId int) (entity.Purchase, error)
+	// MarkRefunded возвращает ErrAlreadyRefunded, если возвращённых штук стало бы больше купленных
+	MarkRefunded(ctx context.Context, purchaseId, quantity int) error
+	// TakeInventory возвращает ErrNotInInventory, если в инвентаре меньше quantity штук товара
+	TakeInventory(ctx context.Context, userId, itemId, quantity int) error
+	SaveRefund(ctx context.Context, refund entity.Refund) (entity.Refund, error)
+	TakePurchases(ctx context.Context, userId, before, limit int) ([]entity.Purchase, error)
+	// GetCart и GetCartForUpdate возвращают строки корзины в порядке возрастания id товара.
+	// GetCartForUpdate блокирует их до конца транзакции
+	GetCart(ctx context.Context, userId int) ([]entity.CartItem, error)
+	GetCartForUpdate(ctx context.Context, userId int) ([]entity.CartItem, error)
+	// AddToCart возвращает, сколько штук товара стало в корзине
+	AddToCart(ctx context.Context, userId, itemId, quantity int) (int, error)
+	// RemoveFromCart возвращает ErrNoItem, если товара с таким именем в корзине нет
+	RemoveFromCart(ctx context.Context, userId int, itemName string) error
+	ClearCart(ctx context.Context, userId int) error
+	CreateOrder(ctx context.Context, userId, total int) (entity.Order, error)
+	// SaveOrderLines записывает строки заказа в покупки со ссылкой на заказ
+	SaveOrderLines(ctx context.Context, userId, orderId int, lines []entity.OrderLine) error
+	TakeOrders(ctx context.Context, userId, before, limit int) ([]entity.Order, error)
+	// TakeInfo собирает всё для /api/info за фиксированное число запросов, переводов в каждую сторону,
+	// покупок и заказов возвращается не больше pageLimit
+	TakeInfo(ctx context.Context, userId, pageLimit int) (entity.ResponseInfo, error)
+	ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error)
+	GetItem(ctx context.Context, itemId int) (entity.Item, error)
+	// CreateItem и UpdateItem возвращают ErrItemExists, если имя занято другим неудалённым товаром
+	CreateItem(ctx context.Context, item entity.Item) (entity.Item, error)
+	UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error)
+	DeleteItem(ctx context.Context, itemId int) error
+	SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error
+	// GetRefreshTokenForUpdate возвращает ErrNoRefreshToken, если токена с таким хэшем нет
+	GetRefreshTokenForUpdate(ctx context.Context, hash []byte) (entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, tokenId int) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyId string) error
+	// ClaimIdempotencyKey занимает ключ в текущей транзакции, false - ключ уже использован
+	ClaimIdempotencyKey(ctx context.Context, userId int, key string, requestHash []byte, ttl time.Duration) (bool, error)
+	GetIdempotentResponse(ctx context.Context, userId int, key string) (entity.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, userId int, key string, res entity.IdempotentResponse) error
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.43.2 --name=IShopService
 type IShopService interface {
-	Login(ctx context.Context, username, password string) (string, error)
-	Register(ctx context.Context, username, password string) (string, error)
-	BuyItem(ctx context.Context, userId int, itemName string) error
+	Login(ctx context.Context, username, password string) (entity.AuthResponse, error)
+	Register(ctx context.Context, username, password string) (entity.AuthResponse, error)
+	// Refresh обменивает refresh-токен на новую пару токенов, старый refresh-токен при этом отзывается
+	Refresh(ctx context.Context, refreshToken string) (entity.AuthResponse, error)
+	// Logout отзывает access-токен и, если передан, refresh-токен вместе со всем его семейством
+	Logout(ctx context.Context, userId int, accessToken, refreshToken string) error
+	// Idempotent выполняет fn и сохраняет её ответ под ключом key в одной транзакции с изменениями, сделанными в fn.
+	// Для уже использованного ключа fn не вызывается, а возвращается сохранённый ответ и replayed = true
+	Idempotent(ctx context.Context, userId int, key string, requestHash []byte, fn func(ctx context.Context) (entity.IdempotentResponse, error)) (res entity.IdempotentResponse, replayed bool, err error)
+	BuyItem(ctx context.Context, userId int, itemName string, quantity int) error
 	SendCoins(ctx context.Context, toUserName string, fromUserId, amount int) error
 	GetInfo(ctx context.Context, userId int) (entity.ResponseInfo, error)
+	GetPurchases(ctx context.Context, userId, before, limit int) (entity.PurchasePage, error)
+	GetCart(ctx context.Context, userId int) (entity.Cart, error)
+	// AddToCart кладёт в корзину quantity штук товара и возвращает корзину
+	AddToCart(ctx context.Context, userId int, itemName string, quantity int) (entity.Cart, error)
+	RemoveFromCart(ctx context.Context, userId int, itemName string) error
+	// Checkout оформляет заказ из всей корзины одной транзакцией: товары, остатки и одно списание монет.
+	// Если что-то из корзины купить нельзя, не покупается ничего и корзина остаётся как была
+	Checkout(ctx context.Context, userId int) (entity.Order, error)
+	GetOrders(ctx context.Context, userId, before, limit int) (entity.OrderPage, error)
+	// RefundPurchase возвращает покупку целиком или частично. Пользователь с правом PermRefundPurchases
+	// возвращает любую покупку, остальные - только свою и не позже окна возврата
+	RefundPurchase(ctx context.Context, by entity.Principal, purchaseId int, req entity.RefundRequest) (entity.Refund, error)
+	GetSentHistory(ctx context.Context, userId, before, limit int) (entity.Page[entity.SentItem], error)
+	GetReceivedHistory(ctx context.Context, userId, before, limit int) (entity.Page[entity.ReceivedItem], error)
+	ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error)
+	GetItem(ctx context.Context, itemId int) (entity.Item, error)
+	CreateItem(ctx context.Context, req entity.ItemRequest) (entity.Item, error)
+	UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error)
+	DeleteItem(ctx context.Context, itemId int) error
+	// SetRole назначает пользователю роль. Новая роль попадает в токены, выданные после смены
+	SetRole(ctx context.Context, username, role string) error
+	// GrantCoins начисляет монеты пользователям из запроса одной транзакцией: либо всем, либо никому
+	GrantCoins(ctx context.Context, grantedBy int, req entity.GrantRequest) ([]entity.Grant, error)
+	// RunAllowance выполняет регулярное начисление за период, в который попадает now.
+	// Если период уже начислен, в том числе другой репликой, ничего не делает и возвращает 0
+	RunAllowance(ctx context.Context, allowance entity.Allowance, now time.Time) (int, error)
+	// Reconcile сверяет балансы пользователей с журналом двойной записи
+	Reconcile(ctx context.Context) (entity.Reconciliation, error)
+}
+
+// Cache хранилище закэшированных ответов. Get возвращает ErrCacheMiss, если ключа нет
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.43.2 --name=Cache
+type Cache interface {
+	Get(ctx context.Context, key string, dst encoding.BinaryUnmarshaler) error
+	Set(ctx context.Context, key string, value encoding.BinaryMarshaler, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
 }
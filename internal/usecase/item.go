@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxItemNameLength     = 255
+	maxItemImageURLLength = 2048
+)
+
+// ListItems возвращает каталог. Неактивные товары видны только администраторам
+func (uc *ShopUseCase) ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error) {
+	const op = "ShopUseCase.ListItems"
+
+	items, err := uc.repo.ListItems(ctx, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+func (uc *ShopUseCase) GetItem(ctx context.Context, itemId int) (entity.Item, error) {
+	const op = "ShopUseCase.GetItem"
+
+	item, err := uc.repo.GetItem(ctx, itemId)
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return item, nil
+}
+
+func (uc *ShopUseCase) CreateItem(ctx context.Context, req entity.ItemRequest) (entity.Item, error) {
+	const op = "ShopUseCase.CreateItem"
+
+	if req.Name == nil || req.Price == nil {
+		return entity.Item{}, ErrInvalidItem.WithMessage("name and price are required")
+	}
+
+	req = normalizeItemRequest(req)
+	if err := validateItemRequest(req); err != nil {
+		return entity.Item{}, err
+	}
+
+	item := entity.Item{
+		Name:   *req.Name,
+		Price:  *req.Price,
+		Active: true,
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.ImageURL != nil {
+		item.ImageURL = *req.ImageURL
+	}
+	if req.Active != nil {
+		item.Active = *req.Active
+	}
+	if req.Stock != nil && *req.Stock != entity.Unlimited {
+		item.Stock = req.Stock
+	}
+	if req.PerUserLimit != nil && *req.PerUserLimit != entity.Unlimited {
+		item.PerUserLimit = req.PerUserLimit
+	}
+
+	created, err := uc.repo.CreateItem(ctx, item)
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// UpdateItem меняет только переданные поля. Цена уже совершённых покупок не меняется, она хранится в покупке
+func (uc *ShopUseCase) UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error) {
+	const op = "ShopUseCase.UpdateItem"
+
+	req = normalizeItemRequest(req)
+	if err := validateItemRequest(req); err != nil {
+		return entity.Item{}, err
+	}
+
+	updated, err := uc.repo.UpdateItem(ctx, itemId, req)
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteItem снимает товар с продажи навсегда. Купленные экземпляры остаются в инвентаре
+func (uc *ShopUseCase) DeleteItem(ctx context.Context, itemId int) error {
+	const op = "ShopUseCase.DeleteItem"
+
+	err := uc.repo.DeleteItem(ctx, itemId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func normalizeItemRequest(req entity.ItemRequest) entity.ItemRequest {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+
+	if req.ImageURL != nil {
+		imageURL := strings.TrimSpace(*req.ImageURL)
+		req.ImageURL = &imageURL
+	}
+
+	return req
+}
+
+// validateItemRequest проверяет заданные поля, отсутствующие не проверяются
+func validateItemRequest(req entity.ItemRequest) error {
+	if req.Name != nil {
+		if *req.Name == "" || utf8.RuneCountInString(*req.Name) > maxItemNameLength {
+			return ErrInvalidItem.WithMessage(fmt.Sprintf("name must be 1 to %d characters long", maxItemNameLength))
+		}
+
+		// имя товара - часть пути /api/buy/{item}
+		if strings.Contains(*req.Name, "/") {
+			return ErrInvalidItem.WithMessage("name must not contain '/'")
+		}
+	}
+
+	if req.Price != nil && *req.Price <= 0 {
+		return ErrInvalidItem.WithMessage("price must be greater than 0")
+	}
+
+	if req.Stock != nil && *req.Stock < 0 && *req.Stock != entity.Unlimited {
+		return ErrInvalidItem.WithMessage(fmt.Sprintf("stock must be 0 or greater, %d for unlimited", entity.Unlimited))
+	}
+
+	if req.PerUserLimit != nil && *req.PerUserLimit <= 0 && *req.PerUserLimit != entity.Unlimited {
+		return ErrInvalidItem.WithMessage(fmt.Sprintf("per user limit must be greater than 0, %d for unlimited", entity.Unlimited))
+	}
+
+	if req.ImageURL != nil && *req.ImageURL != "" {
+		if len(*req.ImageURL) > maxItemImageURLLength {
+			return ErrInvalidItem.WithMessage(fmt.Sprintf("image url must be at most %d characters long", maxItemImageURLLength))
+		}
+
+		u, err := url.Parse(*req.ImageURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidItem.WithMessage("image url must be an absolute http or https url")
+		}
+	}
+
+	return nil
+}
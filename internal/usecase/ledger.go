@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+)
+
+var ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+
+// post записывает проводку. Вызывается внутри WithTx вместе с изменением баланса, которое она отражает
+func (uc *ShopUseCase) post(ctx context.Context, entry entity.JournalEntry) error {
+	if !entry.Balanced() {
+		return fmt.Errorf("%w: %s %d", ErrUnbalancedEntry, entry.Kind, entry.ReferenceId)
+	}
+
+	_, err := uc.repo.PostEntry(ctx, entry)
+
+	return err
+}
+
+func (uc *ShopUseCase) Reconcile(ctx context.Context) (entity.Reconciliation, error) {
+	const op = "ShopUseCase.Reconcile"
+
+	res, err := uc.repo.Reconcile(ctx)
+	if err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// issue проводка выпуска amount монет из эмиссии на кошелёк пользователя
+func issue(kind string, referenceId, userId, amount int, description string) entity.JournalEntry {
+	return entity.JournalEntry{
+		Kind:        kind,
+		ReferenceId: referenceId,
+		Description: description,
+		Postings: []entity.Posting{
+			{Account: entity.IssuanceAccount, Amount: -amount},
+			{Account: entity.WalletAccount(userId), Amount: amount},
+		},
+	}
+}
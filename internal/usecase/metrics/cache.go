@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"time"
+)
+
+// Cache считает попадания и промахи Get вокруг usecase.Cache. name попадает в метку cache
+type Cache struct {
+	usecase.Cache
+	name string
+	m    *Metrics
+}
+
+func NewCache(c usecase.Cache, name string, m *Metrics) *Cache {
+	return &Cache{Cache: c, name: name, m: m}
+}
+
+func (c *Cache) Get(ctx context.Context, key string, dst encoding.BinaryUnmarshaler) error {
+	err := c.Cache.Get(ctx, key, dst)
+
+	result := "hit"
+	switch {
+	case errors.Is(err, usecase.ErrCacheMiss):
+		result = "miss"
+	case err != nil:
+		result = "error"
+	}
+	c.m.cacheRequests.WithLabelValues(c.name, result).Inc()
+
+	return err
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value encoding.BinaryMarshaler, ttl time.Duration) error {
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	return c.Cache.Delete(ctx, keys...)
+}
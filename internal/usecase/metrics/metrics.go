@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "avito_shop"
+
+// reasonInternal причина неудачи для ошибок, которые не отдаются клиенту с кодом
+const reasonInternal = "internal"
+
+// Metrics метрики сервиса и реестр, через который они отдаются на /metrics
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	coinsTransferred prometheus.Counter
+	itemsSold        *prometheus.CounterVec
+	purchaseFailures *prometheus.CounterVec
+
+	dbDuration *prometheus.HistogramVec
+	dbErrors   *prometheus.CounterVec
+
+	cacheRequests *prometheus.CounterVec
+}
+
+// New создаёт метрики в собственном реестре, туда же попадают метрики рантайма Go и процесса
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		coinsTransferred: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "coins_transferred_total",
+			Help:      "Coins sent between users.",
+		}),
+		itemsSold: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "items_sold_total",
+			Help:      "Items sold by name, including items bought through checkout.",
+		}, []string{"item"}),
+		purchaseFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "purchase_failures_total",
+			Help:      "Failed purchases and checkouts by error code.",
+		}, []string{"reason"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "call_duration_seconds",
+			Help:      "Repository call latency by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "call_errors_total",
+			Help:      "Repository calls that returned an error, by method.",
+		}, []string{"method"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "Cache lookups by cache and result: hit, miss or error.",
+		}, []string{"cache", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.coinsTransferred,
+		m.itemsSold,
+		m.purchaseFailures,
+		m.dbDuration,
+		m.dbErrors,
+		m.cacheRequests,
+	)
+
+	return m
+}
+
+// MustRegister добавляет в реестр сторонние коллекторы, например статистику пула соединений
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP учитывает обработанный HTTP-запрос. route - шаблон маршрута, а не путь, чтобы число рядов не росло
+func (m *Metrics) ObserveHTTP(route, method string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpDuration.WithLabelValues(route, method, code).Observe(d.Seconds())
+}
+
+// reason код ошибки usecase для метки; у внутренних ошибок кода нет, их число и так ограничено
+func reason(err error) string {
+	var e *usecase.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return reasonInternal
+}
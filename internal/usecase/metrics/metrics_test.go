@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/internal/usecase/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	m := New()
+	s := mocks.NewIShopService(t)
+	svc := NewService(s, m)
+
+	s.On("BuyItem", ctx, 1, "cup", 2).Return(nil).Once()
+	s.On("BuyItem", ctx, 1, "cup", 1).Return(usecase.ErrNoCoins).Once()
+	s.On("BuyItem", ctx, 1, "pen", 1).Return(errors.New("db is down")).Once()
+	s.On("Checkout", ctx, 1).Return(entity.Order{Id: 7, Lines: []entity.OrderLine{
+		{Type: "cup", Quantity: 3},
+		{Type: "book", Quantity: 1},
+	}}, nil).Once()
+	s.On("Checkout", ctx, 2).Return(entity.Order{}, usecase.ErrEmptyCart).Once()
+	s.On("SendCoins", ctx, "bob", 1, 100).Return(nil).Once()
+	s.On("SendCoins", ctx, "bob", 1, 50).Return(usecase.ErrNoCoins).Once()
+	s.On("GetInfo", ctx, 1).Return(entity.ResponseInfo{Coins: 10}, nil).Once()
+
+	assert.NoError(t, svc.BuyItem(ctx, 1, "cup", 2))
+	assert.ErrorIs(t, svc.BuyItem(ctx, 1, "cup", 1), usecase.ErrNoCoins)
+	assert.Error(t, svc.BuyItem(ctx, 1, "pen", 1))
+
+	order, err := svc.Checkout(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, order.Id)
+
+	_, err = svc.Checkout(ctx, 2)
+	assert.ErrorIs(t, err, usecase.ErrEmptyCart)
+
+	assert.NoError(t, svc.SendCoins(ctx, "bob", 1, 100))
+	assert.ErrorIs(t, svc.SendCoins(ctx, "bob", 1, 50), usecase.ErrNoCoins)
+
+	// методы без метрик вызываются как есть
+	info, err := svc.GetInfo(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, info.Coins)
+
+	assert.Equal(t, 5.0, testutil.ToFloat64(m.itemsSold.WithLabelValues("cup")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.itemsSold.WithLabelValues("book")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.itemsSold.WithLabelValues("pen")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.purchaseFailures.WithLabelValues(usecase.CodeInsufficientFunds)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.purchaseFailures.WithLabelValues(usecase.CodeCartEmpty)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.purchaseFailures.WithLabelValues(reasonInternal)))
+	assert.Equal(t, 100.0, testutil.ToFloat64(m.coinsTransferred))
+}
+
+func TestService_AfterCommit(t *testing.T) {
+	m := New()
+	s := mocks.NewIShopService(t)
+	svc := NewService(s, m)
+
+	repo := mocks.NewIShopRepository(t)
+	uc := usecase.NewShopUseCase(repo, mocks.NewCache(t), nil)
+
+	repo.On("WithTx", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) })
+	repo.On("ClaimIdempotencyKey", mock.Anything, 1, mock.Anything, []byte("hash"), mock.Anything).Return(true, nil)
+	// первая внешняя транзакция откатывается уже после успешной покупки
+	repo.On("SaveIdempotentResponse", mock.Anything, 1, "rolled-back", mock.Anything).Return(errors.New("db is down")).Once()
+	repo.On("SaveIdempotentResponse", mock.Anything, 1, "committed", mock.Anything).Return(nil).Once()
+	s.On("BuyItem", mock.Anything, 1, "cup", 2).Return(nil).Twice()
+
+	buy := func(ctx context.Context) (entity.IdempotentResponse, error) {
+		return entity.IdempotentResponse{StatusCode: 200}, svc.BuyItem(ctx, 1, "cup", 2)
+	}
+
+	_, _, err := uc.Idempotent(context.Background(), 1, "rolled-back", []byte("hash"), buy)
+	assert.Error(t, err)
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.itemsSold.WithLabelValues("cup")))
+
+	_, _, err = uc.Idempotent(context.Background(), 1, "committed", []byte("hash"), buy)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.itemsSold.WithLabelValues("cup")))
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+
+	m := New()
+	r := mocks.NewIShopRepository(t)
+	repo := NewRepository(r, m)
+
+	r.On("TakeCoins", ctx, 1, 10).Return(nil).Once()
+	r.On("TakeCoins", ctx, 1, 1000).Return(usecase.ErrNoCoins).Once()
+	r.On("GetUserById", ctx, 1).Return(entity.User{Id: 1}, nil).Once()
+
+	assert.NoError(t, repo.TakeCoins(ctx, 1, 10))
+	assert.ErrorIs(t, repo.TakeCoins(ctx, 1, 1000), usecase.ErrNoCoins)
+
+	user, err := repo.GetUserById(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, user.Id)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.dbErrors.WithLabelValues("TakeCoins")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.dbErrors.WithLabelValues("GetUserById")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.dbDuration))
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	m := New()
+	c := mocks.NewCache(t)
+	cache := NewCache(c, "info", m)
+
+	var dst entity.ResponseInfo
+	c.On("Get", ctx, "hit", &dst).Return(nil).Once()
+	c.On("Get", ctx, "miss", &dst).Return(usecase.ErrCacheMiss).Once()
+	c.On("Get", ctx, "broken", &dst).Return(errors.New("connection refused")).Once()
+	c.On("Set", ctx, "hit", &dst, time.Minute).Return(nil).Once()
+	c.On("Delete", ctx, "hit", "miss").Return(nil).Once()
+
+	assert.NoError(t, cache.Get(ctx, "hit", &dst))
+	assert.ErrorIs(t, cache.Get(ctx, "miss", &dst), usecase.ErrCacheMiss)
+	assert.Error(t, cache.Get(ctx, "broken", &dst))
+	assert.NoError(t, cache.Set(ctx, "hit", &dst, time.Minute))
+	assert.NoError(t, cache.Delete(ctx, "hit", "miss"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheRequests.WithLabelValues("info", "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheRequests.WithLabelValues("info", "miss")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheRequests.WithLabelValues("info", "error")))
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.ObserveHTTP("/api/info", "GET", 200, 10*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `avito_shop_http_requests_total{method="GET",route="/api/info",status="200"} 1`)
+	assert.Contains(t, string(body), `avito_shop_http_request_duration_seconds_count{method="GET",route="/api/info",status="200"} 1`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector отдаёт статистику пула соединений postgres, она снимается в момент запроса /metrics
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns      *prometheus.Desc
+	idleConns          *prometheus.Desc
+	totalConns         *prometheus.Desc
+	maxConns           *prometheus.Desc
+	acquireCount       *prometheus.Desc
+	acquireDuration    *prometheus.Desc
+	emptyAcquireCount  *prometheus.Desc
+	canceledAcquires   *prometheus.Desc
+	constructingConns  *prometheus.Desc
+	newConnsCount      *prometheus.Desc
+	maxLifetimeDestroy *prometheus.Desc
+	maxIdleDestroy     *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &PoolCollector{
+		pool:               pool,
+		acquiredConns:      desc("acquired_connections", "Connections currently in use."),
+		idleConns:          desc("idle_connections", "Idle connections in the pool."),
+		totalConns:         desc("total_connections", "All connections in the pool."),
+		maxConns:           desc("max_connections", "Maximum pool size."),
+		acquireCount:       desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:    desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquireCount:  desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquires:   desc("canceled_acquires_total", "Acquires canceled by their context."),
+		constructingConns:  desc("constructing_connections", "Connections being established."),
+		newConnsCount:      desc("new_connections_total", "Connections opened by the pool."),
+		maxLifetimeDestroy: desc("max_lifetime_destroys_total", "Connections closed because of MaxConnLifetime."),
+		maxIdleDestroy:     desc("max_idle_destroys_total", "Connections closed because of MaxConnIdleTime."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquiredConns, float64(s.AcquiredConns()))
+	gauge(c.idleConns, float64(s.IdleConns()))
+	gauge(c.totalConns, float64(s.TotalConns()))
+	gauge(c.maxConns, float64(s.MaxConns()))
+	gauge(c.constructingConns, float64(s.ConstructingConns()))
+	counter(c.acquireCount, float64(s.AcquireCount()))
+	counter(c.acquireDuration, s.AcquireDuration().Seconds())
+	counter(c.emptyAcquireCount, float64(s.EmptyAcquireCount()))
+	counter(c.canceledAcquires, float64(s.CanceledAcquireCount()))
+	counter(c.newConnsCount, float64(s.NewConnsCount()))
+	counter(c.maxLifetimeDestroy, float64(s.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroy, float64(s.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,412 @@
+package metrics
+
+import (
+	"context"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"time"
+)
+
+// Repository измеряет время и ошибки вызовов usecase.IShopRepository по методам.
+// Ожидаемые ответы вроде ErrNoUser или ErrNoCoins тоже считаются ошибками вызова
+type Repository struct {
+	usecase.IShopRepository
+	m *Metrics
+}
+
+func NewRepository(r usecase.IShopRepository, m *Metrics) *Repository {
+	return &Repository{IShopRepository: r, m: m}
+}
+
+// WithTx измеряет транзакцию целиком, вызовы внутри fn учитываются по отдельности
+func (r *Repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	err := r.IShopRepository.WithTx(ctx, fn)
+	r.observe("WithTx", start, err)
+
+	return err
+}
+
+func (r *Repository) SaveUser(ctx context.Context, username string, passhash []byte) (int, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.SaveUser(ctx, username, passhash)
+	r.observe("SaveUser", start, err)
+
+	return res, err
+}
+
+func (r *Repository) FindUser(ctx context.Context, username string) (entity.User, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.FindUser(ctx, username)
+	r.observe("FindUser", start, err)
+
+	return res, err
+}
+
+func (r *Repository) BuyItem(ctx context.Context, userId, itemId, quantity int) error {
+	start := time.Now()
+	err := r.IShopRepository.BuyItem(ctx, userId, itemId, quantity)
+	r.observe("BuyItem", start, err)
+
+	return err
+}
+
+func (r *Repository) GetItemByName(ctx context.Context, itemId string) (entity.Item, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetItemByName(ctx, itemId)
+	r.observe("GetItemByName", start, err)
+
+	return res, err
+}
+
+func (r *Repository) GetUserById(ctx context.Context, userId int) (entity.User, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetUserById(ctx, userId)
+	r.observe("GetUserById", start, err)
+
+	return res, err
+}
+
+func (r *Repository) GetUserByIdForUpdate(ctx context.Context, userId int) (entity.User, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetUserByIdForUpdate(ctx, userId)
+	r.observe("GetUserByIdForUpdate", start, err)
+
+	return res, err
+}
+
+func (r *Repository) SetUserRole(ctx context.Context, username, role string) error {
+	start := time.Now()
+	err := r.IShopRepository.SetUserRole(ctx, username, role)
+	r.observe("SetUserRole", start, err)
+
+	return err
+}
+
+func (r *Repository) SaveGrant(ctx context.Context, grant entity.Grant) (entity.Grant, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.SaveGrant(ctx, grant)
+	r.observe("SaveGrant", start, err)
+
+	return res, err
+}
+
+func (r *Repository) ClaimAllowanceRun(ctx context.Context, period string) (bool, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.ClaimAllowanceRun(ctx, period)
+	r.observe("ClaimAllowanceRun", start, err)
+
+	return res, err
+}
+
+func (r *Repository) GrantAll(ctx context.Context, amount int, reason string) ([]int, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GrantAll(ctx, amount, reason)
+	r.observe("GrantAll", start, err)
+
+	return res, err
+}
+
+func (r *Repository) PostEntry(ctx context.Context, entry entity.JournalEntry) (int, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.PostEntry(ctx, entry)
+	r.observe("PostEntry", start, err)
+
+	return res, err
+}
+
+func (r *Repository) Reconcile(ctx context.Context) (entity.Reconciliation, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.Reconcile(ctx)
+	r.observe("Reconcile", start, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeGiveCoins(ctx context.Context, userId, amount int) error {
+	start := time.Now()
+	err := r.IShopRepository.TakeGiveCoins(ctx, userId, amount)
+	r.observe("TakeGiveCoins", start, err)
+
+	return err
+}
+
+func (r *Repository) TakeCoins(ctx context.Context, userId, amount int) error {
+	start := time.Now()
+	err := r.IShopRepository.TakeCoins(ctx, userId, amount)
+	r.observe("TakeCoins", start, err)
+
+	return err
+}
+
+func (r *Repository) MakeRecord(ctx context.Context, fromUserId, toUserId, amount int) (int, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.MakeRecord(ctx, fromUserId, toUserId, amount)
+	r.observe("MakeRecord", start, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeSentRecords(ctx context.Context, userId, before, limit int) ([]entity.SentItem, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.TakeSentRecords(ctx, userId, before, limit)
+	r.observe("TakeSentRecords", start, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeReceivedRecords(ctx context.Context, userId, before, limit int) ([]entity.ReceivedItem, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.TakeReceivedRecords(ctx, userId, before, limit)
+	r.observe("TakeReceivedRecords", start, err)
+
+	return res, err
+}
+
+func (r *Repository) MakePurchase(ctx context.Context, userId, itemId, price, quantity int) (int, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.MakePurchase(ctx, userId, itemId, price, quantity)
+	r.observe("MakePurchase", start, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeStock(ctx context.Context, itemId, quantity int) (entity.Item, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.TakeStock(ctx, itemId, quantity)
+	r.observe("TakeStock", start, err)
+
+	return res, err
+}
+
+func (r *Repository) PurchasedQuantity(ctx context.Context, userId, itemId int) (int, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.PurchasedQuantity(ctx, userId, itemId)
+	r.observe("PurchasedQuantity", start, err)
+
+	return res, err
+}
+
+func (r *Repository) ReturnStock(ctx context.Context, itemId, quantity int) error {
+	start := time.Now()
+	err := r.IShopRepository.ReturnStock(ctx, itemId, quantity)
+	r.observe("ReturnStock", start, err)
+
+	return err
+}
+
+func (r *Repository) GetPurchaseForUpdate(ctx context.Context, purchaseId int) (entity.Purchase, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetPurchaseForUpdate(ctx, purchaseId)
+	r.observe("GetPurchaseForUpdate", start, err)
+
+	return res, err
+}
+
+func (r *Repository) MarkRefunded(ctx context.Context, purchaseId, quantity int) error {
+	start := time.Now()
+	err := r.IShopRepository.MarkRefunded(ctx, purchaseId, quantity)
+	r.observe("MarkRefunded", start, err)
+
+	return err
+}
+
+func (r *Repository) TakeInventory(ctx context.Context, userId, itemId, quantity int) error {
+	start := time.Now()
+	err := r.IShopRepository.TakeInventory(ctx, userId, itemId, quantity)
+	r.observe("TakeInventory", start, err)
+
+	return err
+}
+
+func (r *Repository) SaveRefund(ctx context.Context, refund entity.Refund) (entity.Refund, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.SaveRefund(ctx, refund)
+	r.observe("SaveRefund", start, err)
+
+	return res, err
+}
+
+func (r *Repository) TakePurchases(ctx context.Context, userId, before, limit int) ([]entity.Purchase, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.TakePurchases(ctx, userId, before, limit)
+	r.observe("TakePurchases", start, err)
+
+	return res, err
+}
+
+func (r *Repository) GetCart(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetCart(ctx, userId)
+	r.observe("GetCart", start, err)
+
+	return res, err
+}
+
+func (r *Repository) GetCartForUpdate(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetCartForUpdate(ctx, userId)
+	r.observe("GetCartForUpdate", start, err)
+
+	return res, err
+}
+
+func (r *Repository) AddToCart(ctx context.Context, userId, itemId, quantity int) (int, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.AddToCart(ctx, userId, itemId, quantity)
+	r.observe("AddToCart", start, err)
+
+	return res, err
+}
+
+func (r *Repository) RemoveFromCart(ctx context.Context, userId int, itemName string) error {
+	start := time.Now()
+	err := r.IShopRepository.RemoveFromCart(ctx, userId, itemName)
+	r.observe("RemoveFromCart", start, err)
+
+	return err
+}
+
+func (r *Repository) ClearCart(ctx context.Context, userId int) error {
+	start := time.Now()
+	err := r.IShopRepository.ClearCart(ctx, userId)
+	r.observe("ClearCart", start, err)
+
+	return err
+}
+
+func (r *Repository) CreateOrder(ctx context.Context, userId, total int) (entity.Order, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.CreateOrder(ctx, userId, total)
+	r.observe("CreateOrder", start, err)
+
+	return res, err
+}
+
+func (r *Repository) SaveOrderLines(ctx context.Context, userId, orderId int, lines []entity.OrderLine) error {
+	start := time.Now()
+	err := r.IShopRepository.SaveOrderLines(ctx, userId, orderId, lines)
+	r.observe("SaveOrderLines", start, err)
+
+	return err
+}
+
+func (r *Repository) TakeOrders(ctx context.Context, userId, before, limit int) ([]entity.Order, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.TakeOrders(ctx, userId, before, limit)
+	r.observe("TakeOrders", start, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeInfo(ctx context.Context, userId, pageLimit int) (entity.ResponseInfo, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.TakeInfo(ctx, userId, pageLimit)
+	r.observe("TakeInfo", start, err)
+
+	return res, err
+}
+
+func (r *Repository) ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.ListItems(ctx, includeInactive)
+	r.observe("ListItems", start, err)
+
+	return res, err
+}
+
+func (r *Repository) GetItem(ctx context.Context, itemId int) (entity.Item, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetItem(ctx, itemId)
+	r.observe("GetItem", start, err)
+
+	return res, err
+}
+
+func (r *Repository) CreateItem(ctx context.Context, item entity.Item) (entity.Item, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.CreateItem(ctx, item)
+	r.observe("CreateItem", start, err)
+
+	return res, err
+}
+
+func (r *Repository) UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.UpdateItem(ctx, itemId, req)
+	r.observe("UpdateItem", start, err)
+
+	return res, err
+}
+
+func (r *Repository) DeleteItem(ctx context.Context, itemId int) error {
+	start := time.Now()
+	err := r.IShopRepository.DeleteItem(ctx, itemId)
+	r.observe("DeleteItem", start, err)
+
+	return err
+}
+
+func (r *Repository) SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	start := time.Now()
+	err := r.IShopRepository.SaveRefreshToken(ctx, token)
+	r.observe("SaveRefreshToken", start, err)
+
+	return err
+}
+
+func (r *Repository) GetRefreshTokenForUpdate(ctx context.Context, hash []byte) (entity.RefreshToken, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetRefreshTokenForUpdate(ctx, hash)
+	r.observe("GetRefreshTokenForUpdate", start, err)
+
+	return res, err
+}
+
+func (r *Repository) RevokeRefreshToken(ctx context.Context, tokenId int) error {
+	start := time.Now()
+	err := r.IShopRepository.RevokeRefreshToken(ctx, tokenId)
+	r.observe("RevokeRefreshToken", start, err)
+
+	return err
+}
+
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	start := time.Now()
+	err := r.IShopRepository.RevokeRefreshTokenFamily(ctx, familyId)
+	r.observe("RevokeRefreshTokenFamily", start, err)
+
+	return err
+}
+
+func (r *Repository) ClaimIdempotencyKey(ctx context.Context, userId int, key string, requestHash []byte, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.ClaimIdempotencyKey(ctx, userId, key, requestHash, ttl)
+	r.observe("ClaimIdempotencyKey", start, err)
+
+	return res, err
+}
+
+func (r *Repository) GetIdempotentResponse(ctx context.Context, userId int, key string) (entity.IdempotentResponse, error) {
+	start := time.Now()
+	res, err := r.IShopRepository.GetIdempotentResponse(ctx, userId, key)
+	r.observe("GetIdempotentResponse", start, err)
+
+	return res, err
+}
+
+func (r *Repository) SaveIdempotentResponse(ctx context.Context, userId int, key string, res entity.IdempotentResponse) error {
+	start := time.Now()
+	err := r.IShopRepository.SaveIdempotentResponse(ctx, userId, key, res)
+	r.observe("SaveIdempotentResponse", start, err)
+
+	return err
+}
+
+func (r *Repository) observe(method string, start time.Time, err error) {
+	r.m.dbDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		r.m.dbErrors.WithLabelValues(method).Inc()
+	}
+}
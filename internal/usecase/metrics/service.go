@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+)
+
+// Service считает бизнес-метрики вокруг usecase.IShopService: переведённые монеты, проданные товары
+// и неудачные покупки. Успешные операции считаются через usecase.AfterCommit: внутри Idempotent
+// внешняя транзакция ещё может откатиться. Остальные методы вызываются без изменений
+type Service struct {
+	usecase.IShopService
+	m *Metrics
+}
+
+func NewService(s usecase.IShopService, m *Metrics) *Service {
+	return &Service{IShopService: s, m: m}
+}
+
+func (s *Service) BuyItem(ctx context.Context, userId int, itemName string, quantity int) error {
+	err := s.IShopService.BuyItem(ctx, userId, itemName, quantity)
+	if err != nil {
+		s.m.purchaseFailures.WithLabelValues(reason(err)).Inc()
+
+		return err
+	}
+
+	usecase.AfterCommit(ctx, func() {
+		s.m.itemsSold.WithLabelValues(itemName).Add(float64(quantity))
+	})
+
+	return nil
+}
+
+func (s *Service) Checkout(ctx context.Context, userId int) (entity.Order, error) {
+	order, err := s.IShopService.Checkout(ctx, userId)
+	if err != nil {
+		s.m.purchaseFailures.WithLabelValues(reason(err)).Inc()
+
+		return order, err
+	}
+
+	usecase.AfterCommit(ctx, func() {
+		for _, line := range order.Lines {
+			s.m.itemsSold.WithLabelValues(line.Type).Add(float64(line.Quantity))
+		}
+	})
+
+	return order, nil
+}
+
+func (s *Service) SendCoins(ctx context.Context, toUserName string, fromUserId, amount int) error {
+	err := s.IShopService.SendCoins(ctx, toUserName, fromUserId, amount)
+	if err != nil {
+		return err
+	}
+
+	usecase.AfterCommit(ctx, func() {
+		s.m.coinsTransferred.Add(float64(amount))
+	})
+
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/k1v4/avito_shop/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // IShopRepository is an autogenerated mock type for the IShopRepository type
@@ -14,6 +16,34 @@ type IShopRepository struct {
 	mock.Mock
 }
 
+// AddToCart provides a mock function with given fields: ctx, userId, itemId, quantity
+func (_m *IShopRepository) AddToCart(ctx context.Context, userId int, itemId int, quantity int) (int, error) {
+	ret := _m.Called(ctx, userId, itemId, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddToCart")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (int, error)); ok {
+		return rf(ctx, userId, itemId, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) int); ok {
+		r0 = rf(ctx, userId, itemId, quantity)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, itemId, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BuyItem provides a mock function with given fields: ctx, userId, itemId, quantity
 func (_m *IShopRepository) BuyItem(ctx context.Context, userId int, itemId int, quantity int) error {
 	ret := _m.Called(ctx, userId, itemId, quantity)
@@ -32,6 +62,154 @@ func (_m *IShopRepository) BuyItem(ctx context.Context, userId int, itemId int,
 	return r0
 }
 
+// ClaimAllowanceRun provides a mock function with given fields: ctx, period
+func (_m *IShopRepository) ClaimAllowanceRun(ctx context.Context, period string) (bool, error) {
+	ret := _m.Called(ctx, period)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimAllowanceRun")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, period)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, period)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, period)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimIdempotencyKey provides a mock function with given fields: ctx, userId, key, requestHash, ttl
+func (_m *IShopRepository) ClaimIdempotencyKey(ctx context.Context, userId int, key string, requestHash []byte, ttl time.Duration) (bool, error) {
+	ret := _m.Called(ctx, userId, key, requestHash, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimIdempotencyKey")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, []byte, time.Duration) (bool, error)); ok {
+		return rf(ctx, userId, key, requestHash, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, []byte, time.Duration) bool); ok {
+		r0 = rf(ctx, userId, key, requestHash, ttl)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, []byte, time.Duration) error); ok {
+		r1 = rf(ctx, userId, key, requestHash, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClearCart provides a mock function with given fields: ctx, userId
+func (_m *IShopRepository) ClearCart(ctx context.Context, userId int) error {
+	ret := _m.Called(ctx, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateItem provides a mock function with given fields: ctx, item
+func (_m *IShopRepository) CreateItem(ctx context.Context, item entity.Item) (entity.Item, error) {
+	ret := _m.Called(ctx, item)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateItem")
+	}
+
+	var r0 entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Item) (entity.Item, error)); ok {
+		return rf(ctx, item)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Item) entity.Item); ok {
+		r0 = rf(ctx, item)
+	} else {
+		r0 = ret.Get(0).(entity.Item)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.Item) error); ok {
+		r1 = rf(ctx, item)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateOrder provides a mock function with given fields: ctx, userId, total
+func (_m *IShopRepository) CreateOrder(ctx context.Context, userId int, total int) (entity.Order, error) {
+	ret := _m.Called(ctx, userId, total)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrder")
+	}
+
+	var r0 entity.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (entity.Order, error)); ok {
+		return rf(ctx, userId, total)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) entity.Order); ok {
+		r0 = rf(ctx, userId, total)
+	} else {
+		r0 = ret.Get(0).(entity.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userId, total)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteItem provides a mock function with given fields: ctx, itemId
+func (_m *IShopRepository) DeleteItem(ctx context.Context, itemId int) error {
+	ret := _m.Called(ctx, itemId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteItem")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, itemId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // FindUser provides a mock function with given fields: ctx, username
 func (_m *IShopRepository) FindUser(ctx context.Context, username string) (entity.User, error) {
 	ret := _m.Called(ctx, username)
@@ -60,23 +238,111 @@ func (_m *IShopRepository) FindUser(ctx context.Context, username string) (entit
 	return r0, r1
 }
 
-// GetItemById provides a mock function with given fields: ctx, itemId
-func (_m *IShopRepository) GetItemById(ctx context.Context, itemId int) (string, error) {
+// GetCart provides a mock function with given fields: ctx, userId
+func (_m *IShopRepository) GetCart(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	ret := _m.Called(ctx, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCart")
+	}
+
+	var r0 []entity.CartItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]entity.CartItem, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []entity.CartItem); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.CartItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCartForUpdate provides a mock function with given fields: ctx, userId
+func (_m *IShopRepository) GetCartForUpdate(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	ret := _m.Called(ctx, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCartForUpdate")
+	}
+
+	var r0 []entity.CartItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]entity.CartItem, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []entity.CartItem); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.CartItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetIdempotentResponse provides a mock function with given fields: ctx, userId, key
+func (_m *IShopRepository) GetIdempotentResponse(ctx context.Context, userId int, key string) (entity.IdempotentResponse, error) {
+	ret := _m.Called(ctx, userId, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIdempotentResponse")
+	}
+
+	var r0 entity.IdempotentResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (entity.IdempotentResponse, error)); ok {
+		return rf(ctx, userId, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) entity.IdempotentResponse); ok {
+		r0 = rf(ctx, userId, key)
+	} else {
+		r0 = ret.Get(0).(entity.IdempotentResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userId, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetItem provides a mock function with given fields: ctx, itemId
+func (_m *IShopRepository) GetItem(ctx context.Context, itemId int) (entity.Item, error) {
 	ret := _m.Called(ctx, itemId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetItemById")
+		panic("no return value specified for GetItem")
 	}
 
-	var r0 string
+	var r0 entity.Item
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entity.Item, error)); ok {
 		return rf(ctx, itemId)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) entity.Item); ok {
 		r0 = rf(ctx, itemId)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(entity.Item)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
@@ -116,27 +382,55 @@ func (_m *IShopRepository) GetItemByName(ctx context.Context, itemId string) (en
 	return r0, r1
 }
 
-// GetItemUser provides a mock function with given fields: ctx, userId
-func (_m *IShopRepository) GetItemUser(ctx context.Context, userId int) (entity.Inventory, error) {
-	ret := _m.Called(ctx, userId)
+// GetPurchaseForUpdate provides a mock function with given fields: ctx, purchaseId
+func (_m *IShopRepository) GetPurchaseForUpdate(ctx context.Context, purchaseId int) (entity.Purchase, error) {
+	ret := _m.Called(ctx, purchaseId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetItemUser")
+		panic("no return value specified for GetPurchaseForUpdate")
 	}
 
-	var r0 entity.Inventory
+	var r0 entity.Purchase
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (entity.Inventory, error)); ok {
-		return rf(ctx, userId)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entity.Purchase, error)); ok {
+		return rf(ctx, purchaseId)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) entity.Inventory); ok {
-		r0 = rf(ctx, userId)
+	if rf, ok := ret.Get(0).(func(context.Context, int) entity.Purchase); ok {
+		r0 = rf(ctx, purchaseId)
 	} else {
-		r0 = ret.Get(0).(entity.Inventory)
+		r0 = ret.Get(0).(entity.Purchase)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, userId)
+		r1 = rf(ctx, purchaseId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshTokenForUpdate provides a mock function with given fields: ctx, hash
+func (_m *IShopRepository) GetRefreshTokenForUpdate(ctx context.Context, hash []byte) (entity.RefreshToken, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRefreshTokenForUpdate")
+	}
+
+	var r0 entity.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (entity.RefreshToken, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) entity.RefreshToken); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		r0 = ret.Get(0).(entity.RefreshToken)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, hash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -172,45 +466,57 @@ func (_m *IShopRepository) GetUserById(ctx context.Context, userId int) (entity.
 	return r0, r1
 }
 
-// MakeRecord provides a mock function with given fields: ctx, fromUserId, toUserId, amount
-func (_m *IShopRepository) MakeRecord(ctx context.Context, fromUserId int, toUserId int, amount int) error {
-	ret := _m.Called(ctx, fromUserId, toUserId, amount)
+// GetUserByIdForUpdate provides a mock function with given fields: ctx, userId
+func (_m *IShopRepository) GetUserByIdForUpdate(ctx context.Context, userId int) (entity.User, error) {
+	ret := _m.Called(ctx, userId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for MakeRecord")
+		panic("no return value specified for GetUserByIdForUpdate")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
-		r0 = rf(ctx, fromUserId, toUserId, amount)
+	var r0 entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entity.User, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) entity.User); ok {
+		r0 = rf(ctx, userId)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(entity.User)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// SaveUser provides a mock function with given fields: ctx, username, passhash
-func (_m *IShopRepository) SaveUser(ctx context.Context, username string, passhash []byte) (int, error) {
-	ret := _m.Called(ctx, username, passhash)
+// GrantAll provides a mock function with given fields: ctx, amount, reason
+func (_m *IShopRepository) GrantAll(ctx context.Context, amount int, reason string) ([]int, error) {
+	ret := _m.Called(ctx, amount, reason)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveUser")
+		panic("no return value specified for GrantAll")
 	}
 
-	var r0 int
+	var r0 []int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) (int, error)); ok {
-		return rf(ctx, username, passhash)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) ([]int, error)); ok {
+		return rf(ctx, amount, reason)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) int); ok {
-		r0 = rf(ctx, username, passhash)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) []int); ok {
+		r0 = rf(ctx, amount, reason)
 	} else {
-		r0 = ret.Get(0).(int)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) error); ok {
-		r1 = rf(ctx, username, passhash)
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, amount, reason)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -218,47 +524,673 @@ func (_m *IShopRepository) SaveUser(ctx context.Context, username string, passha
 	return r0, r1
 }
 
-// TakeGiveCoins provides a mock function with given fields: ctx, userId, amount
-func (_m *IShopRepository) TakeGiveCoins(ctx context.Context, userId int, amount int) error {
-	ret := _m.Called(ctx, userId, amount)
+// ListItems provides a mock function with given fields: ctx, includeInactive
+func (_m *IShopRepository) ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error) {
+	ret := _m.Called(ctx, includeInactive)
 
 	if len(ret) == 0 {
-		panic("no return value specified for TakeGiveCoins")
+		panic("no return value specified for ListItems")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
-		r0 = rf(ctx, userId, amount)
+	var r0 []entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]entity.Item, error)); ok {
+		return rf(ctx, includeInactive)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []entity.Item); ok {
+		r0 = rf(ctx, includeInactive)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Item)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, includeInactive)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// TakeRecords provides a mock function with given fields: ctx, userId
-func (_m *IShopRepository) TakeRecords(ctx context.Context, userId int) ([]entity.BothDirection, error) {
-	ret := _m.Called(ctx, userId)
+// MakePurchase provides a mock function with given fields: ctx, userId, itemId, price, quantity
+func (_m *IShopRepository) MakePurchase(ctx context.Context, userId int, itemId int, price int, quantity int) (int, error) {
+	ret := _m.Called(ctx, userId, itemId, price, quantity)
 
 	if len(ret) == 0 {
-		panic("no return value specified for TakeRecords")
+		panic("no return value specified for MakePurchase")
 	}
 
-	var r0 []entity.BothDirection
+	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]entity.BothDirection, error)); ok {
-		return rf(ctx, userId)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) (int, error)); ok {
+		return rf(ctx, userId, itemId, price, quantity)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []entity.BothDirection); ok {
-		r0 = rf(ctx, userId)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) int); ok {
+		r0 = rf(ctx, userId, itemId, price, quantity)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, int) error); ok {
+		r1 = rf(ctx, userId, itemId, price, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MakeRecord provides a mock function with given fields: ctx, fromUserId, toUserId, amount
+func (_m *IShopRepository) MakeRecord(ctx context.Context, fromUserId int, toUserId int, amount int) (int, error) {
+	ret := _m.Called(ctx, fromUserId, toUserId, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MakeRecord")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (int, error)); ok {
+		return rf(ctx, fromUserId, toUserId, amount)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) int); ok {
+		r0 = rf(ctx, fromUserId, toUserId, amount)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, fromUserId, toUserId, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRefunded provides a mock function with given fields: ctx, purchaseId, quantity
+func (_m *IShopRepository) MarkRefunded(ctx context.Context, purchaseId int, quantity int) error {
+	ret := _m.Called(ctx, purchaseId, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRefunded")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, purchaseId, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PostEntry provides a mock function with given fields: ctx, entry
+func (_m *IShopRepository) PostEntry(ctx context.Context, entry entity.JournalEntry) (int, error) {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostEntry")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.JournalEntry) (int, error)); ok {
+		return rf(ctx, entry)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.JournalEntry) int); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.JournalEntry) error); ok {
+		r1 = rf(ctx, entry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurchasedQuantity provides a mock function with given fields: ctx, userId, itemId
+func (_m *IShopRepository) PurchasedQuantity(ctx context.Context, userId int, itemId int) (int, error) {
+	ret := _m.Called(ctx, userId, itemId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurchasedQuantity")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, userId, itemId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, userId, itemId)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userId, itemId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reconcile provides a mock function with given fields: ctx
+func (_m *IShopRepository) Reconcile(ctx context.Context) (entity.Reconciliation, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reconcile")
+	}
+
+	var r0 entity.Reconciliation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (entity.Reconciliation, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) entity.Reconciliation); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(entity.Reconciliation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFromCart provides a mock function with given fields: ctx, userId, itemName
+func (_m *IShopRepository) RemoveFromCart(ctx context.Context, userId int, itemName string) error {
+	ret := _m.Called(ctx, userId, itemName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFromCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userId, itemName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReturnStock provides a mock function with given fields: ctx, itemId, quantity
+func (_m *IShopRepository) ReturnStock(ctx context.Context, itemId int, quantity int) error {
+	ret := _m.Called(ctx, itemId, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReturnStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, itemId, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshToken provides a mock function with given fields: ctx, tokenId
+func (_m *IShopRepository) RevokeRefreshToken(ctx context.Context, tokenId int) error {
+	ret := _m.Called(ctx, tokenId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, tokenId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshTokenFamily provides a mock function with given fields: ctx, familyId
+func (_m *IShopRepository) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	ret := _m.Called(ctx, familyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshTokenFamily")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, familyId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveGrant provides a mock function with given fields: ctx, grant
+func (_m *IShopRepository) SaveGrant(ctx context.Context, grant entity.Grant) (entity.Grant, error) {
+	ret := _m.Called(ctx, grant)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveGrant")
+	}
+
+	var r0 entity.Grant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Grant) (entity.Grant, error)); ok {
+		return rf(ctx, grant)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Grant) entity.Grant); ok {
+		r0 = rf(ctx, grant)
+	} else {
+		r0 = ret.Get(0).(entity.Grant)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.Grant) error); ok {
+		r1 = rf(ctx, grant)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveIdempotentResponse provides a mock function with given fields: ctx, userId, key, res
+func (_m *IShopRepository) SaveIdempotentResponse(ctx context.Context, userId int, key string, res entity.IdempotentResponse) error {
+	ret := _m.Called(ctx, userId, key, res)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveIdempotentResponse")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, entity.IdempotentResponse) error); ok {
+		r0 = rf(ctx, userId, key, res)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveOrderLines provides a mock function with given fields: ctx, userId, orderId, lines
+func (_m *IShopRepository) SaveOrderLines(ctx context.Context, userId int, orderId int, lines []entity.OrderLine) error {
+	ret := _m.Called(ctx, userId, orderId, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveOrderLines")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, []entity.OrderLine) error); ok {
+		r0 = rf(ctx, userId, orderId, lines)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveRefreshToken provides a mock function with given fields: ctx, token
+func (_m *IShopRepository) SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveRefreshToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RefreshToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveRefund provides a mock function with given fields: ctx, refund
+func (_m *IShopRepository) SaveRefund(ctx context.Context, refund entity.Refund) (entity.Refund, error) {
+	ret := _m.Called(ctx, refund)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveRefund")
+	}
+
+	var r0 entity.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Refund) (entity.Refund, error)); ok {
+		return rf(ctx, refund)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Refund) entity.Refund); ok {
+		r0 = rf(ctx, refund)
+	} else {
+		r0 = ret.Get(0).(entity.Refund)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.Refund) error); ok {
+		r1 = rf(ctx, refund)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveUser provides a mock function with given fields: ctx, username, passhash
+func (_m *IShopRepository) SaveUser(ctx context.Context, username string, passhash []byte) (int, error) {
+	ret := _m.Called(ctx, username, passhash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveUser")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) (int, error)); ok {
+		return rf(ctx, username, passhash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) int); ok {
+		r0 = rf(ctx, username, passhash)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) error); ok {
+		r1 = rf(ctx, username, passhash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserRole provides a mock function with given fields: ctx, username, role
+func (_m *IShopRepository) SetUserRole(ctx context.Context, username string, role string) error {
+	ret := _m.Called(ctx, username, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeCoins provides a mock function with given fields: ctx, userId, amount
+func (_m *IShopRepository) TakeCoins(ctx context.Context, userId int, amount int) error {
+	ret := _m.Called(ctx, userId, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeCoins")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, userId, amount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeGiveCoins provides a mock function with given fields: ctx, userId, amount
+func (_m *IShopRepository) TakeGiveCoins(ctx context.Context, userId int, amount int) error {
+	ret := _m.Called(ctx, userId, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeGiveCoins")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, userId, amount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeInfo provides a mock function with given fields: ctx, userId, pageLimit
+func (_m *IShopRepository) TakeInfo(ctx context.Context, userId int, pageLimit int) (entity.ResponseInfo, error) {
+	ret := _m.Called(ctx, userId, pageLimit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeInfo")
+	}
+
+	var r0 entity.ResponseInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (entity.ResponseInfo, error)); ok {
+		return rf(ctx, userId, pageLimit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) entity.ResponseInfo); ok {
+		r0 = rf(ctx, userId, pageLimit)
+	} else {
+		r0 = ret.Get(0).(entity.ResponseInfo)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userId, pageLimit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeInventory provides a mock function with given fields: ctx, userId, itemId, quantity
+func (_m *IShopRepository) TakeInventory(ctx context.Context, userId int, itemId int, quantity int) error {
+	ret := _m.Called(ctx, userId, itemId, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeInventory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
+		r0 = rf(ctx, userId, itemId, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeOrders provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopRepository) TakeOrders(ctx context.Context, userId int, before int, limit int) ([]entity.Order, error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeOrders")
+	}
+
+	var r0 []entity.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]entity.Order, error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []entity.Order); ok {
+		r0 = rf(ctx, userId, before, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]entity.BothDirection)
+			r0 = ret.Get(0).([]entity.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, userId)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakePurchases provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopRepository) TakePurchases(ctx context.Context, userId int, before int, limit int) ([]entity.Purchase, error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakePurchases")
+	}
+
+	var r0 []entity.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]entity.Purchase, error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []entity.Purchase); ok {
+		r0 = rf(ctx, userId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeReceivedRecords provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopRepository) TakeReceivedRecords(ctx context.Context, userId int, before int, limit int) ([]entity.ReceivedItem, error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeReceivedRecords")
+	}
+
+	var r0 []entity.ReceivedItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]entity.ReceivedItem, error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []entity.ReceivedItem); ok {
+		r0 = rf(ctx, userId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ReceivedItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeSentRecords provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopRepository) TakeSentRecords(ctx context.Context, userId int, before int, limit int) ([]entity.SentItem, error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeSentRecords")
+	}
+
+	var r0 []entity.SentItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]entity.SentItem, error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []entity.SentItem); ok {
+		r0 = rf(ctx, userId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.SentItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeStock provides a mock function with given fields: ctx, itemId, quantity
+func (_m *IShopRepository) TakeStock(ctx context.Context, itemId int, quantity int) (entity.Item, error) {
+	ret := _m.Called(ctx, itemId, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeStock")
+	}
+
+	var r0 entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (entity.Item, error)); ok {
+		return rf(ctx, itemId, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) entity.Item); ok {
+		r0 = rf(ctx, itemId, quantity)
+	} else {
+		r0 = ret.Get(0).(entity.Item)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, itemId, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateItem provides a mock function with given fields: ctx, itemId, req
+func (_m *IShopRepository) UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error) {
+	ret := _m.Called(ctx, itemId, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateItem")
+	}
+
+	var r0 entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, entity.ItemRequest) (entity.Item, error)); ok {
+		return rf(ctx, itemId, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, entity.ItemRequest) entity.Item); ok {
+		r0 = rf(ctx, itemId, req)
+	} else {
+		r0 = ret.Get(0).(entity.Item)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, entity.ItemRequest) error); ok {
+		r1 = rf(ctx, itemId, req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -266,6 +1198,24 @@ func (_m *IShopRepository) TakeRecords(ctx context.Context, userId int) ([]entit
 	return r0, r1
 }
 
+// WithTx provides a mock function with given fields: ctx, fn
+func (_m *IShopRepository) WithTx(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTx")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewIShopRepository creates a new instance of IShopRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIShopRepository(t interface {
@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/k1v4/avito_shop/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // IShopService is an autogenerated mock type for the IShopService type
@@ -14,17 +16,45 @@ type IShopService struct {
 	mock.Mock
 }
 
-// BuyItem provides a mock function with given fields: ctx, userId, itemName
-func (_m *IShopService) BuyItem(ctx context.Context, userId int, itemName string) error {
-	ret := _m.Called(ctx, userId, itemName)
+// AddToCart provides a mock function with given fields: ctx, userId, itemName, quantity
+func (_m *IShopService) AddToCart(ctx context.Context, userId int, itemName string, quantity int) (entity.Cart, error) {
+	ret := _m.Called(ctx, userId, itemName, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddToCart")
+	}
+
+	var r0 entity.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int) (entity.Cart, error)); ok {
+		return rf(ctx, userId, itemName, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int) entity.Cart); ok {
+		r0 = rf(ctx, userId, itemName, quantity)
+	} else {
+		r0 = ret.Get(0).(entity.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, int) error); ok {
+		r1 = rf(ctx, userId, itemName, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BuyItem provides a mock function with given fields: ctx, userId, itemName, quantity
+func (_m *IShopService) BuyItem(ctx context.Context, userId int, itemName string, quantity int) error {
+	ret := _m.Called(ctx, userId, itemName, quantity)
 
 	if len(ret) == 0 {
 		panic("no return value specified for BuyItem")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
-		r0 = rf(ctx, userId, itemName)
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int) error); ok {
+		r0 = rf(ctx, userId, itemName, quantity)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -32,6 +62,108 @@ func (_m *IShopService) BuyItem(ctx context.Context, userId int, itemName string
 	return r0
 }
 
+// Checkout provides a mock function with given fields: ctx, userId
+func (_m *IShopService) Checkout(ctx context.Context, userId int) (entity.Order, error) {
+	ret := _m.Called(ctx, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Checkout")
+	}
+
+	var r0 entity.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entity.Order, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) entity.Order); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Get(0).(entity.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateItem provides a mock function with given fields: ctx, req
+func (_m *IShopService) CreateItem(ctx context.Context, req entity.ItemRequest) (entity.Item, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateItem")
+	}
+
+	var r0 entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.ItemRequest) (entity.Item, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.ItemRequest) entity.Item); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(entity.Item)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.ItemRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteItem provides a mock function with given fields: ctx, itemId
+func (_m *IShopService) DeleteItem(ctx context.Context, itemId int) error {
+	ret := _m.Called(ctx, itemId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteItem")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, itemId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCart provides a mock function with given fields: ctx, userId
+func (_m *IShopService) GetCart(ctx context.Context, userId int) (entity.Cart, error) {
+	ret := _m.Called(ctx, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCart")
+	}
+
+	var r0 entity.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entity.Cart, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) entity.Cart); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Get(0).(entity.Cart)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetInfo provides a mock function with given fields: ctx, userId
 func (_m *IShopService) GetInfo(ctx context.Context, userId int) (entity.ResponseInfo, error) {
 	ret := _m.Called(ctx, userId)
@@ -60,23 +192,258 @@ func (_m *IShopService) GetInfo(ctx context.Context, userId int) (entity.Respons
 	return r0, r1
 }
 
+// GetItem provides a mock function with given fields: ctx, itemId
+func (_m *IShopService) GetItem(ctx context.Context, itemId int) (entity.Item, error) {
+	ret := _m.Called(ctx, itemId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetItem")
+	}
+
+	var r0 entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entity.Item, error)); ok {
+		return rf(ctx, itemId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) entity.Item); ok {
+		r0 = rf(ctx, itemId)
+	} else {
+		r0 = ret.Get(0).(entity.Item)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, itemId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrders provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopService) GetOrders(ctx context.Context, userId int, before int, limit int) (entity.Page[entity.Order], error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrders")
+	}
+
+	var r0 entity.Page[entity.Order]
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (entity.Page[entity.Order], error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) entity.Page[entity.Order]); ok {
+		r0 = rf(ctx, userId, before, limit)
+	} else {
+		r0 = ret.Get(0).(entity.Page[entity.Order])
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchases provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopService) GetPurchases(ctx context.Context, userId int, before int, limit int) (entity.Page[entity.Purchase], error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchases")
+	}
+
+	var r0 entity.Page[entity.Purchase]
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (entity.Page[entity.Purchase], error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) entity.Page[entity.Purchase]); ok {
+		r0 = rf(ctx, userId, before, limit)
+	} else {
+		r0 = ret.Get(0).(entity.Page[entity.Purchase])
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReceivedHistory provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopService) GetReceivedHistory(ctx context.Context, userId int, before int, limit int) (entity.Page[entity.ReceivedItem], error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReceivedHistory")
+	}
+
+	var r0 entity.Page[entity.ReceivedItem]
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (entity.Page[entity.ReceivedItem], error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) entity.Page[entity.ReceivedItem]); ok {
+		r0 = rf(ctx, userId, before, limit)
+	} else {
+		r0 = ret.Get(0).(entity.Page[entity.ReceivedItem])
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSentHistory provides a mock function with given fields: ctx, userId, before, limit
+func (_m *IShopService) GetSentHistory(ctx context.Context, userId int, before int, limit int) (entity.Page[entity.SentItem], error) {
+	ret := _m.Called(ctx, userId, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSentHistory")
+	}
+
+	var r0 entity.Page[entity.SentItem]
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (entity.Page[entity.SentItem], error)); ok {
+		return rf(ctx, userId, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) entity.Page[entity.SentItem]); ok {
+		r0 = rf(ctx, userId, before, limit)
+	} else {
+		r0 = ret.Get(0).(entity.Page[entity.SentItem])
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GrantCoins provides a mock function with given fields: ctx, grantedBy, req
+func (_m *IShopService) GrantCoins(ctx context.Context, grantedBy int, req entity.GrantRequest) ([]entity.Grant, error) {
+	ret := _m.Called(ctx, grantedBy, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GrantCoins")
+	}
+
+	var r0 []entity.Grant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, entity.GrantRequest) ([]entity.Grant, error)); ok {
+		return rf(ctx, grantedBy, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, entity.GrantRequest) []entity.Grant); ok {
+		r0 = rf(ctx, grantedBy, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Grant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, entity.GrantRequest) error); ok {
+		r1 = rf(ctx, grantedBy, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Idempotent provides a mock function with given fields: ctx, userId, key, requestHash, fn
+func (_m *IShopService) Idempotent(ctx context.Context, userId int, key string, requestHash []byte, fn func(context.Context) (entity.IdempotentResponse, error)) (entity.IdempotentResponse, bool, error) {
+	ret := _m.Called(ctx, userId, key, requestHash, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Idempotent")
+	}
+
+	var r0 entity.IdempotentResponse
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, []byte, func(context.Context) (entity.IdempotentResponse, error)) (entity.IdempotentResponse, bool, error)); ok {
+		return rf(ctx, userId, key, requestHash, fn)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, []byte, func(context.Context) (entity.IdempotentResponse, error)) entity.IdempotentResponse); ok {
+		r0 = rf(ctx, userId, key, requestHash, fn)
+	} else {
+		r0 = ret.Get(0).(entity.IdempotentResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, []byte, func(context.Context) (entity.IdempotentResponse, error)) bool); ok {
+		r1 = rf(ctx, userId, key, requestHash, fn)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, string, []byte, func(context.Context) (entity.IdempotentResponse, error)) error); ok {
+		r2 = rf(ctx, userId, key, requestHash, fn)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListItems provides a mock function with given fields: ctx, includeInactive
+func (_m *IShopService) ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error) {
+	ret := _m.Called(ctx, includeInactive)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListItems")
+	}
+
+	var r0 []entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]entity.Item, error)); ok {
+		return rf(ctx, includeInactive)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []entity.Item); ok {
+		r0 = rf(ctx, includeInactive)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Item)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, includeInactive)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Login provides a mock function with given fields: ctx, username, password
-func (_m *IShopService) Login(ctx context.Context, username string, password string) (string, error) {
+func (_m *IShopService) Login(ctx context.Context, username string, password string) (entity.AuthResponse, error) {
 	ret := _m.Called(ctx, username, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 entity.AuthResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (entity.AuthResponse, error)); ok {
 		return rf(ctx, username, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) entity.AuthResponse); ok {
 		r0 = rf(ctx, username, password)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(entity.AuthResponse)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
@@ -88,23 +455,125 @@ func (_m *IShopService) Login(ctx context.Context, username string, password str
 	return r0, r1
 }
 
+// Logout provides a mock function with given fields: ctx, userId, accessToken, refreshToken
+func (_m *IShopService) Logout(ctx context.Context, userId int, accessToken string, refreshToken string) error {
+	ret := _m.Called(ctx, userId, accessToken, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(ctx, userId, accessToken, refreshToken)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reconcile provides a mock function with given fields: ctx
+func (_m *IShopService) Reconcile(ctx context.Context) (entity.Reconciliation, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reconcile")
+	}
+
+	var r0 entity.Reconciliation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (entity.Reconciliation, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) entity.Reconciliation); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(entity.Reconciliation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *IShopService) Refresh(ctx context.Context, refreshToken string) (entity.AuthResponse, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 entity.AuthResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (entity.AuthResponse, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) entity.AuthResponse); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		r0 = ret.Get(0).(entity.AuthResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundPurchase provides a mock function with given fields: ctx, by, purchaseId, req
+func (_m *IShopService) RefundPurchase(ctx context.Context, by entity.Principal, purchaseId int, req entity.RefundRequest) (entity.Refund, error) {
+	ret := _m.Called(ctx, by, purchaseId, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundPurchase")
+	}
+
+	var r0 entity.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Principal, int, entity.RefundRequest) (entity.Refund, error)); ok {
+		return rf(ctx, by, purchaseId, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Principal, int, entity.RefundRequest) entity.Refund); ok {
+		r0 = rf(ctx, by, purchaseId, req)
+	} else {
+		r0 = ret.Get(0).(entity.Refund)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.Principal, int, entity.RefundRequest) error); ok {
+		r1 = rf(ctx, by, purchaseId, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Register provides a mock function with given fields: ctx, username, password
-func (_m *IShopService) Register(ctx context.Context, username string, password string) (string, error) {
+func (_m *IShopService) Register(ctx context.Context, username string, password string) (entity.AuthResponse, error) {
 	ret := _m.Called(ctx, username, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Register")
 	}
 
-	var r0 string
+	var r0 entity.AuthResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (entity.AuthResponse, error)); ok {
 		return rf(ctx, username, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) entity.AuthResponse); ok {
 		r0 = rf(ctx, username, password)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(entity.AuthResponse)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
@@ -116,6 +585,52 @@ func (_m *IShopService) Register(ctx context.Context, username string, password
 	return r0, r1
 }
 
+// RemoveFromCart provides a mock function with given fields: ctx, userId, itemName
+func (_m *IShopService) RemoveFromCart(ctx context.Context, userId int, itemName string) error {
+	ret := _m.Called(ctx, userId, itemName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFromCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userId, itemName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunAllowance provides a mock function with given fields: ctx, allowance, now
+func (_m *IShopService) RunAllowance(ctx context.Context, allowance entity.Allowance, now time.Time) (int, error) {
+	ret := _m.Called(ctx, allowance, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RunAllowance")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Allowance, time.Time) (int, error)); ok {
+		return rf(ctx, allowance, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Allowance, time.Time) int); ok {
+		r0 = rf(ctx, allowance, now)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.Allowance, time.Time) error); ok {
+		r1 = rf(ctx, allowance, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SendCoins provides a mock function with given fields: ctx, toUserName, fromUserId, amount
 func (_m *IShopService) SendCoins(ctx context.Context, toUserName string, fromUserId int, amount int) error {
 	ret := _m.Called(ctx, toUserName, fromUserId, amount)
@@ -134,6 +649,52 @@ func (_m *IShopService) SendCoins(ctx context.Context, toUserName string, fromUs
 	return r0
 }
 
+// SetRole provides a mock function with given fields: ctx, username, role
+func (_m *IShopService) SetRole(ctx context.Context, username string, role string) error {
+	ret := _m.Called(ctx, username, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateItem provides a mock function with given fields: ctx, itemId, req
+func (_m *IShopService) UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error) {
+	ret := _m.Called(ctx, itemId, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateItem")
+	}
+
+	var r0 entity.Item
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, entity.ItemRequest) (entity.Item, error)); ok {
+		return rf(ctx, itemId, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, entity.ItemRequest) entity.Item); ok {
+		r0 = rf(ctx, itemId, req)
+	} else {
+		r0 = ret.Get(0).(entity.Item)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, entity.ItemRequest) error); ok {
+		r1 = rf(ctx, itemId, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewIShopService creates a new instance of IShopService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewIShopService(t interface {
@@ -0,0 +1,56 @@
+package usecase
+
+import "time"
+
+const (
+	defaultCacheNamespace = "avito_shop"
+	defaultInfoTTL        = time.Minute
+	defaultTokenTTL       = time.Hour
+	defaultRefreshTTL     = 30 * 24 * time.Hour
+	defaultIdempotencyTTL = 24 * time.Hour
+	defaultRefundWindow   = 24 * time.Hour
+)
+
+type Option func(*ShopUseCase)
+
+// CacheNamespace задаёт префикс ключей кэша
+func CacheNamespace(namespace string) Option {
+	return func(uc *ShopUseCase) {
+		uc.cacheNamespace = namespace
+	}
+}
+
+// InfoTTL задаёт время жизни закэшированного ответа GetInfo
+func InfoTTL(ttl time.Duration) Option {
+	return func(uc *ShopUseCase) {
+		uc.infoTTL = ttl
+	}
+}
+
+// TokenTTL задаёт время жизни выдаваемых токенов
+func TokenTTL(ttl time.Duration) Option {
+	return func(uc *ShopUseCase) {
+		uc.tokenTTL = ttl
+	}
+}
+
+// RefreshTTL задаёт время жизни refresh-токенов
+func RefreshTTL(ttl time.Duration) Option {
+	return func(uc *ShopUseCase) {
+		uc.refreshTTL = ttl
+	}
+}
+
+// IdempotencyTTL задаёт, сколько хранится ответ на запрос с Idempotency-Key
+func IdempotencyTTL(ttl time.Duration) Option {
+	return func(uc *ShopUseCase) {
+		uc.idempotencyTTL = ttl
+	}
+}
+
+// RefundWindow задаёт, сколько после покупки пользователь может сам её вернуть, 0 - только через администратора
+func RefundWindow(window time.Duration) Option {
+	return func(uc *ShopUseCase) {
+		uc.refundWindow = window
+	}
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxRefundReasonLength = 500
+
+// RefundPurchase возвращает монеты за quantity штук покупки, убирает их из инвентаря и возвращает на склад.
+// Строка покупки блокируется, поэтому параллельные возвраты одной покупки идут по очереди и вместе
+// не вернут больше, чем куплено
+func (uc *ShopUseCase) RefundPurchase(ctx context.Context, by entity.Principal, purchaseId int, req entity.RefundRequest) (entity.Refund, error) {
+	const op = "ShopUseCase.RefundPurchase"
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || utf8.RuneCountInString(req.Reason) > maxRefundReasonLength {
+		return entity.Refund{}, ErrInvalidRefund.WithMessage(fmt.Sprintf("reason must be 1 to %d characters", maxRefundReasonLength))
+	}
+
+	if req.Quantity < 0 {
+		return entity.Refund{}, ErrInvalidQuantity.WithMessage("quantity must not be negative")
+	}
+
+	var refund entity.Refund
+	err := uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		purchase, err := uc.repo.GetPurchaseForUpdate(ctx, purchaseId)
+		if err != nil {
+			return err
+		}
+
+		if !by.Can(entity.PermRefundPurchases) {
+			// о чужих покупках не сообщаем, что они существуют
+			if purchase.UserId != by.Id {
+				return ErrNoPurchase
+			}
+
+			if uc.refundWindow <= 0 || time.Since(purchase.CreatedAt) > uc.refundWindow {
+				return ErrRefundWindowExpired
+			}
+		}
+
+		left := purchase.Quantity - purchase.Refunded
+		if left == 0 {
+			return ErrAlreadyRefunded
+		}
+
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = left
+		}
+
+		if quantity > left {
+			return ErrInvalidQuantity.WithMessage(fmt.Sprintf("only %d left to refund", left))
+		}
+
+		amount := purchase.Price * quantity
+
+		err = uc.repo.MarkRefunded(ctx, purchase.Id, quantity)
+		if err != nil {
+			return err
+		}
+
+		// товар, баланс и инвентарь меняются в том же порядке, что и при покупке, чтобы возврат
+		// и параллельная покупка того же товара не заблокировали друг друга
+		err = uc.repo.ReturnStock(ctx, purchase.ItemId, quantity)
+		if err != nil {
+			return err
+		}
+
+		err = uc.repo.TakeGiveCoins(ctx, purchase.UserId, amount)
+		if err != nil {
+			return err
+		}
+
+		err = uc.repo.TakeInventory(ctx, purchase.UserId, purchase.ItemId, quantity)
+		if err != nil {
+			return err
+		}
+
+		refund, err = uc.repo.SaveRefund(ctx, entity.Refund{
+			PurchaseId: purchase.Id,
+			UserId:     purchase.UserId,
+			Type:       purchase.Type,
+			Quantity:   quantity,
+			Amount:     amount,
+			Reason:     req.Reason,
+			RefundedBy: by.Id,
+		})
+		if err != nil {
+			return err
+		}
+
+		return uc.post(ctx, entity.JournalEntry{
+			Kind:        entity.EntryRefund,
+			ReferenceId: refund.Id,
+			Description: req.Reason,
+			Postings: []entity.Posting{
+				{Account: entity.RevenueAccount, Amount: -amount},
+				{Account: entity.WalletAccount(purchase.UserId), Amount: amount},
+			},
+		})
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoPurchase) || errors.Is(err, ErrRefundWindowExpired) ||
+			errors.Is(err, ErrAlreadyRefunded) || errors.Is(err, ErrInvalidQuantity) || errors.Is(err, ErrNotInInventory) {
+			return entity.Refund{}, err
+		}
+
+		return entity.Refund{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	uc.invalidateInfo(ctx, refund.UserId)
+
+	return refund, nil
+}
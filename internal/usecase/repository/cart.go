@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+)
+
+func (s *ShopRepository) GetCart(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	const op = "ShopRepository.GetCart"
+
+	cart, err := s.queryCart(ctx, s.cartQuery(userId))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cart, nil
+}
+
+// GetCartForUpdate возвращает корзину и блокирует её строки до конца транзакции
+func (s *ShopRepository) GetCartForUpdate(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	const op = "ShopRepository.GetCartForUpdate"
+
+	cart, err := s.queryCart(ctx, s.cartQuery(userId).Suffix("FOR UPDATE OF ci"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cart, nil
+}
+
+// AddToCart добавляет quantity штук товара в корзину и возвращает, сколько их там стало
+func (s *ShopRepository) AddToCart(ctx context.Context, userId, itemId, quantity int) (int, error) {
+	const op = "ShopRepository.AddToCart"
+
+	sq, args, err := s.Builder.Insert("cart_items").
+		Columns("user_id", "item_id", "quantity").
+		Values(userId, itemId, quantity).
+		Suffix("ON CONFLICT (user_id, item_id) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity RETURNING quantity").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var total int
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return total, nil
+}
+
+// RemoveFromCart убирает товар из корзины по имени, в том числе уже удалённый из каталога
+func (s *ShopRepository) RemoveFromCart(ctx context.Context, userId int, itemName string) error {
+	const op = "ShopRepository.RemoveFromCart"
+
+	sq, args, err := s.Builder.Delete("cart_items ci").
+		Suffix("USING items i WHERE i.id = ci.item_id AND ci.user_id = ? AND i.name = ?", userId, itemName).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNoItem.WithMessage("item is not in the cart")
+	}
+
+	return nil
+}
+
+func (s *ShopRepository) ClearCart(ctx context.Context, userId int) error {
+	const op = "ShopRepository.ClearCart"
+
+	sq, args, err := s.Builder.Delete("cart_items").
+		Where(squirrel.Eq{"user_id": userId}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// cartQuery строки корзины в порядке возрастания id товара, в этом же порядке checkout блокирует товары
+func (s *ShopRepository) cartQuery(userId int) squirrel.SelectBuilder {
+	return s.Builder.
+		Select("ci.item_id", "i.name", "i.price", "ci.quantity", "i.active AND i.deleted_at IS NULL", "i.per_user_limit").
+		From("cart_items ci").
+		Join("items i ON i.id = ci.item_id").
+		Where(squirrel.Eq{"ci.user_id": userId}).
+		OrderBy("ci.item_id")
+}
+
+func (s *ShopRepository) queryCart(ctx context.Context, q squirrel.SelectBuilder) ([]entity.CartItem, error) {
+	sq, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCart(rows)
+}
+
+// scanCart читает строки cartQuery и закрывает rows
+func scanCart(rows pgx.Rows) ([]entity.CartItem, error) {
+	defer rows.Close()
+
+	cart := make([]entity.CartItem, 0, defaultEntityCap)
+	for rows.Next() {
+		var item entity.CartItem
+
+		err := rows.Scan(&item.ItemId, &item.Type, &item.Price, &item.Quantity, &item.Available, &item.PerUserLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		cart = append(cart, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
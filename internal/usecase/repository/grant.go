@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/k1v4/avito_shop/internal/entity"
+)
+
+// SaveGrant записывает начисление в журнал начислений. Баланс меняется отдельно, через TakeGiveCoins
+func (s *ShopRepository) SaveGrant(ctx context.Context, grant entity.Grant) (entity.Grant, error) {
+	const op = "ShopRepository.SaveGrant"
+
+	var grantedBy *int
+	if grant.GrantedBy != 0 {
+		grantedBy = &grant.GrantedBy
+	}
+
+	sq, args, err := s.Builder.
+		Insert("coin_grants").
+		Columns("user_id", "amount", "reason", "granted_by").
+		Values(grant.UserId, grant.Amount, grant.Reason, grantedBy).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return entity.Grant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&grant.Id, &grant.CreatedAt)
+	if err != nil {
+		return entity.Grant{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return grant, nil
+}
+
+// ClaimAllowanceRun отмечает период регулярного начисления выполненным. false - период уже отмечен.
+// Параллельная транзакция с тем же периодом ждёт на первичном ключе, пока первая не завершится
+func (s *ShopRepository) ClaimAllowanceRun(ctx context.Context, period string) (bool, error) {
+	const op = "ShopRepository.ClaimAllowanceRun"
+
+	sq, args, err := s.Builder.
+		Insert("allowance_runs").
+		Columns("period").
+		Values(period).
+		Suffix("ON CONFLICT (period) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// GrantAll начисляет amount монет всем пользователям одним запросом и записывает начисления в журнал.
+// Возвращает id пользователей, которым начислено. Строки пользователей сначала блокируются в порядке id,
+// как в SendCoins, иначе UPDATE без порядка взаимоблокируется с параллельными переводами.
+// Имеет смысл только внутри WithTx
+func (s *ShopRepository) GrantAll(ctx context.Context, amount int, reason string) ([]int, error) {
+	const op = "ShopRepository.GrantAll"
+
+	sq, args, err := s.Builder.
+		Select("id").
+		From("users").
+		OrderBy("id").
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// вложенный select собирается без $-плейсхолдеров, их пронумерует внешний запрос
+	sq, args, err = s.Builder.
+		Insert("coin_grants").
+		Prefix("WITH credited AS (UPDATE users SET amount = amount + ? RETURNING id)", amount).
+		Columns("user_id", "amount", "reason").
+		Select(squirrel.Select("id").
+			Column(squirrel.Expr("?::int", amount)).
+			Column(squirrel.Expr("?::text", reason)).
+			From("credited")).
+		Suffix("RETURNING user_id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	userIds := make([]int, 0, defaultEntityCap)
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		userIds = append(userIds, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userIds, nil
+}
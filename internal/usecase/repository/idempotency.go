@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"time"
+)
+
+// ClaimIdempotencyKey занимает ключ за текущей транзакцией. Если ключ уже занят незавершённой транзакцией,
+// вставка ждёт её окончания. Возвращает false, если ключ занят закоммиченным запросом.
+// Просроченная запись с тем же ключом удаляется, и ключ можно использовать заново
+func (s *ShopRepository) ClaimIdempotencyKey(ctx context.Context, userId int, key string, requestHash []byte, ttl time.Duration) (bool, error) {
+	const op = "ShopRepository.ClaimIdempotencyKey"
+
+	sql, args, err := s.Builder.Delete("idempotency_keys").
+		Where(squirrel.Eq{"user_id": userId, "idempotency_key": key}).
+		Where(squirrel.Lt{"created_at": time.Now().Add(-ttl)}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sql, args, err = s.Builder.Insert("idempotency_keys").
+		Columns("user_id", "idempotency_key", "request_hash").
+		Values(userId, key, requestHash).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *ShopRepository) GetIdempotentResponse(ctx context.Context, userId int, key string) (entity.IdempotentResponse, error) {
+	const op = "ShopRepository.GetIdempotentResponse"
+
+	sq, args, err := s.Builder.
+		Select("request_hash", "COALESCE(status_code, 0)", "COALESCE(response_body, ''::bytea)").
+		From("idempotency_keys").
+		Where(squirrel.Eq{"user_id": userId, "idempotency_key": key}).
+		ToSql()
+	if err != nil {
+		return entity.IdempotentResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var res entity.IdempotentResponse
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&res.RequestHash, &res.StatusCode, &res.Body)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.IdempotentResponse{}, usecase.ErrNoIdempotencyKey
+		}
+
+		return entity.IdempotentResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (s *ShopRepository) SaveIdempotentResponse(ctx context.Context, userId int, key string, res entity.IdempotentResponse) error {
+	const op = "ShopRepository.SaveIdempotentResponse"
+
+	sql, args, err := s.Builder.Update("idempotency_keys").
+		Set("status_code", res.StatusCode).
+		Set("response_body", res.Body).
+		Where(squirrel.Eq{"user_id": userId, "idempotency_key": key}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
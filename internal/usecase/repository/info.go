@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+)
+
+// TakeInfo собирает баланс, инвентарь и до pageLimit последних переводов в каждую сторону, покупок и заказов
+// одним batch-запросом, то есть за один поход в базу независимо от объёма данных пользователя
+func (s *ShopRepository) TakeInfo(ctx context.Context, userId, pageLimit int) (entity.ResponseInfo, error) {
+	const op = "ShopRepository.TakeInfo"
+
+	queries := []squirrel.Sqlizer{
+		s.Builder.Select("amount").From("users").Where(squirrel.Eq{"id": userId}),
+		s.inventoryQuery(userId),
+		s.sentQuery(userId, 0, pageLimit),
+		s.receivedQuery(userId, 0, pageLimit),
+		s.purchasesQuery(userId, 0, pageLimit),
+		s.ordersQuery(userId, 0, pageLimit),
+	}
+
+	batch := &pgx.Batch{}
+	for _, q := range queries {
+		sq, args, err := q.ToSql()
+		if err != nil {
+			return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		batch.Queue(sq, args...)
+	}
+
+	br := s.conn(ctx).SendBatch(ctx, batch)
+	defer br.Close()
+
+	var res entity.ResponseInfo
+
+	err := br.QueryRow().Scan(&res.Coins)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ResponseInfo{}, usecase.ErrNoUser
+		}
+
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := br.Query()
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res.Inventory, err = scanInventory(rows)
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = br.Query()
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res.CoinHistory.Sent.SentItems, err = scanSent(rows, pageLimit)
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = br.Query()
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res.CoinHistory.Received.ReceivedItems, err = scanReceived(rows, pageLimit)
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = br.Query()
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res.Purchases.Items, err = scanPurchases(rows, pageLimit)
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = br.Query()
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res.Orders.Items, err = scanOrders(rows, pageLimit)
+	if err != nil {
+		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (s *ShopRepository) inventoryQuery(userId int) squirrel.SelectBuilder {
+	return s.Builder.Select("inv.item_id", "i.name", "inv.quantity").
+		From("inventory inv").
+		Join("items i ON i.id = inv.item_id").
+		Where(squirrel.Eq{"inv.user_id": userId}).
+		OrderBy("inv.item_id")
+}
+
+// scanInventory читает строки inventoryQuery и закрывает rows
+func scanInventory(rows pgx.Rows) (entity.Inventory, error) {
+	defer rows.Close()
+
+	var items []entity.InventoryItem
+	for rows.Next() {
+		var item entity.InventoryItem
+
+		err := rows.Scan(&item.ItemId, &item.Type, &item.Quantity)
+		if err != nil {
+			return entity.Inventory{}, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return entity.Inventory{}, err
+	}
+
+	return entity.Inventory{Items: items}, nil
+}
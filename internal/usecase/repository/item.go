@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"strings"
+)
+
+// uniqueViolationCode код ошибки postgres при нарушении уникального индекса
+const uniqueViolationCode = "23505"
+
+var itemColumns = []string{"id", "name", "price", "description", "image_url", "active", "stock", "per_user_limit", "created_at", "updated_at"}
+
+// notDeleted условие, которое отсекает удалённые товары
+var notDeleted = squirrel.Eq{"deleted_at": nil}
+
+func scanItem(row pgx.Row) (entity.Item, error) {
+	var item entity.Item
+	err := row.Scan(
+		&item.Id,
+		&item.Name,
+		&item.Price,
+		&item.Description,
+		&item.ImageURL,
+		&item.Active,
+		&item.Stock,
+		&item.PerUserLimit,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+
+	return item, err
+}
+
+// itemError переводит ошибки запросов к items в ошибки usecase
+func itemError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return usecase.ErrNoItem
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return usecase.ErrItemExists
+	}
+
+	return nil
+}
+
+func (s *ShopRepository) ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error) {
+	const op = "ShopRepository.ListItems"
+
+	q := s.Builder.Select(itemColumns...).
+		From("items").
+		Where(notDeleted).
+		OrderBy("id")
+	if !includeInactive {
+		q = q.Where(squirrel.Eq{"active": true})
+	}
+
+	sq, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	items := make([]entity.Item, 0, defaultEntityCap)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+func (s *ShopRepository) GetItem(ctx context.Context, itemId int) (entity.Item, error) {
+	const op = "ShopRepository.GetItem"
+
+	sq, args, err := s.Builder.Select(itemColumns...).
+		From("items").
+		Where(squirrel.Eq{"id": itemId}).
+		Where(notDeleted).
+		ToSql()
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	item, err := scanItem(s.conn(ctx).QueryRow(ctx, sq, args...))
+	if err != nil {
+		if itemErr := itemError(err); itemErr != nil {
+			return entity.Item{}, itemErr
+		}
+
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return item, nil
+}
+
+func (s *ShopRepository) CreateItem(ctx context.Context, item entity.Item) (entity.Item, error) {
+	const op = "ShopRepository.CreateItem"
+
+	sq, args, err := s.Builder.Insert("items").
+		Columns("name", "price", "description", "image_url", "active", "stock", "per_user_limit").
+		Values(item.Name, item.Price, item.Description, item.ImageURL, item.Active, item.Stock, item.PerUserLimit).
+		Suffix("RETURNING " + joinColumns(itemColumns)).
+		ToSql()
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanItem(s.conn(ctx).QueryRow(ctx, sq, args...))
+	if err != nil {
+		if itemErr := itemError(err); itemErr != nil {
+			return entity.Item{}, itemErr
+		}
+
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// UpdateItem меняет только заданные в req поля одним UPDATE
+func (s *ShopRepository) UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error) {
+	const op = "ShopRepository.UpdateItem"
+
+	q := s.Builder.Update("items").
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": itemId}).
+		Where(notDeleted).
+		Suffix("RETURNING " + joinColumns(itemColumns))
+
+	if req.Name != nil {
+		q = q.Set("name", *req.Name)
+	}
+	if req.Price != nil {
+		q = q.Set("price", *req.Price)
+	}
+	if req.Description != nil {
+		q = q.Set("description", *req.Description)
+	}
+	if req.ImageURL != nil {
+		q = q.Set("image_url", *req.ImageURL)
+	}
+	if req.Active != nil {
+		q = q.Set("active", *req.Active)
+	}
+	if req.Stock != nil {
+		q = q.Set("stock", limit(*req.Stock))
+	}
+	if req.PerUserLimit != nil {
+		q = q.Set("per_user_limit", limit(*req.PerUserLimit))
+	}
+
+	sq, args, err := q.ToSql()
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := scanItem(s.conn(ctx).QueryRow(ctx, sq, args...))
+	if err != nil {
+		if itemErr := itemError(err); itemErr != nil {
+			return entity.Item{}, itemErr
+		}
+
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteItem помечает товар удалённым, покупки и инвентарь продолжают на него ссылаться
+func (s *ShopRepository) DeleteItem(ctx context.Context, itemId int) error {
+	const op = "ShopRepository.DeleteItem"
+
+	sq, args, err := s.Builder.Update("items").
+		Set("deleted_at", squirrel.Expr("now()")).
+		Set("updated_at", squirrel.Expr("now()")).
+		Set("active", false).
+		Where(squirrel.Eq{"id": itemId}).
+		Where(notDeleted).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNoItem
+	}
+
+	return nil
+}
+
+// TakeStock списывает quantity со склада, если товар продаётся и его хватает, и возвращает строку товара
+// после списания. Строка остаётся заблокированной до конца транзакции, в том числе у товаров без остатка,
+// так что покупки одного товара идут по очереди, а цена и лимит берутся из заблокированной строки
+func (s *ShopRepository) TakeStock(ctx context.Context, itemId, quantity int) (entity.Item, error) {
+	const op = "ShopRepository.TakeStock"
+
+	sq, args, err := s.Builder.Update("items").
+		Set("stock", squirrel.Expr("stock - ?", quantity)).
+		Where(squirrel.Eq{"id": itemId, "active": true}).
+		Where(notDeleted).
+		Where(squirrel.Or{
+			squirrel.Eq{"stock": nil},
+			squirrel.GtOrEq{"stock": quantity},
+		}).
+		Suffix("RETURNING " + joinColumns(itemColumns)).
+		ToSql()
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	item, err := scanItem(s.conn(ctx).QueryRow(ctx, sq, args...))
+	if err == nil {
+		return item, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// строка не подошла под условие, выясняем почему
+	sq, args, err = s.Builder.Select("active").
+		From("items").
+		Where(squirrel.Eq{"id": itemId}).
+		Where(notDeleted).
+		ToSql()
+	if err != nil {
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var active bool
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&active)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Item{}, usecase.ErrNoItem
+		}
+
+		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !active {
+		return entity.Item{}, usecase.ErrItemInactive
+	}
+
+	return entity.Item{}, usecase.ErrOutOfStock
+}
+
+// PurchasedQuantity сколько штук товара пользователь уже купил, возвращённые не считаются
+func (s *ShopRepository) PurchasedQuantity(ctx context.Context, userId, itemId int) (int, error) {
+	const op = "ShopRepository.PurchasedQuantity"
+
+	sq, args, err := s.Builder.Select("COALESCE(SUM(quantity - refunded_quantity), 0)").
+		From("purchases").
+		Where(squirrel.Eq{"user_id": userId, "item_id": itemId}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var quantity int
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&quantity)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return quantity, nil
+}
+
+// ReturnStock возвращает quantity на склад. У товаров без остатка ничего не меняется
+func (s *ShopRepository) ReturnStock(ctx context.Context, itemId, quantity int) error {
+	const op = "ShopRepository.ReturnStock"
+
+	sq, args, err := s.Builder.Update("items").
+		Set("stock", squirrel.Expr("stock + ?", quantity)).
+		Where(squirrel.Eq{"id": itemId}).
+		Where(squirrel.NotEq{"stock": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// limit значение ограничения для колонки: Unlimited хранится как NULL
+func limit(v int) *int {
+	if v == entity.Unlimited {
+		return nil
+	}
+
+	return &v
+}
+
+func joinColumns(columns []string) string {
+	return strings.Join(columns, ", ")
+}
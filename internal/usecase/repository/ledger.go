@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/k1v4/avito_shop/internal/entity"
+)
+
+func (s *ShopRepository) PostEntry(ctx context.Context, entry entity.JournalEntry) (int, error) {
+	const op = "ShopRepository.PostEntry"
+
+	accounts, err := s.ledgerAccounts(ctx, entry.Postings)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var referenceId *int
+	if entry.ReferenceId != 0 {
+		referenceId = &entry.ReferenceId
+	}
+
+	sq, args, err := s.Builder.
+		Insert("journal_entries").
+		Columns("kind", "reference_id", "description").
+		Values(entry.Kind, referenceId, entry.Description).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var entryId int
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&entryId)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accountIds := make([]int, 0, len(entry.Postings))
+	amounts := make([]int, 0, len(entry.Postings))
+	for _, p := range entry.Postings {
+		accountIds = append(accountIds, accounts[p.Account])
+		amounts = append(amounts, p.Amount)
+	}
+
+	// проводки передаются массивами, так что число параметров не растёт с числом проводок:
+	// в начислении всем пользователям их столько же, сколько пользователей
+	sq, args, err = s.Builder.
+		Insert("postings").
+		Columns("entry_id", "account_id", "amount").
+		Select(squirrel.Select().
+			Column(squirrel.Expr("?::int", entryId)).
+			Column(squirrel.Expr("unnest(?::int[])", accountIds)).
+			Column(squirrel.Expr("unnest(?::int[])", amounts))).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entryId, nil
+}
+
+// ledgerAccounts возвращает id счетов, по которым идут postings. Кошельков, которых ещё нет, создаёт
+func (s *ShopRepository) ledgerAccounts(ctx context.Context, postings []entity.Posting) (map[entity.Account]int, error) {
+	userIds := make([]int, 0, len(postings))
+	for _, p := range postings {
+		if p.Account.Kind == entity.AccountWallet {
+			userIds = append(userIds, p.Account.UserId)
+		}
+	}
+
+	if len(userIds) > 0 {
+		// вложенный select собирается без $-плейсхолдеров, их пронумерует внешний запрос
+		sq, args, err := s.Builder.
+			Insert("ledger_accounts").
+			Columns("kind", "user_id").
+			Select(squirrel.Select().
+				Column(squirrel.Expr("?", entity.AccountWallet)).
+				Column(squirrel.Expr("unnest(?::int[])", userIds))).
+			Suffix("ON CONFLICT DO NOTHING").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = s.conn(ctx).Exec(ctx, sq, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	sq, args, err := s.Builder.
+		Select("id", "kind", "user_id").
+		From("ledger_accounts").
+		Where(squirrel.Or{
+			squirrel.Eq{"user_id": nil},
+			squirrel.Expr("user_id = ANY(?::int[])", userIds),
+		}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make(map[entity.Account]int, len(postings))
+	for rows.Next() {
+		var (
+			id      int
+			account entity.Account
+			userId  *int
+		)
+		if err = rows.Scan(&id, &account.Kind, &userId); err != nil {
+			return nil, err
+		}
+
+		if userId != nil {
+			account.UserId = *userId
+		}
+
+		accounts[account] = id
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range postings {
+		if _, ok := accounts[p.Account]; !ok {
+			return nil, fmt.Errorf("ledger account %s %d not found", p.Account.Kind, p.Account.UserId)
+		}
+	}
+
+	return accounts, nil
+}
+
+func (s *ShopRepository) Reconcile(ctx context.Context) (entity.Reconciliation, error) {
+	const op = "ShopRepository.Reconcile"
+
+	var res entity.Reconciliation
+
+	sq, args, err := s.Builder.Select("count(*)").From("users").ToSql()
+	if err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&res.Users); err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sq, args, err = s.Builder.
+		Select("u.id", "u.username", "u.amount", "COALESCE(SUM(p.amount), 0)").
+		From("users u").
+		LeftJoin("ledger_accounts a ON a.user_id = u.id").
+		LeftJoin("postings p ON p.account_id = a.id").
+		GroupBy("u.id").
+		Having("u.amount <> COALESCE(SUM(p.amount), 0)").
+		OrderBy("u.id").
+		ToSql()
+	if err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for rows.Next() {
+		var d entity.BalanceDrift
+		if err = rows.Scan(&d.UserId, &d.Username, &d.Balance, &d.Ledger); err != nil {
+			rows.Close()
+
+			return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		res.Drifts = append(res.Drifts, d)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sq, args, err = s.Builder.
+		Select("entry_id").
+		From("postings").
+		GroupBy("entry_id").
+		Having("SUM(amount) <> 0").
+		OrderBy("entry_id").
+		ToSql()
+	if err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		res.UnbalancedEntries = append(res.UnbalancedEntries, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return entity.Reconciliation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/internal/entity"
+)
+
+func (s *ShopRepository) CreateOrder(ctx context.Context, userId, total int) (entity.Order, error) {
+	const op = "ShopRepository.CreateOrder"
+
+	sq, args, err := s.Builder.Insert("orders").
+		Columns("user_id", "total").
+		Values(userId, total).
+		Suffix("RETURNING id, total, created_at").
+		ToSql()
+	if err != nil {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var order entity.Order
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&order.Id, &order.Total, &order.CreatedAt)
+	if err != nil {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return order, nil
+}
+
+// SaveOrderLines записывает строки заказа покупками одним INSERT
+func (s *ShopRepository) SaveOrderLines(ctx context.Context, userId, orderId int, lines []entity.OrderLine) error {
+	const op = "ShopRepository.SaveOrderLines"
+
+	builder := s.Builder.Insert("purchases").Columns("user_id", "item_id", "price", "quantity", "order_id")
+	for _, l := range lines {
+		builder = builder.Values(userId, l.ItemId, l.Price, l.Quantity, orderId)
+	}
+
+	sq, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeOrders возвращает до limit заказов пользователя с id < before вместе со строками, от новых к старым
+func (s *ShopRepository) TakeOrders(ctx context.Context, userId, before, limit int) ([]entity.Order, error) {
+	const op = "ShopRepository.TakeOrders"
+
+	sq, args, err := s.ordersQuery(userId, before, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	orders, err := scanOrders(rows, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+// ordersQuery строки заказов, limit накладывается на сами заказы, а не на строки
+func (s *ShopRepository) ordersQuery(userId, before, limit int) squirrel.SelectBuilder {
+	// вложенный select собирается без $-плейсхолдеров, их пронумерует внешний запрос
+	ids := squirrel.Select("id").
+		From("orders").
+		Where(squirrel.Eq{"user_id": userId}).
+		OrderBy("id DESC").
+		Limit(uint64(limit))
+
+	if before > 0 {
+		ids = ids.Where(squirrel.Lt{"id": before})
+	}
+
+	return s.Builder.Select("o.id", "o.total", "o.created_at", "p.item_id", "i.name", "p.price", "p.quantity").
+		From("orders o").
+		Join("purchases p ON p.order_id = o.id").
+		Join("items i ON i.id = p.item_id").
+		Where(squirrel.Expr("o.id IN (?)", ids)).
+		OrderBy("o.id DESC", "p.id")
+}
+
+// scanOrders собирает заказы из строк ordersQuery, строки одного заказа идут подряд. Закрывает rows
+func scanOrders(rows pgx.Rows, limit int) ([]entity.Order, error) {
+	defer rows.Close()
+
+	orders := make([]entity.Order, 0, limit)
+	for rows.Next() {
+		var (
+			order entity.Order
+			line  entity.OrderLine
+		)
+
+		err := rows.Scan(&order.Id, &order.Total, &order.CreatedAt, &line.ItemId, &line.Type, &line.Price, &line.Quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(orders); n == 0 || orders[n-1].Id != order.Id {
+			orders = append(orders, order)
+		}
+
+		last := &orders[len(orders)-1]
+		last.Lines = append(last.Lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+)
+
+// GetPurchaseForUpdate возвращает покупку и блокирует её строку до конца транзакции
+func (s *ShopRepository) GetPurchaseForUpdate(ctx context.Context, purchaseId int) (entity.Purchase, error) {
+	const op = "ShopRepository.GetPurchaseForUpdate"
+
+	sq, args, err := s.Builder.
+		Select("p.id", "p.user_id", "p.item_id", "i.name", "p.price", "p.quantity", "p.created_at", "COALESCE(p.order_id, 0)", "p.refunded_quantity").
+		From("purchases p").
+		Join("items i ON i.id = p.item_id").
+		Where(squirrel.Eq{"p.id": purchaseId}).
+		Suffix("FOR UPDATE OF p").
+		ToSql()
+	if err != nil {
+		return entity.Purchase{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var p entity.Purchase
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).
+		Scan(&p.Id, &p.UserId, &p.ItemId, &p.Type, &p.Price, &p.Quantity, &p.CreatedAt, &p.OrderId, &p.Refunded)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Purchase{}, usecase.ErrNoPurchase
+		}
+
+		return entity.Purchase{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// MarkRefunded увеличивает возвращённое количество покупки, если оно не превысит купленное.
+// Проверка и изменение идут одним UPDATE, так что одну штуку нельзя вернуть дважды
+func (s *ShopRepository) MarkRefunded(ctx context.Context, purchaseId, quantity int) error {
+	const op = "ShopRepository.MarkRefunded"
+
+	sq, args, err := s.Builder.Update("purchases").
+		Set("refunded_quantity", squirrel.Expr("refunded_quantity + ?", quantity)).
+		Where(squirrel.Eq{"id": purchaseId}).
+		Where(squirrel.Expr("refunded_quantity + ? <= quantity", quantity)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrAlreadyRefunded
+	}
+
+	return nil
+}
+
+// TakeInventory убирает quantity штук товара из инвентаря пользователя
+func (s *ShopRepository) TakeInventory(ctx context.Context, userId, itemId, quantity int) error {
+	const op = "ShopRepository.TakeInventory"
+
+	sq, args, err := s.Builder.Update("inventory").
+		Set("quantity", squirrel.Expr("quantity - ?", quantity)).
+		Where(squirrel.Eq{"user_id": userId, "item_id": itemId}).
+		Where(squirrel.GtOrEq{"quantity": quantity}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNotInInventory
+	}
+
+	return nil
+}
+
+func (s *ShopRepository) SaveRefund(ctx context.Context, refund entity.Refund) (entity.Refund, error) {
+	const op = "ShopRepository.SaveRefund"
+
+	sq, args, err := s.Builder.
+		Insert("refunds").
+		Columns("purchase_id", "quantity", "amount", "reason", "refunded_by").
+		Values(refund.PurchaseId, refund.Quantity, refund.Amount, refund.Reason, refund.RefundedBy).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return entity.Refund{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&refund.Id, &refund.CreatedAt)
+	if err != nil {
+		return entity.Refund{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refund, nil
+}
@@ -13,6 +13,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLinksRepository(t *testing.T) {
@@ -72,8 +73,20 @@ func TestLinksRepository(t *testing.T) {
 		Username: username,
 		Passhash: passHash,
 		Coins:    1000,
+		Role:     entity.RoleEmployee,
 	}, userFind)
 
+	// SetUserRole
+	err = linksRepository.SetUserRole(ctx, username, entity.RoleHR)
+	assert.NoError(t, err)
+
+	userFind, err = linksRepository.FindUser(ctx, username)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleHR, userFind.Role)
+
+	err = linksRepository.SetUserRole(ctx, "1", entity.RoleHR)
+	assert.ErrorIs(t, err, usecase.ErrNoUser)
+
 	userFindErr, err := linksRepository.FindUser(ctx, "1")
 	assert.Error(t, err)
 	assert.Equal(t, entity.User{}, userFindErr)
@@ -85,20 +98,6 @@ func TestLinksRepository(t *testing.T) {
 	err = linksRepository.BuyItem(ctx, -1, 1, 1)
 	assert.Error(t, err)
 
-	// GetItemUser
-	userItems, err := linksRepository.GetItemUser(ctx, userSave)
-	assert.NoError(t, err)
-	assert.Equal(t, entity.Inventory{Items: []entity.InventoryItem{
-		{
-			ItemId:   1,
-			Type:     "",
-			Quantity: 1,
-		},
-	}}, userItems)
-
-	userItemsErr, err := linksRepository.GetItemUser(ctx, -1)
-	assert.Equal(t, entity.Inventory{}, userItemsErr)
-
 	// GetItemByName
 	item, err := linksRepository.GetItemByName(ctx, "cup")
 	assert.NoError(t, err)
@@ -108,15 +107,73 @@ func TestLinksRepository(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, itemEmpty, entity.Item{})
 
-	// GetItemById
-	id, err := linksRepository.GetItemById(ctx, item.Id)
+	// CreateItem
+	itemName := fmt.Sprintf("test-item-%d", time.Now().UnixNano())
+	created, err := linksRepository.CreateItem(ctx, entity.Item{Name: itemName, Price: 7, Description: "test", Active: true})
 	assert.NoError(t, err)
-	assert.Equal(t, id, item.Name)
+	assert.NotZero(t, created.Id)
+	assert.Equal(t, "test", created.Description)
 
-	idEmpty, err := linksRepository.GetItemById(ctx, -1)
-	assert.Error(t, err)
+	_, err = linksRepository.CreateItem(ctx, entity.Item{Name: itemName, Price: 7, Active: true})
+	assert.ErrorIs(t, err, usecase.ErrItemExists)
+
+	// TakeStock
+	taken, err := linksRepository.TakeStock(ctx, created.Id, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, taken.Price)
+	assert.Nil(t, taken.Stock)
+
+	stock := 2
+	_, err = linksRepository.UpdateItem(ctx, created.Id, entity.ItemRequest{Stock: &stock})
+	assert.NoError(t, err)
+
+	_, err = linksRepository.TakeStock(ctx, created.Id, 3)
+	assert.ErrorIs(t, err, usecase.ErrOutOfStock)
+
+	taken, err = linksRepository.TakeStock(ctx, created.Id, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, *taken.Stock)
+
+	// PurchasedQuantity
+	bought, err := linksRepository.PurchasedQuantity(ctx, userSave, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, bought)
+
+	// UpdateItem
+	inactive := false
+	updated, err := linksRepository.UpdateItem(ctx, created.Id, entity.ItemRequest{Active: &inactive})
+	assert.NoError(t, err)
+	assert.False(t, updated.Active)
+	assert.Equal(t, 7, updated.Price)
+	assert.Equal(t, 0, *updated.Stock)
+
+	_, err = linksRepository.TakeStock(ctx, created.Id, 0)
+	assert.ErrorIs(t, err, usecase.ErrItemInactive)
+
+	// ListItems
+	activeItems, err := linksRepository.ListItems(ctx, false)
+	assert.NoError(t, err)
+	assert.NotContains(t, activeItems, updated)
+
+	allItems, err := linksRepository.ListItems(ctx, true)
+	assert.NoError(t, err)
+	assert.Contains(t, allItems, updated)
+
+	// DeleteItem
+	err = linksRepository.DeleteItem(ctx, created.Id)
+	assert.NoError(t, err)
+
+	_, err = linksRepository.GetItem(ctx, created.Id)
+	assert.ErrorIs(t, err, usecase.ErrNoItem)
+
+	_, err = linksRepository.GetItemByName(ctx, itemName)
+	assert.ErrorIs(t, err, usecase.ErrNoItem)
+
+	_, err = linksRepository.TakeStock(ctx, created.Id, 0)
+	assert.ErrorIs(t, err, usecase.ErrNoItem)
+
+	err = linksRepository.DeleteItem(ctx, created.Id)
 	assert.ErrorIs(t, err, usecase.ErrNoItem)
-	assert.Equal(t, idEmpty, "")
 
 	// GetUserById
 	byId, err := linksRepository.GetUserById(ctx, userSave)
@@ -129,12 +186,238 @@ func TestLinksRepository(t *testing.T) {
 
 	err = linksRepository.TakeGiveCoins(ctx, -1, 1)
 
+	err = linksRepository.TakeGiveCoins(ctx, userSave, -100000)
+	assert.ErrorIs(t, err, usecase.ErrNoCoins)
+
+	// TakeCoins
+	err = linksRepository.TakeCoins(ctx, userSave, 100)
+	assert.NoError(t, err)
+
+	err = linksRepository.TakeCoins(ctx, userSave, 100000)
+	assert.ErrorIs(t, err, usecase.ErrNoCoins)
+
 	// MakeRecord
-	err = linksRepository.MakeRecord(ctx, userSave, 1, 100)
+	recordId, err := linksRepository.MakeRecord(ctx, userSave, 1, 100)
+	assert.NoError(t, err)
+	assert.NotZero(t, recordId)
+
+	// TakeSentRecords
+	sent, err := linksRepository.TakeSentRecords(ctx, userSave, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 1)
+	assert.Equal(t, recordId, sent[0].Id)
+
+	sentBefore, err := linksRepository.TakeSentRecords(ctx, userSave, sent[0].Id, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, sentBefore)
+
+	// TakeInfo
+	infoBefore, err := linksRepository.TakeInfo(ctx, userSave, 10)
 	assert.NoError(t, err)
+	assert.Equal(t, entity.Inventory{Items: []entity.InventoryItem{
+		{
+			ItemId:   1,
+			Type:     "t-shirt",
+			Quantity: 1,
+		},
+	}}, infoBefore.Inventory)
+	assert.Len(t, infoBefore.CoinHistory.Sent.SentItems, 1)
+	assert.Equal(t, sent[0], infoBefore.CoinHistory.Sent.SentItems[0])
+
+	// AddToCart
+	inCart, err := linksRepository.AddToCart(ctx, userSave, item.Id, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, inCart)
+
+	inCart, err = linksRepository.AddToCart(ctx, userSave, item.Id, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, inCart)
+
+	// GetCart
+	cart, err := linksRepository.GetCart(ctx, userSave)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.CartItem{{ItemId: item.Id, Type: "cup", Price: item.Price, Quantity: 3, Available: true}}, cart)
+
+	cartForUpdate, err := linksRepository.GetCartForUpdate(ctx, userSave)
+	assert.NoError(t, err)
+	assert.Equal(t, cart, cartForUpdate)
+
+	// RemoveFromCart
+	err = linksRepository.RemoveFromCart(ctx, userSave, "pen")
+	assert.ErrorIs(t, err, usecase.ErrNoItem)
 
-	// TakeRecords
-	records, err := linksRepository.TakeRecords(ctx, userSave)
+	err = linksRepository.RemoveFromCart(ctx, userSave, "cup")
 	assert.NoError(t, err)
-	assert.NotEmpty(t, records)
+
+	// ClearCart
+	_, err = linksRepository.AddToCart(ctx, userSave, item.Id, 1)
+	assert.NoError(t, err)
+
+	err = linksRepository.ClearCart(ctx, userSave)
+	assert.NoError(t, err)
+
+	cart, err = linksRepository.GetCart(ctx, userSave)
+	assert.NoError(t, err)
+	assert.Empty(t, cart)
+
+	// CreateOrder
+	order, err := linksRepository.CreateOrder(ctx, userSave, 2*item.Price)
+	assert.NoError(t, err)
+	assert.NotZero(t, order.Id)
+
+	// SaveOrderLines
+	lines := []entity.OrderLine{{ItemId: item.Id, Type: "cup", Price: item.Price, Quantity: 2}}
+	err = linksRepository.SaveOrderLines(ctx, userSave, order.Id, lines)
+	assert.NoError(t, err)
+
+	// TakeOrders
+	orders, err := linksRepository.TakeOrders(ctx, userSave, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, order.Id, orders[0].Id)
+	assert.Equal(t, lines, orders[0].Lines)
+
+	orderPurchases, err := linksRepository.TakePurchases(ctx, userSave, 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, order.Id, orderPurchases[0].OrderId)
+
+	// GetPurchaseForUpdate
+	purchase, err := linksRepository.GetPurchaseForUpdate(ctx, orderPurchases[0].Id)
+	assert.NoError(t, err)
+	assert.Equal(t, userSave, purchase.UserId)
+	assert.Equal(t, 2, purchase.Quantity)
+
+	_, err = linksRepository.GetPurchaseForUpdate(ctx, -1)
+	assert.ErrorIs(t, err, usecase.ErrNoPurchase)
+
+	// MarkRefunded
+	err = linksRepository.MarkRefunded(ctx, purchase.Id, 1)
+	assert.NoError(t, err)
+
+	err = linksRepository.MarkRefunded(ctx, purchase.Id, 2)
+	assert.ErrorIs(t, err, usecase.ErrAlreadyRefunded)
+
+	bought, err = linksRepository.PurchasedQuantity(ctx, userSave, item.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bought)
+
+	// ReturnStock
+	err = linksRepository.ReturnStock(ctx, item.Id, 1)
+	assert.NoError(t, err)
+
+	// TakeInventory
+	err = linksRepository.TakeInventory(ctx, userSave, item.Id, 1)
+	assert.ErrorIs(t, err, usecase.ErrNotInInventory)
+
+	// SaveRefund
+	refund, err := linksRepository.SaveRefund(ctx, entity.Refund{PurchaseId: purchase.Id, Quantity: 1, Amount: purchase.Price, Reason: "test", RefundedBy: userSave})
+	assert.NoError(t, err)
+	assert.NotZero(t, refund.Id)
+
+	// TakeInfo
+	info, err := linksRepository.TakeInfo(ctx, userSave, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, infoBefore.Inventory, info.Inventory)
+	assert.Equal(t, infoBefore.CoinHistory, info.CoinHistory)
+	assert.Equal(t, orders, info.Orders.Items)
+
+	_, err = linksRepository.TakeInfo(ctx, -1, 10)
+	assert.ErrorIs(t, err, usecase.ErrNoUser)
+
+	// SaveGrant
+	grant, err := linksRepository.SaveGrant(ctx, entity.Grant{UserId: userSave, Amount: 10, Reason: "bonus"})
+	assert.NoError(t, err)
+	assert.NotZero(t, grant.Id)
+	assert.False(t, grant.CreatedAt.IsZero())
+
+	// ClaimAllowanceRun
+	period := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	claimedRun, err := linksRepository.ClaimAllowanceRun(ctx, period)
+	assert.NoError(t, err)
+	assert.True(t, claimedRun)
+
+	claimedRun, err = linksRepository.ClaimAllowanceRun(ctx, period)
+	assert.NoError(t, err)
+	assert.False(t, claimedRun)
+
+	// GrantAll
+	before, err := linksRepository.GetUserById(ctx, userSave)
+	assert.NoError(t, err)
+
+	grantedIds, err := linksRepository.GrantAll(ctx, 5, "allowance")
+	assert.NoError(t, err)
+	assert.Contains(t, grantedIds, userSave)
+
+	after, err := linksRepository.GetUserById(ctx, userSave)
+	assert.NoError(t, err)
+	assert.Equal(t, before.Coins+5, after.Coins)
+
+	// PostEntry
+	entryId, err := linksRepository.PostEntry(ctx, entity.JournalEntry{
+		Kind:        entity.EntryTransfer,
+		ReferenceId: recordId,
+		Postings: []entity.Posting{
+			{Account: entity.WalletAccount(userSave), Amount: -100},
+			{Account: entity.WalletAccount(1), Amount: 100},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, entryId)
+
+	// Reconcile: пользователь создан в обход usecase, так что его баланс не совпадает с журналом
+	report, err := linksRepository.Reconcile(ctx)
+	assert.NoError(t, err)
+	assert.NotZero(t, report.Users)
+	assert.Empty(t, report.UnbalancedEntries)
+	assert.False(t, report.OK())
+
+	// SaveRefreshToken
+	refreshHash := []byte(fmt.Sprintf("hash-%d", userSave))
+	err = linksRepository.SaveRefreshToken(ctx, entity.RefreshToken{
+		UserId:    userSave,
+		Hash:      refreshHash,
+		FamilyId:  fmt.Sprintf("family-%d", userSave),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	// GetRefreshTokenForUpdate
+	refresh, err := linksRepository.GetRefreshTokenForUpdate(ctx, refreshHash)
+	assert.NoError(t, err)
+	assert.Equal(t, userSave, refresh.UserId)
+	assert.False(t, refresh.Revoked)
+
+	_, err = linksRepository.GetRefreshTokenForUpdate(ctx, []byte("unknown"))
+	assert.ErrorIs(t, err, usecase.ErrNoRefreshToken)
+
+	// RevokeRefreshTokenFamily
+	err = linksRepository.RevokeRefreshTokenFamily(ctx, refresh.FamilyId)
+	assert.NoError(t, err)
+
+	refresh, err = linksRepository.GetRefreshTokenForUpdate(ctx, refreshHash)
+	assert.NoError(t, err)
+	assert.True(t, refresh.Revoked)
+
+	// ClaimIdempotencyKey
+	idempotencyKey := fmt.Sprintf("key-%d", userSave)
+	claimed, err := linksRepository.ClaimIdempotencyKey(ctx, userSave, idempotencyKey, []byte("hash"), time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// SaveIdempotentResponse
+	stored := entity.IdempotentResponse{RequestHash: []byte("hash"), StatusCode: 200, Body: []byte("{}")}
+	err = linksRepository.SaveIdempotentResponse(ctx, userSave, idempotencyKey, stored)
+	assert.NoError(t, err)
+
+	claimed, err = linksRepository.ClaimIdempotencyKey(ctx, userSave, idempotencyKey, []byte("hash"), time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// GetIdempotentResponse
+	got, err := linksRepository.GetIdempotentResponse(ctx, userSave, idempotencyKey)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, got)
+
+	_, err = linksRepository.GetIdempotentResponse(ctx, userSave, "unknown")
+	assert.ErrorIs(t, err, usecase.ErrNoIdempotencyKey)
 }
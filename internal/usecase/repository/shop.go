@@ -13,6 +13,9 @@ import (
 
 const defaultEntityCap = 64
 
+// userColumns колонки users в порядке полей entity.User
+var userColumns = []string{"id", "username", "password", "amount", "role"}
+
 type ShopRepository struct {
 	*postgres.Postgres
 }
@@ -36,7 +39,7 @@ func (s *ShopRepository) SaveUser(ctx context.Context, username string, passhash
 	}
 
 	var id int
-	err = s.Pool.QueryRow(ctx, sql, args...).Scan(&id)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -47,7 +50,7 @@ func (s *ShopRepository) SaveUser(ctx context.Context, username string, passhash
 func (s *ShopRepository) FindUser(ctx context.Context, username string) (entity.User, error) {
 	const op = "ShopRepository.FindUser"
 
-	sq, args, err := s.Builder.Select("*").
+	sq, args, err := s.Builder.Select(userColumns...).
 		From("users").
 		Where(squirrel.Eq{"username": username}).
 		ToSql()
@@ -56,7 +59,7 @@ func (s *ShopRepository) FindUser(ctx context.Context, username string) (entity.
 	}
 
 	var user entity.User
-	err = s.Pool.QueryRow(ctx, sq, args...).Scan(&user.Id, &user.Username, &user.Passhash, &user.Coins)
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&user.Id, &user.Username, &user.Passhash, &user.Coins, &user.Role)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return entity.User{}, usecase.ErrNoUser
@@ -80,7 +83,7 @@ func (s *ShopRepository) BuyItem(ctx context.Context, userId, itemId, quantity i
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.Pool.Exec(ctx, sq, args...)
+	_, err = s.conn(ctx).Exec(ctx, sq, args...)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -88,51 +91,19 @@ func (s *ShopRepository) BuyItem(ctx context.Context, userId, itemId, quantity i
 	return nil
 }
 
-func (s *ShopRepository) GetItemUser(ctx context.Context, userId int) (entity.Inventory, error) {
-	const op = "ShopRepository.GetItemUser"
-
-	sq, args, err := s.Builder.Select("item_id", "quantity").
-		From("inventory").
-		Where(squirrel.Eq{"user_id": userId}).
-		ToSql()
-	if err != nil {
-		return entity.Inventory{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	rows, err := s.Pool.Query(ctx, sq, args...)
-	if err != nil {
-		return entity.Inventory{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	var inventory []entity.InventoryItem
-	for rows.Next() {
-		var item entity.InventoryItem
-		err = rows.Scan(&item.ItemId, &item.Quantity)
-		if err != nil {
-			return entity.Inventory{}, fmt.Errorf("%s: %w", op, err)
-		}
-
-		inventory = append(inventory, item)
-	}
-
-	return entity.Inventory{
-		Items: inventory,
-	}, nil
-}
-
 func (s *ShopRepository) GetItemByName(ctx context.Context, itemName string) (entity.Item, error) {
 	const op = "ShopRepository.GetItemByName"
 
-	sq, args, err := s.Builder.Select("*").
+	sq, args, err := s.Builder.Select(itemColumns...).
 		From("items").
 		Where(squirrel.Eq{"name": itemName}).
+		Where(notDeleted).
 		ToSql()
 	if err != nil {
 		return entity.Item{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var item entity.Item
-	err = s.Pool.QueryRow(ctx, sq, args...).Scan(&item.Id, &item.Name, &item.Price)
+	item, err := scanItem(s.conn(ctx).QueryRow(ctx, sq, args...))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return entity.Item{}, usecase.ErrNoItem
@@ -144,44 +115,48 @@ func (s *ShopRepository) GetItemByName(ctx context.Context, itemName string) (en
 	return item, nil
 }
 
-func (s *ShopRepository) GetItemById(ctx context.Context, itemId int) (string, error) {
-	const op = "ShopRepository.GetItemById"
+func (s *ShopRepository) GetUserById(ctx context.Context, userId int) (entity.User, error) {
+	const op = "ShopRepository.GetCoins"
 
-	sq, args, err := s.Builder.Select("name").
-		From("items").
-		Where(squirrel.Eq{"id": itemId}).
+	sq, args, err := s.Builder.
+		Select(userColumns...).
+		From("users").
+		Where(squirrel.Eq{"id": userId}).
 		ToSql()
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return entity.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var res string
-	err = s.Pool.QueryRow(ctx, sq, args...).Scan(&res)
+	var user entity.User
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&user.Id, &user.Username, &user.Passhash, &user.Coins, &user.Role)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", usecase.ErrNoItem
+			return entity.User{}, usecase.ErrNoUser
 		}
 
-		return "", fmt.Errorf("%s: %w", op, err)
+		return entity.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return res, nil
+	return user, nil
 }
 
-func (s *ShopRepository) GetUserById(ctx context.Context, userId int) (entity.User, error) {
-	const op = "ShopRepository.GetCoins"
+// GetUserByIdForUpdate то же, что GetUserById, но блокирует строку пользователя до конца транзакции.
+// Имеет смысл только внутри WithTx
+func (s *ShopRepository) GetUserByIdForUpdate(ctx context.Context, userId int) (entity.User, error) {
+	const op = "ShopRepository.GetUserByIdForUpdate"
 
 	sq, args, err := s.Builder.
-		Select("*").
+		Select(userColumns...).
 		From("users").
 		Where(squirrel.Eq{"id": userId}).
+		Suffix("FOR UPDATE").
 		ToSql()
 	if err != nil {
 		return entity.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	var user entity.User
-	err = s.Pool.QueryRow(ctx, sq, args...).Scan(&user.Id, &user.Username, &user.Passhash, &user.Coins)
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&user.Id, &user.Username, &user.Passhash, &user.Coins, &user.Role)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return entity.User{}, usecase.ErrNoUser
@@ -193,6 +168,31 @@ func (s *ShopRepository) GetUserById(ctx context.Context, userId int) (entity.Us
 	return user, nil
 }
 
+// SetUserRole меняет роль пользователя по имени
+func (s *ShopRepository) SetUserRole(ctx context.Context, username, role string) error {
+	const op = "ShopRepository.SetUserRole"
+
+	sq, args, err := s.Builder.
+		Update("users").
+		Set("role", role).
+		Where(squirrel.Eq{"username": username}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sq, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNoUser
+	}
+
+	return nil
+}
+
 // TakeGiveCoins надо передавать значение amount со знаком согласно операции (добавить: +, убрать: - )
 func (s *ShopRepository) TakeGiveCoins(ctx context.Context, userId, amount int) error {
 	const op = "ShopRepository.TakeGiveCoins"
@@ -206,63 +206,269 @@ func (s *ShopRepository) TakeGiveCoins(ctx context.Context, userId, amount int)
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.Pool.Exec(ctx, sq, args...)
+	_, err = s.conn(ctx).Exec(ctx, sq, args...)
 	if err != nil {
+		if isCheckViolation(err) {
+			return usecase.ErrNoCoins
+		}
+
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
-func (s *ShopRepository) MakeRecord(ctx context.Context, fromUserId, toUserId, amount int) error {
-	const op = "ShopRepository.MakeRecord"
+// TakeCoins списывает amount монет, только если на балансе их достаточно.
+// Проверка и списание происходят одним UPDATE, поэтому параллельные списания не уводят баланс в минус
+func (s *ShopRepository) TakeCoins(ctx context.Context, userId, amount int) error {
+	const op = "ShopRepository.TakeCoins"
 
-	sq, args, err := s.Builder.Insert("coin_history").
-		Columns("from_user", "to_user", "amount").
-		Values(fromUserId, toUserId, amount).
+	sq, args, err := s.Builder.
+		Update("users").
+		Set("amount", squirrel.Expr("amount - ?", amount)).
+		Where(squirrel.Eq{"id": userId}).
+		Where(squirrel.GtOrEq{"amount": amount}).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.Pool.Exec(ctx, sq, args...)
+	tag, err := s.conn(ctx).Exec(ctx, sq, args...)
 	if err != nil {
+		if isCheckViolation(err) {
+			return usecase.ErrNoCoins
+		}
+
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrNoCoins
+	}
+
 	return nil
 }
 
-func (s *ShopRepository) TakeRecords(ctx context.Context, userId int) ([]entity.BothDirection, error) {
-	const op = "ShopRepository.TakeRecords"
+func (s *ShopRepository) MakeRecord(ctx context.Context, fromUserId, toUserId, amount int) (int, error) {
+	const op = "ShopRepository.MakeRecord"
 
-	sq, args, err := s.Builder.Select("from_user", "to_user", "amount").
-		From("coin_history").
-		Where(squirrel.Or{
-			squirrel.Eq{"from_user": userId},
-			squirrel.Eq{"to_user": userId},
-		}).
+	sq, args, err := s.Builder.Insert("coin_history").
+		Columns("from_user", "to_user", "amount").
+		Values(fromUserId, toUserId, amount).
+		Suffix("RETURNING id").
 		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id int
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// TakeSentRecords возвращает до limit исходящих переводов пользователя с id < before, от новых к старым.
+// before = 0 - с самого нового. Запрос идёт по индексу idx_from_user_coin_history (from_user, id)
+func (s *ShopRepository) TakeSentRecords(ctx context.Context, userId, before, limit int) ([]entity.SentItem, error) {
+	const op = "ShopRepository.TakeSentRecords"
+
+	sq, args, err := s.sentQuery(userId, before, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rows, err := s.Pool.Query(ctx, sq, args...)
+	items, err := scanSent(rows, limit)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var items []entity.BothDirection
+	return items, nil
+}
+
+// TakeReceivedRecords возвращает до limit входящих переводов пользователя с id < before, от новых к старым.
+// before = 0 - с самого нового. Запрос идёт по индексу idx_to_user_coin_history (to_user, id)
+func (s *ShopRepository) TakeReceivedRecords(ctx context.Context, userId, before, limit int) ([]entity.ReceivedItem, error) {
+	const op = "ShopRepository.TakeReceivedRecords"
+
+	sq, args, err := s.receivedQuery(userId, before, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	items, err := scanReceived(rows, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+func (s *ShopRepository) sentQuery(userId, before, limit int) squirrel.SelectBuilder {
+	builder := s.Builder.Select("ch.id", "u.username", "ch.amount", "ch.created_at").
+		From("coin_history ch").
+		Join("users u ON u.id = ch.to_user").
+		Where(squirrel.Eq{"ch.from_user": userId}).
+		OrderBy("ch.id DESC").
+		Limit(uint64(limit))
+
+	if before > 0 {
+		builder = builder.Where(squirrel.Lt{"ch.id": before})
+	}
+
+	return builder
+}
+
+func (s *ShopRepository) receivedQuery(userId, before, limit int) squirrel.SelectBuilder {
+	builder := s.Builder.Select("ch.id", "u.username", "ch.amount", "ch.created_at").
+		From("coin_history ch").
+		Join("users u ON u.id = ch.from_user").
+		Where(squirrel.Eq{"ch.to_user": userId}).
+		OrderBy("ch.id DESC").
+		Limit(uint64(limit))
+
+	if before > 0 {
+		builder = builder.Where(squirrel.Lt{"ch.id": before})
+	}
+
+	return builder
+}
+
+// scanSent читает строки sentQuery и закрывает rows
+func scanSent(rows pgx.Rows, limit int) ([]entity.SentItem, error) {
+	defer rows.Close()
+
+	items := make([]entity.SentItem, 0, limit)
+	for rows.Next() {
+		var item entity.SentItem
+
+		err := rows.Scan(&item.Id, &item.ToUser, &item.Amount, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanReceived читает строки receivedQuery и закрывает rows
+func scanReceived(rows pgx.Rows, limit int) ([]entity.ReceivedItem, error) {
+	defer rows.Close()
+
+	items := make([]entity.ReceivedItem, 0, limit)
 	for rows.Next() {
-		var both entity.BothDirection
+		var item entity.ReceivedItem
 
-		err = rows.Scan(&both.ToUser, &both.FromUser, &both.Amount)
+		err := rows.Scan(&item.Id, &item.FromUser, &item.Amount, &item.CreatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
+			return nil, err
 		}
 
-		items = append(items, both)
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return items, nil
 }
+
+func (s *ShopRepository) MakePurchase(ctx context.Context, userId, itemId, price, quantity int) (int, error) {
+	const op = "ShopRepository.MakePurchase"
+
+	sq, args, err := s.Builder.Insert("purchases").
+		Columns("user_id", "item_id", "price", "quantity").
+		Values(userId, itemId, price, quantity).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id int
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// TakePurchases возвращает до limit покупок пользователя с id < before, от новых к старым. before = 0 - с самой новой
+func (s *ShopRepository) TakePurchases(ctx context.Context, userId, before, limit int) ([]entity.Purchase, error) {
+	const op = "ShopRepository.TakePurchases"
+
+	sq, args, err := s.purchasesQuery(userId, before, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	purchases, err := scanPurchases(rows, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purchases, nil
+}
+
+func (s *ShopRepository) purchasesQuery(userId, before, limit int) squirrel.SelectBuilder {
+	builder := s.Builder.Select("p.id", "p.item_id", "i.name", "p.price", "p.quantity", "p.created_at", "COALESCE(p.order_id, 0)", "p.refunded_quantity").
+		From("purchases p").
+		Join("items i ON i.id = p.item_id").
+		Where(squirrel.Eq{"p.user_id": userId}).
+		OrderBy("p.id DESC").
+		Limit(uint64(limit))
+
+	if before > 0 {
+		builder = builder.Where(squirrel.Lt{"p.id": before})
+	}
+
+	return builder
+}
+
+// scanPurchases читает строки purchasesQuery и закрывает rows
+func scanPurchases(rows pgx.Rows, limit int) ([]entity.Purchase, error) {
+	defer rows.Close()
+
+	purchases := make([]entity.Purchase, 0, limit)
+	for rows.Next() {
+		var p entity.Purchase
+
+		err := rows.Scan(&p.Id, &p.ItemId, &p.Type, &p.Price, &p.Quantity, &p.CreatedAt, &p.OrderId, &p.Refunded)
+		if err != nil {
+			return nil, err
+		}
+
+		purchases = append(purchases, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return purchases, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+)
+
+func (s *ShopRepository) SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	const op = "ShopRepository.SaveRefreshToken"
+
+	sql, args, err := s.Builder.Insert("refresh_tokens").
+		Columns("user_id", "token_hash", "family_id", "expires_at").
+		Values(token.UserId, token.Hash, token.FamilyId, token.ExpiresAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenForUpdate ищет токен по хэшу и блокирует его строку, чтобы один токен нельзя было
+// обменять дважды параллельными запросами. Имеет смысл только внутри WithTx
+func (s *ShopRepository) GetRefreshTokenForUpdate(ctx context.Context, hash []byte) (entity.RefreshToken, error) {
+	const op = "ShopRepository.GetRefreshTokenForUpdate"
+
+	sq, args, err := s.Builder.
+		Select("id", "user_id", "token_hash", "family_id", "expires_at", "revoked_at IS NOT NULL").
+		From("refresh_tokens").
+		Where(squirrel.Eq{"token_hash": hash}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return entity.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var token entity.RefreshToken
+	err = s.conn(ctx).QueryRow(ctx, sq, args...).
+		Scan(&token.Id, &token.UserId, &token.Hash, &token.FamilyId, &token.ExpiresAt, &token.Revoked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.RefreshToken{}, usecase.ErrNoRefreshToken
+		}
+
+		return entity.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+func (s *ShopRepository) RevokeRefreshToken(ctx context.Context, tokenId int) error {
+	const op = "ShopRepository.RevokeRefreshToken"
+
+	sql, args, err := s.Builder.Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": tokenId, "revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenFamily отзывает все токены, полученные ротацией от одного логина
+func (s *ShopRepository) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	const op = "ShopRepository.RevokeRefreshTokenFamily"
+
+	sql, args, err := s.Builder.Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"family_id": familyId, "revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
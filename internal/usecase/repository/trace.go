@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/k1v4/avito_shop/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = telemetry.Tracer("github.com/k1v4/avito_shop/internal/usecase/repository")
+
+// tracedQuerier пишет спан на каждый SQL-запрос. Спан запроса, возвращающего строки, заканчивается,
+// когда строки закрыты, так что в его время входит и чтение результата
+type tracedQuerier struct {
+	q querier
+}
+
+func (t tracedQuerier) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startQuery(ctx, "postgres.exec", sql)
+
+	tag, err := t.q.Exec(ctx, sql, arguments...)
+	telemetry.End(span, err)
+
+	return tag, err
+}
+
+func (t tracedQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := startQuery(ctx, "postgres.query", sql)
+
+	rows, err := t.q.Query(ctx, sql, args...)
+	if err != nil {
+		telemetry.End(span, err)
+
+		return rows, err
+	}
+
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (t tracedQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := startQuery(ctx, "postgres.query", sql)
+
+	return tracedRow{row: t.q.QueryRow(ctx, sql, args...), span: span}
+}
+
+func (t tracedQuerier) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	ctx, span := tracer.Start(ctx, "postgres.batch", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.Int("db.batch.size", b.Len()),
+		))
+
+	return &tracedBatch{BatchResults: t.q.SendBatch(ctx, b), span: span}
+}
+
+func startQuery(ctx context.Context, name, sql string) (context.Context, trace.Span) {
+	// в запросах только плейсхолдеры, значения параметров в спан не попадают
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", sql),
+		))
+}
+
+type tracedRows struct {
+	pgx.Rows
+	span trace.Span
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+
+	// Close вызывается и повторно, например явно и в defer
+	if r.span.IsRecording() {
+		telemetry.End(r.span, r.Rows.Err())
+	}
+}
+
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+
+	// отсутствие строки - обычный ответ, а не сбой запроса
+	if errors.Is(err, pgx.ErrNoRows) {
+		telemetry.End(r.span, nil)
+	} else {
+		telemetry.End(r.span, err)
+	}
+
+	return err
+}
+
+type tracedBatch struct {
+	pgx.BatchResults
+	span trace.Span
+}
+
+func (b *tracedBatch) Close() error {
+	err := b.BatchResults.Close()
+	if b.span.IsRecording() {
+		telemetry.End(b.span, err)
+	}
+
+	return err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// checkViolationCode код ошибки postgres при нарушении CHECK-ограничения
+const checkViolationCode = "23514"
+
+type txKey struct{}
+
+// querier общий набор методов пула и транзакции pgx
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// conn возвращает транзакцию из контекста, если она открыта через WithTx, иначе пул.
+// Запросы через него трассируются
+func (s *ShopRepository) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tracedQuerier{q: tx}
+	}
+
+	return tracedQuerier{q: s.Pool}
+}
+
+// WithTx выполняет fn в одной транзакции. Все методы репозитория, вызванные с переданным в fn контекстом,
+// работают внутри неё. Вложенный вызов переиспользует уже открытую транзакцию.
+func (s *ShopRepository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "ShopRepository.WithTx"
+
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// после Commit откат ничего не делает
+	defer tx.Rollback(ctx)
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// isCheckViolation сообщает, что запрос нарушил CHECK-ограничение, например users.amount >= 0
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == checkViolationCode
+}
@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/k1v4/avito_shop/internal/entity"
+)
+
+func (uc *ShopUseCase) SetRole(ctx context.Context, username, role string) error {
+	const op = "ShopUseCase.SetRole"
+
+	if !entity.ValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	if err := uc.repo.SetUserRole(ctx, username, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
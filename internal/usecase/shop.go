@@ -6,146 +6,304 @@ import (
 	"fmt"
 	"github.com/k1v4/avito_shop/internal/entity"
 	"github.com/k1v4/avito_shop/pkg/jwtPkg"
-	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
+	"net/http"
 	"time"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrWrongPassword      = errors.New("wrong password")
-	ErrUserExist          = errors.New("user exist")
+	ErrInvalidCredentials = NewError(CodeInvalidCredentials, http.StatusUnauthorized, "invalid credentials")
+	ErrWrongPassword      = NewError(CodeInvalidCredentials, http.StatusUnauthorized, "wrong password")
+	ErrUserExist          = NewError(CodeUserExists, http.StatusConflict, "user exist")
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	// maxPurchaseQuantity сколько штук можно купить за раз
+	maxPurchaseQuantity = 100
 )
 
 type ShopUseCase struct {
-	repo  IShopRepository
-	cache *redis.Client
+	repo   IShopRepository
+	cache  Cache
+	tokens *jwtPkg.Manager
+
+	cacheNamespace string
+	infoTTL        time.Duration
+	tokenTTL       time.Duration
+	refreshTTL     time.Duration
+	idempotencyTTL time.Duration
+	refundWindow   time.Duration
 }
 
-func NewShopUseCase(r IShopRepository, red *redis.Client) *ShopUseCase {
-	return &ShopUseCase{
-		repo:  r,
-		cache: red,
+func NewShopUseCase(r IShopRepository, c Cache, tokens *jwtPkg.Manager, opts ...Option) *ShopUseCase {
+	uc := &ShopUseCase{
+		repo:           r,
+		cache:          c,
+		tokens:         tokens,
+		cacheNamespace: defaultCacheNamespace,
+		infoTTL:        defaultInfoTTL,
+		tokenTTL:       defaultTokenTTL,
+		refreshTTL:     defaultRefreshTTL,
+		idempotencyTTL: defaultIdempotencyTTL,
+		refundWindow:   defaultRefundWindow,
 	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
 }
 
-func (uc *ShopUseCase) Login(ctx context.Context, username, password string) (string, error) {
+func (uc *ShopUseCase) Login(ctx context.Context, username, password string) (entity.AuthResponse, error) {
 	const op = "ShopUseCase.Login"
 
 	user, err := uc.repo.FindUser(ctx, username)
 	if err != nil {
 		if errors.Is(err, ErrNoUser) {
-			token, err := uc.Register(ctx, username, password)
+			tokens, err := uc.Register(ctx, username, password)
 			if err != nil {
-				return "", fmt.Errorf("%s: %w", op, err)
+				return entity.AuthResponse{}, fmt.Errorf("%s: %w", op, err)
 			}
-			return token, nil
+			return tokens, nil
 		}
 
-		return "", fmt.Errorf("%s: %w", op, err)
+		return entity.AuthResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err = bcrypt.CompareHashAndPassword(user.Passhash, []byte(password)); err != nil {
-		return "", ErrWrongPassword
+		return entity.AuthResponse{}, ErrWrongPassword
 	}
 
-	token, err := jwtPkg.NewToken(user, time.Duration(1*time.Hour))
+	tokens, err := uc.issueTokens(ctx, user, "")
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return entity.AuthResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return token, nil
+	return tokens, nil
 }
 
-func (uc *ShopUseCase) Register(ctx context.Context, username, password string) (string, error) {
+func (uc *ShopUseCase) Register(ctx context.Context, username, password string) (entity.AuthResponse, error) {
 	const op = "ShopUseCase.Register"
 
 	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return entity.AuthResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	saveUserId, err := uc.repo.SaveUser(ctx, username, passHash)
+	var saveUserId int
+	err = uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		id, err := uc.repo.SaveUser(ctx, username, passHash)
+		if err != nil {
+			return err
+		}
+
+		// стартовый баланс задаёт default колонки, в журнал он попадает выпуском из эмиссии
+		user, err := uc.repo.GetUserById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if user.Coins > 0 {
+			err = uc.post(ctx, issue(entity.EntryOpening, id, id, user.Coins, "opening balance"))
+			if err != nil {
+				return err
+			}
+		}
+
+		saveUserId = id
+
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return entity.AuthResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	token, err := jwtPkg.NewToken(entity.User{
+	tokens, err := uc.issueTokens(ctx, entity.User{
 		Id:       saveUserId,
 		Username: username,
 		Passhash: passHash,
-	}, time.Duration(1*time.Hour))
+		Role:     entity.RoleEmployee,
+	}, "")
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return entity.AuthResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return token, nil
+	return tokens, nil
 }
 
-func (uc *ShopUseCase) BuyItem(ctx context.Context, userId int, itemName string) error {
+// BuyItem покупает quantity штук товара. Остаток на складе, доступность, цена и лимит на пользователя
+// берутся из строки товара под блокировкой, поэтому ни параллельные покупки, ни правка товара
+// админом между чтением и покупкой их не обойдут
+func (uc *ShopUseCase) BuyItem(ctx context.Context, userId int, itemName string, quantity int) error {
 	const op = "ShopUseCase.BuyItem"
 
-	item, err := uc.repo.GetItemByName(ctx, itemName)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	if quantity <= 0 || quantity > maxPurchaseQuantity {
+		return ErrInvalidQuantity.WithMessage(fmt.Sprintf("quantity must be 1 to %d", maxPurchaseQuantity))
 	}
 
-	user, err := uc.repo.GetUserById(ctx, userId)
+	item, err := uc.repo.GetItemByName(ctx, itemName)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if user.Coins < item.Price {
-		return ErrNoCoins
+	// до транзакции отсекаем только заведомо неактивный товар, окончательно это проверяет reserve
+	if !item.Active {
+		return ErrItemInactive
 	}
 
-	err = uc.repo.BuyItem(ctx, userId, item.Id, 1)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
+	err = uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		locked, err := uc.reserve(ctx, userId, item.Id, quantity)
+		if err != nil {
+			return err
+		}
+
+		cost := locked.Price * quantity
+
+		// списание идёт условным UPDATE, так что параллельные покупки не уведут баланс в минус
+		err = uc.repo.TakeCoins(ctx, userId, cost)
+		if err != nil {
+			return err
+		}
+
+		err = uc.repo.BuyItem(ctx, userId, item.Id, quantity)
+		if err != nil {
+			return err
+		}
+
+		// цена сохраняется вместе с покупкой, потому что цена товара может поменяться
+		purchaseId, err := uc.repo.MakePurchase(ctx, userId, item.Id, locked.Price, quantity)
+		if err != nil {
+			return err
+		}
 
-	err = uc.repo.TakeGiveCoins(ctx, userId, -item.Price)
+		return uc.post(ctx, entity.JournalEntry{
+			Kind:        entity.EntryPurchase,
+			ReferenceId: purchaseId,
+			Postings: []entity.Posting{
+				{Account: entity.WalletAccount(userId), Amount: -cost},
+				{Account: entity.RevenueAccount, Amount: cost},
+			},
+		})
+	})
 	if err != nil {
+		if errors.Is(err, ErrNoCoins) || errors.Is(err, ErrOutOfStock) || errors.Is(err, ErrPurchaseLimit) ||
+			errors.Is(err, ErrItemInactive) || errors.Is(err, ErrNoItem) {
+			return err
+		}
+
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	uc.invalidateInfo(ctx, userId)
+
 	return nil
 }
 
-func (uc *ShopUseCase) SendCoins(ctx context.Context, toUserName string, fromUserId, amount int) error {
-	const op = "ShopUseCase.SendCoins"
-
-	toUser, err := uc.repo.FindUser(ctx, toUserName)
+// reserve списывает товар со склада и проверяет лимит на пользователя, возвращает заблокированную строку
+// товара. Вызывается внутри WithTx: TakeStock блокирует строку, поэтому проверка лимита не гонится
+// с параллельными покупками
+func (uc *ShopUseCase) reserve(ctx context.Context, userId, itemId, quantity int) (entity.Item, error) {
+	item, err := uc.repo.TakeStock(ctx, itemId, quantity)
 	if err != nil {
-		return ErrNoUser
+		return entity.Item{}, err
+	}
+
+	if item.PerUserLimit == nil {
+		return item, nil
 	}
 
-	fromUser, err := uc.repo.GetUserById(ctx, fromUserId)
+	bought, err := uc.repo.PurchasedQuantity(ctx, userId, itemId)
 	if err != nil {
-		return ErrNoUser
+		return entity.Item{}, err
 	}
 
-	if fromUser.Coins < amount {
-		return ErrNoCoins
+	if bought+quantity > *item.PerUserLimit {
+		return entity.Item{}, ErrPurchaseLimit.WithMessage(fmt.Sprintf("limit is %d per user, already bought %d", *item.PerUserLimit, bought))
 	}
 
-	toUserId := toUser.Id
+	return item, nil
+}
+
+func (uc *ShopUseCase) SendCoins(ctx context.Context, toUserName string, fromUserId, amount int) error {
+	const op = "ShopUseCase.SendCoins"
 
-	err = uc.repo.TakeGiveCoins(ctx, toUserId, amount)
+	toUser, err := uc.repo.FindUser(ctx, toUserName)
 	if err != nil {
+		if errors.Is(err, ErrNoUser) {
+			return ErrNoUser
+		}
+
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = uc.repo.TakeGiveCoins(ctx, fromUserId, -amount)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	toUserId := toUser.Id
+	if toUserId == fromUserId {
+		return ErrSelfTransfer
 	}
 
-	err = uc.repo.MakeRecord(ctx, fromUserId, toUserId, amount)
+	err = uc.repo.WithTx(ctx, func(ctx context.Context) error {
+		// блокируем строки всегда в порядке возрастания id, иначе встречные переводы
+		// A -> B и B -> A могут заблокировать друг друга
+		lockOrder := []int{fromUserId, toUserId}
+		if toUserId < fromUserId {
+			lockOrder = []int{toUserId, fromUserId}
+		}
+
+		var fromUser entity.User
+		for _, id := range lockOrder {
+			u, err := uc.repo.GetUserByIdForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if id == fromUserId {
+				fromUser = u
+			}
+		}
+
+		if fromUser.Coins < amount {
+			return ErrNoCoins
+		}
+
+		err := uc.repo.TakeGiveCoins(ctx, toUserId, amount)
+		if err != nil {
+			return err
+		}
+
+		err = uc.repo.TakeGiveCoins(ctx, fromUserId, -amount)
+		if err != nil {
+			return err
+		}
+
+		recordId, err := uc.repo.MakeRecord(ctx, fromUserId, toUserId, amount)
+		if err != nil {
+			return err
+		}
+
+		return uc.post(ctx, entity.JournalEntry{
+			Kind:        entity.EntryTransfer,
+			ReferenceId: recordId,
+			Postings: []entity.Posting{
+				{Account: entity.WalletAccount(fromUserId), Amount: -amount},
+				{Account: entity.WalletAccount(toUserId), Amount: amount},
+			},
+		})
+	})
 	if err != nil {
+		if errors.Is(err, ErrNoUser) || errors.Is(err, ErrNoCoins) {
+			return err
+		}
+
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	uc.invalidateInfo(ctx, fromUserId, toUserId)
+
 	return nil
 }
 
@@ -153,78 +311,113 @@ func (uc *ShopUseCase) GetInfo(ctx context.Context, userId int) (entity.Response
 	const op = "ShopUseCase.GetInfo"
 	var res entity.ResponseInfo
 
-	err := uc.cache.Get(ctx, fmt.Sprintf("%d", userId)).Scan(&res)
-	if err != nil && !errors.Is(err, redis.Nil) {
-		return res, fmt.Errorf("%s: %w", op, err)
+	key := uc.infoKey(userId)
+
+	// ошибка кэша не должна ломать запрос, в этом случае просто идём в базу
+	err := uc.cache.Get(ctx, key, &res)
+	if err == nil {
+		return res, nil
 	}
 
-	// Coins
-	user, err := uc.repo.GetUserById(ctx, userId)
+	// баланс, инвентарь, история, покупки и заказы приходят одним batch-запросом,
+	// покупок и заказов берём на одну больше, чтобы понять, есть ли следующая страница.
+	// История переводов ограничена первой страницей, дальше её читают через /api/coinHistory
+	res, err = uc.repo.TakeInfo(ctx, userId, defaultPageLimit+1)
 	if err != nil {
 		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	res.Coins = user.Coins
+	sent, received := res.CoinHistory.Sent.SentItems, res.CoinHistory.Received.ReceivedItems
+	res.CoinHistory.Sent.SentItems = sent[:min(len(sent), defaultPageLimit)]
+	res.CoinHistory.Received.ReceivedItems = received[:min(len(received), defaultPageLimit)]
 
-	itemsUser, err := uc.repo.GetItemUser(ctx, userId)
-	if err != nil {
-		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
-	}
+	res.Purchases = makePage(res.Purchases.Items, defaultPageLimit, func(p entity.Purchase) int { return p.Id })
+	res.Orders = makePage(res.Orders.Items, defaultPageLimit, func(o entity.Order) int { return o.Id })
 
-	for i, _ := range itemsUser.Items {
-		name, err := uc.repo.GetItemById(ctx, itemsUser.Items[i].ItemId)
-		if err != nil {
-			return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
-		}
+	uc.cache.Set(context.WithoutCancel(ctx), key, &res, uc.infoTTL)
+
+	return res, nil
+}
 
-		itemsUser.Items[i].Type = name
+// infoKey ключ закэшированного ответа GetInfo пользователя
+func (uc *ShopUseCase) infoKey(userId int) string {
+	return fmt.Sprintf("%s:info:%d", uc.cacheNamespace, userId)
+}
+
+// invalidateInfo удаляет из кэша ответы GetInfo пользователей, чьи данные изменились.
+// Ключи удаляются после коммита, ошибку не возвращаем: изменения уже сохранены, а устаревшая запись проживёт не дольше infoTTL.
+// Если вызов идёт внутри Idempotent, коммит ещё впереди, и удаление откладывается до него через AfterCommit
+func (uc *ShopUseCase) invalidateInfo(ctx context.Context, userIds ...int) {
+	keys := make([]string, 0, len(userIds))
+	for _, id := range userIds {
+		keys = append(keys, uc.infoKey(id))
 	}
 
-	res.Inventory = itemsUser
+	AfterCommit(ctx, func() {
+		uc.cache.Delete(context.WithoutCancel(ctx), keys...)
+	})
+}
+
+// GetPurchases возвращает страницу истории покупок от новых к старым, начиная с покупок с id < before
+func (uc *ShopUseCase) GetPurchases(ctx context.Context, userId, before, limit int) (entity.PurchasePage, error) {
+	const op = "ShopUseCase.GetPurchases"
+
+	limit = pageLimit(limit)
 
-	bothDir, err := uc.repo.TakeRecords(ctx, userId)
+	// запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	purchases, err := uc.repo.TakePurchases(ctx, userId, before, limit+1)
 	if err != nil {
-		return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
+		return entity.PurchasePage{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var sentItems []entity.SentItem
-	var receivedItems []entity.ReceivedItem
+	return makePage(purchases, limit, func(p entity.Purchase) int { return p.Id }), nil
+}
 
-	for _, item := range bothDir {
-		var s entity.SentItem
-		var r entity.ReceivedItem
+// GetSentHistory возвращает страницу исходящих переводов от новых к старым, начиная с переводов с id < before
+func (uc *ShopUseCase) GetSentHistory(ctx context.Context, userId, before, limit int) (entity.Page[entity.SentItem], error) {
+	const op = "ShopUseCase.GetSentHistory"
 
-		if item.FromUser == userId {
-			u, err := uc.repo.GetUserById(ctx, item.ToUser)
-			if err != nil {
-				return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
-			}
+	limit = pageLimit(limit)
 
-			r.Amount = item.Amount
-			r.FromUser = u.Username
+	items, err := uc.repo.TakeSentRecords(ctx, userId, before, limit+1)
+	if err != nil {
+		return entity.Page[entity.SentItem]{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-			receivedItems = append(receivedItems, r)
-		} else {
-			u, err := uc.repo.GetUserById(ctx, item.FromUser)
-			if err != nil {
-				return entity.ResponseInfo{}, fmt.Errorf("%s: %w", op, err)
-			}
+	return makePage(items, limit, func(i entity.SentItem) int { return i.Id }), nil
+}
 
-			s.Amount = item.Amount
-			s.ToUser = u.Username
+// GetReceivedHistory возвращает страницу входящих переводов от новых к старым, начиная с переводов с id < before
+func (uc *ShopUseCase) GetReceivedHistory(ctx context.Context, userId, before, limit int) (entity.Page[entity.ReceivedItem], error) {
+	const op = "ShopUseCase.GetReceivedHistory"
 
-			sentItems = append(sentItems, s)
-		}
+	limit = pageLimit(limit)
+
+	items, err := uc.repo.TakeReceivedRecords(ctx, userId, before, limit+1)
+	if err != nil {
+		return entity.Page[entity.ReceivedItem]{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	coinHistory := entity.CoinHistory{
-		Received: entity.Received{ReceivedItems: receivedItems},
-		Sent:     entity.Sent{SentItems: sentItems},
+	return makePage(items, limit, func(i entity.ReceivedItem) int { return i.Id }), nil
+}
+
+// makePage обрезает items, полученные с запасом в одну запись, до limit и выставляет курсор следующей страницы
+func makePage[T any](items []T, limit int, id func(T) int) entity.Page[T] {
+	var page entity.Page[T]
+	if len(items) > limit {
+		items = items[:limit]
+		page.NextBefore = id(items[limit-1])
 	}
 
-	res.CoinHistory = coinHistory
+	page.Items = items
+
+	return page
+}
 
-	uc.cache.Set(context.Background(), fmt.Sprintf("%d", userId), res, 1*time.Minute)
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageLimit
+	}
 
-	return res, nil
+	return min(limit, maxPageLimit)
 }
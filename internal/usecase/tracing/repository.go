@@ -0,0 +1,409 @@
+package tracing
+
+import (
+	"context"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/telemetry"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// Repository пишет спан на каждый вызов usecase.IShopRepository, сами SQL-запросы внутри него
+// трассирует репозиторий
+type Repository struct {
+	usecase.IShopRepository
+	tracer trace.Tracer
+}
+
+func NewRepository(r usecase.IShopRepository, tp trace.TracerProvider) *Repository {
+	return &Repository{IShopRepository: r, tracer: tp.Tracer(instrumentationName)}
+}
+
+func (r *Repository) start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, name)
+}
+
+func (r *Repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, span := r.start(ctx, "ShopRepository.WithTx")
+	err := r.IShopRepository.WithTx(ctx, fn)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) SaveUser(ctx context.Context, username string, passhash []byte) (int, error) {
+	ctx, span := r.start(ctx, "ShopRepository.SaveUser")
+	res, err := r.IShopRepository.SaveUser(ctx, username, passhash)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) FindUser(ctx context.Context, username string) (entity.User, error) {
+	ctx, span := r.start(ctx, "ShopRepository.FindUser")
+	res, err := r.IShopRepository.FindUser(ctx, username)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) BuyItem(ctx context.Context, userId, itemId, quantity int) error {
+	ctx, span := r.start(ctx, "ShopRepository.BuyItem")
+	err := r.IShopRepository.BuyItem(ctx, userId, itemId, quantity)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) GetItemByName(ctx context.Context, itemId string) (entity.Item, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetItemByName")
+	res, err := r.IShopRepository.GetItemByName(ctx, itemId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) GetUserById(ctx context.Context, userId int) (entity.User, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetUserById")
+	res, err := r.IShopRepository.GetUserById(ctx, userId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) GetUserByIdForUpdate(ctx context.Context, userId int) (entity.User, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetUserByIdForUpdate")
+	res, err := r.IShopRepository.GetUserByIdForUpdate(ctx, userId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) SetUserRole(ctx context.Context, username, role string) error {
+	ctx, span := r.start(ctx, "ShopRepository.SetUserRole")
+	err := r.IShopRepository.SetUserRole(ctx, username, role)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) SaveGrant(ctx context.Context, grant entity.Grant) (entity.Grant, error) {
+	ctx, span := r.start(ctx, "ShopRepository.SaveGrant")
+	res, err := r.IShopRepository.SaveGrant(ctx, grant)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) ClaimAllowanceRun(ctx context.Context, period string) (bool, error) {
+	ctx, span := r.start(ctx, "ShopRepository.ClaimAllowanceRun")
+	res, err := r.IShopRepository.ClaimAllowanceRun(ctx, period)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) GrantAll(ctx context.Context, amount int, reason string) ([]int, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GrantAll")
+	res, err := r.IShopRepository.GrantAll(ctx, amount, reason)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) PostEntry(ctx context.Context, entry entity.JournalEntry) (int, error) {
+	ctx, span := r.start(ctx, "ShopRepository.PostEntry")
+	res, err := r.IShopRepository.PostEntry(ctx, entry)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) Reconcile(ctx context.Context) (entity.Reconciliation, error) {
+	ctx, span := r.start(ctx, "ShopRepository.Reconcile")
+	res, err := r.IShopRepository.Reconcile(ctx)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeGiveCoins(ctx context.Context, userId, amount int) error {
+	ctx, span := r.start(ctx, "ShopRepository.TakeGiveCoins")
+	err := r.IShopRepository.TakeGiveCoins(ctx, userId, amount)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) TakeCoins(ctx context.Context, userId, amount int) error {
+	ctx, span := r.start(ctx, "ShopRepository.TakeCoins")
+	err := r.IShopRepository.TakeCoins(ctx, userId, amount)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) MakeRecord(ctx context.Context, fromUserId, toUserId, amount int) (int, error) {
+	ctx, span := r.start(ctx, "ShopRepository.MakeRecord")
+	res, err := r.IShopRepository.MakeRecord(ctx, fromUserId, toUserId, amount)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeSentRecords(ctx context.Context, userId, before, limit int) ([]entity.SentItem, error) {
+	ctx, span := r.start(ctx, "ShopRepository.TakeSentRecords")
+	res, err := r.IShopRepository.TakeSentRecords(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeReceivedRecords(ctx context.Context, userId, before, limit int) ([]entity.ReceivedItem, error) {
+	ctx, span := r.start(ctx, "ShopRepository.TakeReceivedRecords")
+	res, err := r.IShopRepository.TakeReceivedRecords(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) MakePurchase(ctx context.Context, userId, itemId, price, quantity int) (int, error) {
+	ctx, span := r.start(ctx, "ShopRepository.MakePurchase")
+	res, err := r.IShopRepository.MakePurchase(ctx, userId, itemId, price, quantity)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeStock(ctx context.Context, itemId, quantity int) (entity.Item, error) {
+	ctx, span := r.start(ctx, "ShopRepository.TakeStock")
+	res, err := r.IShopRepository.TakeStock(ctx, itemId, quantity)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) PurchasedQuantity(ctx context.Context, userId, itemId int) (int, error) {
+	ctx, span := r.start(ctx, "ShopRepository.PurchasedQuantity")
+	res, err := r.IShopRepository.PurchasedQuantity(ctx, userId, itemId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) ReturnStock(ctx context.Context, itemId, quantity int) error {
+	ctx, span := r.start(ctx, "ShopRepository.ReturnStock")
+	err := r.IShopRepository.ReturnStock(ctx, itemId, quantity)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) GetPurchaseForUpdate(ctx context.Context, purchaseId int) (entity.Purchase, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetPurchaseForUpdate")
+	res, err := r.IShopRepository.GetPurchaseForUpdate(ctx, purchaseId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) MarkRefunded(ctx context.Context, purchaseId, quantity int) error {
+	ctx, span := r.start(ctx, "ShopRepository.MarkRefunded")
+	err := r.IShopRepository.MarkRefunded(ctx, purchaseId, quantity)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) TakeInventory(ctx context.Context, userId, itemId, quantity int) error {
+	ctx, span := r.start(ctx, "ShopRepository.TakeInventory")
+	err := r.IShopRepository.TakeInventory(ctx, userId, itemId, quantity)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) SaveRefund(ctx context.Context, refund entity.Refund) (entity.Refund, error) {
+	ctx, span := r.start(ctx, "ShopRepository.SaveRefund")
+	res, err := r.IShopRepository.SaveRefund(ctx, refund)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) TakePurchases(ctx context.Context, userId, before, limit int) ([]entity.Purchase, error) {
+	ctx, span := r.start(ctx, "ShopRepository.TakePurchases")
+	res, err := r.IShopRepository.TakePurchases(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) GetCart(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetCart")
+	res, err := r.IShopRepository.GetCart(ctx, userId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) GetCartForUpdate(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetCartForUpdate")
+	res, err := r.IShopRepository.GetCartForUpdate(ctx, userId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) AddToCart(ctx context.Context, userId, itemId, quantity int) (int, error) {
+	ctx, span := r.start(ctx, "ShopRepository.AddToCart")
+	res, err := r.IShopRepository.AddToCart(ctx, userId, itemId, quantity)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) RemoveFromCart(ctx context.Context, userId int, itemName string) error {
+	ctx, span := r.start(ctx, "ShopRepository.RemoveFromCart")
+	err := r.IShopRepository.RemoveFromCart(ctx, userId, itemName)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) ClearCart(ctx context.Context, userId int) error {
+	ctx, span := r.start(ctx, "ShopRepository.ClearCart")
+	err := r.IShopRepository.ClearCart(ctx, userId)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) CreateOrder(ctx context.Context, userId, total int) (entity.Order, error) {
+	ctx, span := r.start(ctx, "ShopRepository.CreateOrder")
+	res, err := r.IShopRepository.CreateOrder(ctx, userId, total)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) SaveOrderLines(ctx context.Context, userId, orderId int, lines []entity.OrderLine) error {
+	ctx, span := r.start(ctx, "ShopRepository.SaveOrderLines")
+	err := r.IShopRepository.SaveOrderLines(ctx, userId, orderId, lines)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) TakeOrders(ctx context.Context, userId, before, limit int) ([]entity.Order, error) {
+	ctx, span := r.start(ctx, "ShopRepository.TakeOrders")
+	res, err := r.IShopRepository.TakeOrders(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) TakeInfo(ctx context.Context, userId, pageLimit int) (entity.ResponseInfo, error) {
+	ctx, span := r.start(ctx, "ShopRepository.TakeInfo")
+	res, err := r.IShopRepository.TakeInfo(ctx, userId, pageLimit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error) {
+	ctx, span := r.start(ctx, "ShopRepository.ListItems")
+	res, err := r.IShopRepository.ListItems(ctx, includeInactive)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) GetItem(ctx context.Context, itemId int) (entity.Item, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetItem")
+	res, err := r.IShopRepository.GetItem(ctx, itemId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) CreateItem(ctx context.Context, item entity.Item) (entity.Item, error) {
+	ctx, span := r.start(ctx, "ShopRepository.CreateItem")
+	res, err := r.IShopRepository.CreateItem(ctx, item)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error) {
+	ctx, span := r.start(ctx, "ShopRepository.UpdateItem")
+	res, err := r.IShopRepository.UpdateItem(ctx, itemId, req)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) DeleteItem(ctx context.Context, itemId int) error {
+	ctx, span := r.start(ctx, "ShopRepository.DeleteItem")
+	err := r.IShopRepository.DeleteItem(ctx, itemId)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	ctx, span := r.start(ctx, "ShopRepository.SaveRefreshToken")
+	err := r.IShopRepository.SaveRefreshToken(ctx, token)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) GetRefreshTokenForUpdate(ctx context.Context, hash []byte) (entity.RefreshToken, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetRefreshTokenForUpdate")
+	res, err := r.IShopRepository.GetRefreshTokenForUpdate(ctx, hash)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) RevokeRefreshToken(ctx context.Context, tokenId int) error {
+	ctx, span := r.start(ctx, "ShopRepository.RevokeRefreshToken")
+	err := r.IShopRepository.RevokeRefreshToken(ctx, tokenId)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	ctx, span := r.start(ctx, "ShopRepository.RevokeRefreshTokenFamily")
+	err := r.IShopRepository.RevokeRefreshTokenFamily(ctx, familyId)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (r *Repository) ClaimIdempotencyKey(ctx context.Context, userId int, key string, requestHash []byte, ttl time.Duration) (bool, error) {
+	ctx, span := r.start(ctx, "ShopRepository.ClaimIdempotencyKey")
+	res, err := r.IShopRepository.ClaimIdempotencyKey(ctx, userId, key, requestHash, ttl)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) GetIdempotentResponse(ctx context.Context, userId int, key string) (entity.IdempotentResponse, error) {
+	ctx, span := r.start(ctx, "ShopRepository.GetIdempotentResponse")
+	res, err := r.IShopRepository.GetIdempotentResponse(ctx, userId, key)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (r *Repository) SaveIdempotentResponse(ctx context.Context, userId int, key string, res entity.IdempotentResponse) error {
+	ctx, span := r.start(ctx, "ShopRepository.SaveIdempotentResponse")
+	err := r.IShopRepository.SaveIdempotentResponse(ctx, userId, key, res)
+	telemetry.End(span, err)
+
+	return err
+}
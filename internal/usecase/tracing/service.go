@@ -0,0 +1,234 @@
+package tracing
+
+import (
+	"context"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// Service пишет спан на каждый вызов usecase.IShopService. Спаны репозитория и запросов к postgres
+// и redis, сделанные внутри вызова, становятся его дочерними
+type Service struct {
+	usecase.IShopService
+	tracer trace.Tracer
+}
+
+func NewService(s usecase.IShopService, tp trace.TracerProvider) *Service {
+	return &Service{IShopService: s, tracer: tp.Tracer(instrumentationName)}
+}
+
+func (s *Service) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func (s *Service) Login(ctx context.Context, username, password string) (entity.AuthResponse, error) {
+	ctx, span := s.start(ctx, "ShopService.Login")
+	res, err := s.IShopService.Login(ctx, username, password)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) Register(ctx context.Context, username, password string) (entity.AuthResponse, error) {
+	ctx, span := s.start(ctx, "ShopService.Register")
+	res, err := s.IShopService.Register(ctx, username, password)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (entity.AuthResponse, error) {
+	ctx, span := s.start(ctx, "ShopService.Refresh")
+	res, err := s.IShopService.Refresh(ctx, refreshToken)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) Logout(ctx context.Context, userId int, accessToken, refreshToken string) error {
+	ctx, span := s.start(ctx, "ShopService.Logout", attribute.Int("user.id", userId))
+	err := s.IShopService.Logout(ctx, userId, accessToken, refreshToken)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (s *Service) Idempotent(ctx context.Context, userId int, key string, requestHash []byte, fn func(ctx context.Context) (entity.IdempotentResponse, error)) (res entity.IdempotentResponse, replayed bool, err error) {
+	ctx, span := s.start(ctx, "ShopService.Idempotent", attribute.Int("user.id", userId))
+	res, replayed, err = s.IShopService.Idempotent(ctx, userId, key, requestHash, fn)
+	telemetry.End(span, err)
+
+	return res, replayed, err
+}
+
+func (s *Service) BuyItem(ctx context.Context, userId int, itemName string, quantity int) error {
+	ctx, span := s.start(ctx, "ShopService.BuyItem", attribute.Int("user.id", userId))
+	err := s.IShopService.BuyItem(ctx, userId, itemName, quantity)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (s *Service) SendCoins(ctx context.Context, toUserName string, fromUserId, amount int) error {
+	ctx, span := s.start(ctx, "ShopService.SendCoins")
+	err := s.IShopService.SendCoins(ctx, toUserName, fromUserId, amount)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (s *Service) GetInfo(ctx context.Context, userId int) (entity.ResponseInfo, error) {
+	ctx, span := s.start(ctx, "ShopService.GetInfo", attribute.Int("user.id", userId))
+	res, err := s.IShopService.GetInfo(ctx, userId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) GetPurchases(ctx context.Context, userId, before, limit int) (entity.PurchasePage, error) {
+	ctx, span := s.start(ctx, "ShopService.GetPurchases", attribute.Int("user.id", userId))
+	res, err := s.IShopService.GetPurchases(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) GetCart(ctx context.Context, userId int) (entity.Cart, error) {
+	ctx, span := s.start(ctx, "ShopService.GetCart", attribute.Int("user.id", userId))
+	res, err := s.IShopService.GetCart(ctx, userId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) AddToCart(ctx context.Context, userId int, itemName string, quantity int) (entity.Cart, error) {
+	ctx, span := s.start(ctx, "ShopService.AddToCart", attribute.Int("user.id", userId))
+	res, err := s.IShopService.AddToCart(ctx, userId, itemName, quantity)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) RemoveFromCart(ctx context.Context, userId int, itemName string) error {
+	ctx, span := s.start(ctx, "ShopService.RemoveFromCart", attribute.Int("user.id", userId))
+	err := s.IShopService.RemoveFromCart(ctx, userId, itemName)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (s *Service) Checkout(ctx context.Context, userId int) (entity.Order, error) {
+	ctx, span := s.start(ctx, "ShopService.Checkout", attribute.Int("user.id", userId))
+	res, err := s.IShopService.Checkout(ctx, userId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) GetOrders(ctx context.Context, userId, before, limit int) (entity.OrderPage, error) {
+	ctx, span := s.start(ctx, "ShopService.GetOrders", attribute.Int("user.id", userId))
+	res, err := s.IShopService.GetOrders(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) RefundPurchase(ctx context.Context, by entity.Principal, purchaseId int, req entity.RefundRequest) (entity.Refund, error) {
+	ctx, span := s.start(ctx, "ShopService.RefundPurchase")
+	res, err := s.IShopService.RefundPurchase(ctx, by, purchaseId, req)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) GetSentHistory(ctx context.Context, userId, before, limit int) (entity.Page[entity.SentItem], error) {
+	ctx, span := s.start(ctx, "ShopService.GetSentHistory", attribute.Int("user.id", userId))
+	res, err := s.IShopService.GetSentHistory(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) GetReceivedHistory(ctx context.Context, userId, before, limit int) (entity.Page[entity.ReceivedItem], error) {
+	ctx, span := s.start(ctx, "ShopService.GetReceivedHistory", attribute.Int("user.id", userId))
+	res, err := s.IShopService.GetReceivedHistory(ctx, userId, before, limit)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) ListItems(ctx context.Context, includeInactive bool) ([]entity.Item, error) {
+	ctx, span := s.start(ctx, "ShopService.ListItems")
+	res, err := s.IShopService.ListItems(ctx, includeInactive)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) GetItem(ctx context.Context, itemId int) (entity.Item, error) {
+	ctx, span := s.start(ctx, "ShopService.GetItem")
+	res, err := s.IShopService.GetItem(ctx, itemId)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) CreateItem(ctx context.Context, req entity.ItemRequest) (entity.Item, error) {
+	ctx, span := s.start(ctx, "ShopService.CreateItem")
+	res, err := s.IShopService.CreateItem(ctx, req)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) UpdateItem(ctx context.Context, itemId int, req entity.ItemRequest) (entity.Item, error) {
+	ctx, span := s.start(ctx, "ShopService.UpdateItem")
+	res, err := s.IShopService.UpdateItem(ctx, itemId, req)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) DeleteItem(ctx context.Context, itemId int) error {
+	ctx, span := s.start(ctx, "ShopService.DeleteItem")
+	err := s.IShopService.DeleteItem(ctx, itemId)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (s *Service) SetRole(ctx context.Context, username, role string) error {
+	ctx, span := s.start(ctx, "ShopService.SetRole")
+	err := s.IShopService.SetRole(ctx, username, role)
+	telemetry.End(span, err)
+
+	return err
+}
+
+func (s *Service) GrantCoins(ctx context.Context, grantedBy int, req entity.GrantRequest) ([]entity.Grant, error) {
+	ctx, span := s.start(ctx, "ShopService.GrantCoins")
+	res, err := s.IShopService.GrantCoins(ctx, grantedBy, req)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) RunAllowance(ctx context.Context, allowance entity.Allowance, now time.Time) (int, error) {
+	ctx, span := s.start(ctx, "ShopService.RunAllowance")
+	res, err := s.IShopService.RunAllowance(ctx, allowance, now)
+	telemetry.End(span, err)
+
+	return res, err
+}
+
+func (s *Service) Reconcile(ctx context.Context) (entity.Reconciliation, error) {
+	ctx, span := s.start(ctx, "ShopService.Reconcile")
+	res, err := s.IShopService.Reconcile(ctx)
+	telemetry.End(span, err)
+
+	return res, err
+}
@@ -0,0 +1,5 @@
+package tracing
+
+// instrumentationName имя трассировщика декораторов. Ошибки usecase вроде ErrNoCoins тоже помечают
+// спан неуспешным: по ним видно, на каком шаге запрос был отклонён
+const instrumentationName = "github.com/k1v4/avito_shop/internal/usecase"
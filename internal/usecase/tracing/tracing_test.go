@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"github.com/k1v4/avito_shop/internal/entity"
+	"github.com/k1v4/avito_shop/internal/usecase"
+	"github.com/k1v4/avito_shop/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func newRecorder() (*tracetest.SpanRecorder, trace.TracerProvider) {
+	sr := tracetest.NewSpanRecorder()
+
+	return sr, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+}
+
+func TestService(t *testing.T) {
+	sr, tp := newRecorder()
+
+	s := mocks.NewIShopService(t)
+	svc := NewService(s, tp)
+
+	// вызов получает контекст со спаном декоратора
+	inSpan := mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanContextFromContext(ctx).IsValid()
+	})
+	s.On("BuyItem", inSpan, 1, "cup", 1).Return(nil).Once()
+	s.On("BuyItem", inSpan, 1, "cup", 100).Return(usecase.ErrNoCoins).Once()
+	s.On("ListItems", inSpan, false).Return([]entity.Item{{Id: 1}}, nil).Once()
+
+	ctx := context.Background()
+
+	assert.NoError(t, svc.BuyItem(ctx, 1, "cup", 1))
+	assert.ErrorIs(t, svc.BuyItem(ctx, 1, "cup", 100), usecase.ErrNoCoins)
+
+	items, err := svc.ListItems(ctx, false)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	spans := sr.Ended()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "ShopService.BuyItem", spans[0].Name())
+		assert.Contains(t, spans[0].Attributes(), attribute.Int("user.id", 1))
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+		assert.Equal(t, "not enough coins", spans[1].Status().Description)
+
+		assert.Equal(t, "ShopService.ListItems", spans[2].Name())
+		assert.Empty(t, spans[2].Attributes())
+	}
+}
+
+func TestRepository(t *testing.T) {
+	sr, tp := newRecorder()
+
+	r := mocks.NewIShopRepository(t)
+	repo := NewRepository(r, tp)
+
+	r.On("TakeCoins", mock.Anything, 1, 10).Return(nil).Once()
+	r.On("WithTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}).Once()
+
+	// спан вызова репозитория - дочерний для спана, открытого выше, в том числе внутри транзакции
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		return repo.TakeCoins(ctx, 1, 10)
+	})
+	parent.End()
+	assert.NoError(t, err)
+
+	spans := sr.Ended()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "ShopRepository.TakeCoins", spans[0].Name())
+		assert.Equal(t, "ShopRepository.WithTx", spans[1].Name())
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent().SpanID())
+		assert.Equal(t, parent.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	}
+}
@@ -3,15 +3,22 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/k1v4/avito_shop/internal/entity"
 	"github.com/k1v4/avito_shop/internal/usecase/mocks"
-	"github.com/redis/go-redis/v9"
+	"github.com/k1v4/avito_shop/pkg/jwtPkg"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+var testTokens, _ = jwtPkg.NewManager(jwtPkg.JWTConfig{Algorithm: jwtPkg.AlgorithmHS256, Secret: "secret"})
+
 func TestLogin_Register(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -74,28 +81,52 @@ func TestLogin_Register(t *testing.T) {
 			}
 
 			mockRepo := new(mocks.IShopRepository)
-			mockCache := new(redis.Client)
-			uc := NewShopUseCase(mockRepo, mockCache)
+			mockCache := new(mocks.Cache)
+			uc := NewShopUseCase(mockRepo, mockCache, testTokens)
 
 			mockRepo.
 				On("FindUser", mock.Anything, tc.username).
 				Return(tc.mockUser, tc.mockErr)
 
 			if errors.Is(tc.mockErr, ErrNoUser) {
+				mockRepo.
+					On("WithTx", mock.Anything, mock.Anything).
+					Return(runInTx)
 				mockRepo.
 					On("SaveUser", mock.Anything, tc.username, mock.Anything).
 					Return(1, nil)
+				mockRepo.
+					On("GetUserById", mock.Anything, 1).
+					Return(entity.User{Id: 1, Username: tc.username, Coins: 1000}, nil)
+				mockRepo.
+					On("PostEntry", mock.Anything, entity.JournalEntry{
+						Kind:        entity.EntryOpening,
+						ReferenceId: 1,
+						Description: "opening balance",
+						Postings: []entity.Posting{
+							{Account: entity.IssuanceAccount, Amount: -1000},
+							{Account: entity.WalletAccount(1), Amount: 1000},
+						},
+					}).
+					Return(1, nil)
 			}
 
-			token, err := uc.Login(context.Background(), tc.username, tc.password)
+			mockRepo.
+				On("SaveRefreshToken", mock.Anything, mock.MatchedBy(func(token entity.RefreshToken) bool {
+					return len(token.Hash) != 0 && len(token.FamilyId) != 0
+				})).
+				Return(nil).
+				Maybe()
+
+			tokens, err := uc.Login(context.Background(), tc.username, tc.password)
 
 			if (err != nil) != tc.wantErr {
 				t.Errorf("Login() error = %v, wantErr %v", err, tc.wantErr)
 				return
 			}
 
-			if len(tc.wantToken) != 0 && len(strings.TrimSpace(token)) == 0 {
-				t.Errorf("Login() token = %v, want not nill", token)
+			if len(tc.wantToken) != 0 && (len(strings.TrimSpace(tokens.Token)) == 0 || len(tokens.RefreshToken) == 0) {
+				t.Errorf("Login() tokens = %v, want not nill", tokens)
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -117,7 +148,7 @@ func TestBuyItem(t *testing.T) {
 			name:     "success",
 			userId:   1,
 			itemName: "item1",
-			mockItem: entity.Item{Id: 1, Name: "item1", Price: 100},
+			mockItem: entity.Item{Id: 1, Name: "item1", Price: 100, Active: true},
 			mockUser: entity.User{Id: 1, Coins: 200},
 			mockErr:  nil,
 			wantErr:  false,
@@ -126,7 +157,7 @@ func TestBuyItem(t *testing.T) {
 			name:     "not_enough_coins",
 			userId:   1,
 			itemName: "item1",
-			mockItem: entity.Item{Id: 1, Name: "item1", Price: 100},
+			mockItem: entity.Item{Id: 1, Name: "item1", Price: 100, Active: true},
 			mockUser: entity.User{Id: 1, Coins: 50},
 			mockErr:  ErrNoCoins,
 			wantErr:  true,
@@ -136,104 +167,1834 @@ func TestBuyItem(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRepo := new(mocks.IShopRepository)
-			mockCache := new(redis.Client)
-			uc := NewShopUseCase(mockRepo, mockCache)
+			mockCache := new(mocks.Cache)
+			uc := NewShopUseCase(mockRepo, mockCache, testTokens)
 
 			mockRepo.
 				On("GetItemByName", mock.Anything, tc.itemName).
 				Return(tc.mockItem, nil)
 			mockRepo.
-				On("GetUserById", mock.Anything, tc.userId).
-				Return(tc.mockUser, nil)
+				On("WithTx", mock.Anything, mock.Anything).
+				Return(runInTx)
+			mockRepo.
+				On("TakeStock", mock.Anything, tc.mockItem.Id, 1).
+				Return(tc.mockItem, nil)
+			mockRepo.
+				On("TakeCoins", mock.Anything, tc.userId, tc.mockItem.Price).
+				Return(tc.mockErr)
 			if tc.mockErr == nil {
 				mockRepo.
 					On("BuyItem", mock.Anything, tc.userId, tc.mockItem.Id, 1).
 					Return(nil)
 
 				mockRepo.
-					On("TakeGiveCoins", mock.Anything, tc.userId, -tc.mockItem.Price).
+					On("MakePurchase", mock.Anything, tc.userId, tc.mockItem.Id, tc.mockItem.Price, 1).
+					Return(7, nil)
+
+				mockRepo.
+					On("PostEntry", mock.Anything, entity.JournalEntry{
+						Kind:        entity.EntryPurchase,
+						ReferenceId: 7,
+						Postings: []entity.Posting{
+							{Account: entity.WalletAccount(tc.userId), Amount: -tc.mockItem.Price},
+							{Account: entity.RevenueAccount, Amount: tc.mockItem.Price},
+						},
+					}).
+					Return(1, nil)
+
+				mockCache.
+					On("Delete", mock.Anything, fmt.Sprintf("avito_shop:info:%d", tc.userId)).
 					Return(nil)
 			}
 
-			err := uc.BuyItem(context.Background(), tc.userId, tc.itemName)
+			err := uc.BuyItem(context.Background(), tc.userId, tc.itemName, 1)
 
 			if (err != nil) != tc.wantErr {
 				t.Errorf("BuyItem() error = %v, wantErr %v", err, tc.wantErr)
 			}
 
+			if tc.mockErr != nil && !errors.Is(err, tc.mockErr) {
+				t.Errorf("BuyItem() error = %v, want %v", err, tc.mockErr)
+			}
+
 			mockRepo.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
 		})
 	}
 }
 
-func TestSendCoins(t *testing.T) {
+func TestBuyItem_Inactive(t *testing.T) {
+	mockRepo := new(mocks.IShopRepository)
+	uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+	mockRepo.
+		On("GetItemByName", mock.Anything, "item1").
+		Return(entity.Item{Id: 1, Name: "item1", Price: 100, Active: false}, nil)
+
+	err := uc.BuyItem(context.Background(), 1, "item1", 1)
+	if !errors.Is(err, ErrItemInactive) {
+		t.Errorf("BuyItem() error = %v, want %v", err, ErrItemInactive)
+	}
+
+	// монеты не списываются
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBuyItem_Limits(t *testing.T) {
+	stock, limit := 10, 2
+	hoody := entity.Item{Id: 6, Name: "pink-hoody", Price: 500, Active: true, Stock: &stock, PerUserLimit: &limit}
+
+	t.Run("quantity", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		mockRepo.On("GetItemByName", mock.Anything, "pink-hoody").Return(hoody, nil)
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("TakeStock", mock.Anything, 6, 2).Return(hoody, nil)
+		mockRepo.On("PurchasedQuantity", mock.Anything, 1, 6).Return(0, nil)
+		mockRepo.On("TakeCoins", mock.Anything, 1, 1000).Return(nil)
+		mockRepo.On("BuyItem", mock.Anything, 1, 6, 2).Return(nil)
+		mockRepo.On("MakePurchase", mock.Anything, 1, 6, 500, 2).Return(8, nil)
+		mockRepo.On("PostEntry", mock.Anything, mock.MatchedBy(func(e entity.JournalEntry) bool {
+			return e.Kind == entity.EntryPurchase && e.ReferenceId == 8 && e.Postings[1].Amount == 1000
+		})).Return(1, nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:1").Return(nil)
+
+		if err := uc.BuyItem(context.Background(), 1, "pink-hoody", 2); err != nil {
+			t.Fatalf("BuyItem() unexpected error = %v", err)
+		}
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("out_of_stock", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("GetItemByName", mock.Anything, "pink-hoody").Return(hoody, nil)
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("TakeStock", mock.Anything, 6, 1).Return(entity.Item{}, ErrOutOfStock)
+
+		err := uc.BuyItem(context.Background(), 1, "pink-hoody", 1)
+		if !errors.Is(err, ErrOutOfStock) {
+			t.Errorf("BuyItem() error = %v, want %v", err, ErrOutOfStock)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("limit_reached", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("GetItemByName", mock.Anything, "pink-hoody").Return(hoody, nil)
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("TakeStock", mock.Anything, 6, 1).Return(hoody, nil)
+		mockRepo.On("PurchasedQuantity", mock.Anything, 1, 6).Return(2, nil)
+
+		err := uc.BuyItem(context.Background(), 1, "pink-hoody", 1)
+		if !errors.Is(err, ErrPurchaseLimit) {
+			t.Errorf("BuyItem() error = %v, want %v", err, ErrPurchaseLimit)
+		}
+
+		// монеты не списываются
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("price_changed", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		// админ поднял цену между чтением товара и покупкой, списывается цена из заблокированной строки
+		repriced := hoody
+		repriced.Price = 700
+
+		mockRepo.On("GetItemByName", mock.Anything, "pink-hoody").Return(hoody, nil)
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("TakeStock", mock.Anything, 6, 1).Return(repriced, nil)
+		mockRepo.On("PurchasedQuantity", mock.Anything, 1, 6).Return(0, nil)
+		mockRepo.On("TakeCoins", mock.Anything, 1, 700).Return(nil)
+		mockRepo.On("BuyItem", mock.Anything, 1, 6, 1).Return(nil)
+		mockRepo.On("MakePurchase", mock.Anything, 1, 6, 700, 1).Return(8, nil)
+		mockRepo.On("PostEntry", mock.Anything, mock.MatchedBy(func(e entity.JournalEntry) bool {
+			return e.Kind == entity.EntryPurchase && e.ReferenceId == 8 && e.Postings[1].Amount == 700
+		})).Return(1, nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:1").Return(nil)
+
+		if err := uc.BuyItem(context.Background(), 1, "pink-hoody", 1); err != nil {
+			t.Fatalf("BuyItem() unexpected error = %v", err)
+		}
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("deactivated", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		// товар сняли с продажи после чтения, под блокировкой это видно
+		mockRepo.On("GetItemByName", mock.Anything, "pink-hoody").Return(hoody, nil)
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("TakeStock", mock.Anything, 6, 1).Return(entity.Item{}, ErrItemInactive)
+
+		err := uc.BuyItem(context.Background(), 1, "pink-hoody", 1)
+		if !errors.Is(err, ErrItemInactive) {
+			t.Errorf("BuyItem() error = %v, want %v", err, ErrItemInactive)
+		}
+
+		// монеты не списываются
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid_quantity", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		for _, quantity := range []int{0, -1, maxPurchaseQuantity + 1} {
+			err := uc.BuyItem(context.Background(), 1, "pink-hoody", quantity)
+			if !errors.Is(err, ErrInvalidQuantity) {
+				t.Errorf("BuyItem(%d) error = %v, want %v", quantity, err, ErrInvalidQuantity)
+			}
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAddToCart(t *testing.T) {
+	cup := entity.Item{Id: 2, Name: "cup", Price: 20, Active: true}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("GetItemByName", mock.Anything, "cup").Return(cup, nil)
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("AddToCart", mock.Anything, 1, 2, 3).Return(3, nil)
+		mockRepo.On("GetCart", mock.Anything, 1).Return([]entity.CartItem{
+			{ItemId: 2, Type: "cup", Price: 20, Quantity: 3, Available: true},
+			{ItemId: 4, Type: "pen", Price: 10, Quantity: 1, Available: true},
+		}, nil)
+
+		cart, err := uc.AddToCart(context.Background(), 1, "cup", 3)
+		assert.NoError(t, err)
+		assert.Len(t, cart.Items, 2)
+		assert.Equal(t, 70, cart.Total)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("too_many", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("GetItemByName", mock.Anything, "cup").Return(cup, nil)
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("AddToCart", mock.Anything, 1, 2, 50).Return(maxPurchaseQuantity+10, nil)
+
+		_, err := uc.AddToCart(context.Background(), 1, "cup", 50)
+		if !errors.Is(err, ErrInvalidQuantity) {
+			t.Errorf("AddToCart() error = %v, want %v", err, ErrInvalidQuantity)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("inactive", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("GetItemByName", mock.Anything, "cup").Return(entity.Item{Id: 2, Name: "cup", Price: 20}, nil)
+
+		_, err := uc.AddToCart(context.Background(), 1, "cup", 1)
+		if !errors.Is(err, ErrItemInactive) {
+			t.Errorf("AddToCart() error = %v, want %v", err, ErrItemInactive)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestCheckout(t *testing.T) {
+	limit := 5
+	cart := []entity.CartItem{
+		{ItemId: 2, Type: "cup", Price: 20, Quantity: 3, Available: true},
+		{ItemId: 4, Type: "pen", Price: 10, Quantity: 1, Available: true, PerUserLimit: &limit},
+	}
+	cup := entity.Item{Id: 2, Name: "cup", Price: 20, Active: true}
+	pen := entity.Item{Id: 4, Name: "pen", Price: 10, Active: true, PerUserLimit: &limit}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		lines := []entity.OrderLine{
+			{ItemId: 2, Type: "cup", Price: 20, Quantity: 3},
+			{ItemId: 4, Type: "pen", Price: 10, Quantity: 1},
+		}
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("GetCartForUpdate", mock.Anything, 1).Return(cart, nil)
+		mockRepo.On("TakeStock", mock.Anything, 2, 3).Return(cup, nil)
+		mockRepo.On("TakeStock", mock.Anything, 4, 1).Return(pen, nil)
+		mockRepo.On("PurchasedQuantity", mock.Anything, 1, 4).Return(4, nil)
+		// весь заказ оплачивается одним списанием
+		mockRepo.On("TakeCoins", mock.Anything, 1, 70).Return(nil).Once()
+		mockRepo.On("BuyItem", mock.Anything, 1, 2, 3).Return(nil)
+		mockRepo.On("BuyItem", mock.Anything, 1, 4, 1).Return(nil)
+		mockRepo.On("CreateOrder", mock.Anything, 1, 70).Return(entity.Order{Id: 9, Total: 70}, nil)
+		mockRepo.On("SaveOrderLines", mock.Anything, 1, 9, lines).Return(nil)
+		mockRepo.On("PostEntry", mock.Anything, entity.JournalEntry{
+			Kind:        entity.EntryOrder,
+			ReferenceId: 9,
+			Postings: []entity.Posting{
+				{Account: entity.WalletAccount(1), Amount: -70},
+				{Account: entity.RevenueAccount, Amount: 70},
+			},
+		}).Return(1, nil)
+		mockRepo.On("ClearCart", mock.Anything, 1).Return(nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:1").Return(nil)
+
+		order, err := uc.Checkout(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Order{Id: 9, Total: 70, Lines: lines}, order)
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("price_changed", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		// цена в корзине устарела, заказ считается по цене из заблокированной строки
+		repriced := cup
+		repriced.Price = 25
+
+		lines := []entity.OrderLine{
+			{ItemId: 2, Type: "cup", Price: 25, Quantity: 3},
+			{ItemId: 4, Type: "pen", Price: 10, Quantity: 1},
+		}
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("GetCartForUpdate", mock.Anything, 1).Return(cart, nil)
+		mockRepo.On("TakeStock", mock.Anything, 2, 3).Return(repriced, nil)
+		mockRepo.On("TakeStock", mock.Anything, 4, 1).Return(pen, nil)
+		mockRepo.On("PurchasedQuantity", mock.Anything, 1, 4).Return(0, nil)
+		mockRepo.On("TakeCoins", mock.Anything, 1, 85).Return(nil).Once()
+		mockRepo.On("BuyItem", mock.Anything, 1, 2, 3).Return(nil)
+		mockRepo.On("BuyItem", mock.Anything, 1, 4, 1).Return(nil)
+		mockRepo.On("CreateOrder", mock.Anything, 1, 85).Return(entity.Order{Id: 9, Total: 85}, nil)
+		mockRepo.On("SaveOrderLines", mock.Anything, 1, 9, lines).Return(nil)
+		mockRepo.On("PostEntry", mock.Anything, mock.MatchedBy(func(e entity.JournalEntry) bool {
+			return e.Kind == entity.EntryOrder && e.ReferenceId == 9 && e.Postings[1].Amount == 85
+		})).Return(1, nil)
+		mockRepo.On("ClearCart", mock.Anything, 1).Return(nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:1").Return(nil)
+
+		order, err := uc.Checkout(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Order{Id: 9, Total: 85, Lines: lines}, order)
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("GetCartForUpdate", mock.Anything, 1).Return([]entity.CartItem{}, nil)
+
+		_, err := uc.Checkout(context.Background(), 1)
+		if !errors.Is(err, ErrEmptyCart) {
+			t.Errorf("Checkout() error = %v, want %v", err, ErrEmptyCart)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
 	cases := []struct {
-		name       string
-		fromUserId int
-		toUserName string
-		amount     int
-		mockFrom   entity.User
-		mockTo     entity.User
-		mockErr    error
-		wantErr    bool
+		name    string
+		mock    func(m *mocks.IShopRepository)
+		wantErr error
 	}{
 		{
-			name:       "success",
-			fromUserId: 1,
-			toUserName: "user2",
-			amount:     100,
-			mockFrom:   entity.User{Id: 1, Coins: 200},
-			mockTo:     entity.User{Id: 2, Username: "user2"},
-			mockErr:    nil,
-			wantErr:    false,
+			name: "unavailable",
+			mock: func(m *mocks.IShopRepository) {
+				// в корзине товар ещё доступен, но под блокировкой он уже снят с продажи
+				m.On("GetCartForUpdate", mock.Anything, 1).Return(cart, nil)
+				m.On("TakeStock", mock.Anything, 2, 3).Return(entity.Item{}, ErrItemInactive)
+			},
+			wantErr: ErrItemInactive,
 		},
 		{
-			name:       "not_enough_coins",
-			fromUserId: 1,
-			toUserName: "user2",
-			amount:     300,
-			mockFrom:   entity.User{Id: 1, Coins: 200},
-			mockTo:     entity.User{Id: 2, Username: "user2"},
-			mockErr:    ErrNoCoins,
-			wantErr:    true,
+			name: "deleted",
+			mock: func(m *mocks.IShopRepository) {
+				m.On("GetCartForUpdate", mock.Anything, 1).Return(cart, nil)
+				m.On("TakeStock", mock.Anything, 2, 3).Return(entity.Item{}, ErrNoItem)
+			},
+			wantErr: ErrItemInactive,
+		},
+		{
+			name: "out_of_stock",
+			mock: func(m *mocks.IShopRepository) {
+				m.On("GetCartForUpdate", mock.Anything, 1).Return(cart, nil)
+				m.On("TakeStock", mock.Anything, 2, 3).Return(cup, nil)
+				m.On("TakeStock", mock.Anything, 4, 1).Return(entity.Item{}, ErrOutOfStock)
+			},
+			wantErr: ErrOutOfStock,
+		},
+		{
+			name: "limit_reached",
+			mock: func(m *mocks.IShopRepository) {
+				m.On("GetCartForUpdate", mock.Anything, 1).Return(cart, nil)
+				m.On("TakeStock", mock.Anything, 2, 3).Return(cup, nil)
+				m.On("TakeStock", mock.Anything, 4, 1).Return(pen, nil)
+				m.On("PurchasedQuantity", mock.Anything, 1, 4).Return(5, nil)
+			},
+			wantErr: ErrPurchaseLimit,
+		},
+		{
+			name: "no_coins",
+			mock: func(m *mocks.IShopRepository) {
+				m.On("GetCartForUpdate", mock.Anything, 1).Return(cart, nil)
+				m.On("TakeStock", mock.Anything, 2, 3).Return(cup, nil)
+				m.On("TakeStock", mock.Anything, 4, 1).Return(pen, nil)
+				m.On("PurchasedQuantity", mock.Anything, 1, 4).Return(0, nil)
+				m.On("TakeCoins", mock.Anything, 1, 70).Return(ErrNoCoins)
+			},
+			wantErr: ErrNoCoins,
 		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRepo := new(mocks.IShopRepository)
-			mockCache := new(redis.Client)
-			uc := NewShopUseCase(mockRepo, mockCache)
+			uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
 
-			mockRepo.
-				On("FindUser", mock.Anything, tc.toUserName).
-				Return(tc.mockTo, nil)
+			mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+			tc.mock(mockRepo)
 
-			mockRepo.
-				On("GetUserById", mock.Anything, tc.fromUserId).
-				Return(tc.mockFrom, nil)
+			_, err := uc.Checkout(context.Background(), 1)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Checkout() error = %v, want %v", err, tc.wantErr)
+			}
 
-			if tc.mockErr == nil {
-				mockRepo.
-					On("TakeGiveCoins", mock.Anything, tc.mockTo.Id, tc.amount).
-					Return(nil)
+			// заказ не создаётся, корзина остаётся, кэш не сбрасывается
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "ClearCart", mock.Anything, mock.Anything)
+		})
+	}
+}
 
-				mockRepo.
-					On("TakeGiveCoins", mock.Anything, tc.fromUserId, -tc.amount).
-					Return(nil)
+func TestGetOrders(t *testing.T) {
+	mockRepo := new(mocks.IShopRepository)
+	uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+	mockRepo.
+		On("TakeOrders", mock.Anything, 1, 10, 3).
+		Return([]entity.Order{{Id: 9}, {Id: 8}, {Id: 7}}, nil)
+
+	page, err := uc.GetOrders(context.Background(), 1, 10, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderPage{Items: []entity.Order{{Id: 9}, {Id: 8}}, NextBefore: 8}, page)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefundPurchase(t *testing.T) {
+	employee := entity.Principal{Id: 1, Roles: []string{entity.RoleEmployee}}
+	admin := entity.Principal{Id: 7, Roles: []string{entity.RoleAdmin}}
+
+	purchase := func(createdAt time.Time, refunded int) entity.Purchase {
+		return entity.Purchase{Id: 5, UserId: 1, ItemId: 2, Type: "cup", Price: 20, Quantity: 3, Refunded: refunded, CreatedAt: createdAt}
+	}
+
+	t.Run("partial_by_owner", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens, RefundWindow(time.Hour))
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("GetPurchaseForUpdate", mock.Anything, 5).Return(purchase(time.Now().Add(-time.Minute), 1), nil)
+		mockRepo.On("MarkRefunded", mock.Anything, 5, 2).Return(nil)
+		mockRepo.On("ReturnStock", mock.Anything, 2, 2).Return(nil)
+		mockRepo.On("TakeGiveCoins", mock.Anything, 1, 40).Return(nil)
+		mockRepo.On("TakeInventory", mock.Anything, 1, 2, 2).Return(nil)
+		mockRepo.On("SaveRefund", mock.Anything, entity.Refund{
+			PurchaseId: 5, UserId: 1, Type: "cup", Quantity: 2, Amount: 40, Reason: "wrong size", RefundedBy: 1,
+		}).Return(entity.Refund{Id: 3, PurchaseId: 5, UserId: 1, Type: "cup", Quantity: 2, Amount: 40, Reason: "wrong size", RefundedBy: 1}, nil)
+		mockRepo.On("PostEntry", mock.Anything, entity.JournalEntry{
+			Kind:        entity.EntryRefund,
+			ReferenceId: 3,
+			Description: "wrong size",
+			Postings: []entity.Posting{
+				{Account: entity.RevenueAccount, Amount: -40},
+				{Account: entity.WalletAccount(1), Amount: 40},
+			},
+		}).Return(1, nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:1").Return(nil)
+
+		refund, err := uc.RefundPurchase(context.Background(), employee, 5, entity.RefundRequest{Reason: " wrong size "})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, refund.Id)
+		assert.Equal(t, 40, refund.Amount)
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("admin_after_window", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens, RefundWindow(0))
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("GetPurchaseForUpdate", mock.Anything, 5).Return(purchase(time.Now().Add(-30*24*time.Hour), 0), nil)
+		mockRepo.On("MarkRefunded", mock.Anything, 5, 1).Return(nil)
+		mockRepo.On("ReturnStock", mock.Anything, 2, 1).Return(nil)
+		mockRepo.On("TakeGiveCoins", mock.Anything, 1, 20).Return(nil)
+		mockRepo.On("TakeInventory", mock.Anything, 1, 2, 1).Return(nil)
+		mockRepo.On("SaveRefund", mock.Anything, mock.MatchedBy(func(r entity.Refund) bool {
+			return r.RefundedBy == 7 && r.UserId == 1 && r.Quantity == 1
+		})).Return(entity.Refund{Id: 4, UserId: 1}, nil)
+		mockRepo.On("PostEntry", mock.Anything, mock.Anything).Return(1, nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:1").Return(nil)
+
+		_, err := uc.RefundPurchase(context.Background(), admin, 5, entity.RefundRequest{Quantity: 1, Reason: "mistake"})
+		assert.NoError(t, err)
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	cases := []struct {
+		name     string
+		by       entity.Principal
+		purchase entity.Purchase
+		req      entity.RefundRequest
+		wantErr  error
+	}{
+		{
+			name:     "someone_elses",
+			by:       entity.Principal{Id: 2, Roles: []string{entity.RoleHR}},
+			purchase: purchase(time.Now(), 0),
+			req:      entity.RefundRequest{Reason: "mistake"},
+			wantErr:  ErrNoPurchase,
+		},
+		{
+			name:     "window_expired",
+			by:       employee,
+			purchase: purchase(time.Now().Add(-2*time.Hour), 0),
+			req:      entity.RefundRequest{Reason: "mistake"},
+			wantErr:  ErrRefundWindowExpired,
+		},
+		{
+			name:     "already_refunded",
+			by:       admin,
+			purchase: purchase(time.Now(), 3),
+			req:      entity.RefundRequest{Reason: "mistake"},
+			wantErr:  ErrAlreadyRefunded,
+		},
+		{
+			name:     "too_many",
+			by:       employee,
+			purchase: purchase(time.Now(), 2),
+			req:      entity.RefundRequest{Quantity: 2, Reason: "mistake"},
+			wantErr:  ErrInvalidQuantity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens, RefundWindow(time.Hour))
+
+			mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+			mockRepo.On("GetPurchaseForUpdate", mock.Anything, 5).Return(tc.purchase, nil)
+
+			_, err := uc.RefundPurchase(context.Background(), tc.by, 5, tc.req)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("RefundPurchase() error = %v, want %v", err, tc.wantErr)
+			}
+
+			// ничего не возвращается
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("not_in_inventory", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens, RefundWindow(time.Hour))
+
+		// пользователь уже отдал товар, транзакция откатывается, кэш не сбрасывается
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("GetPurchaseForUpdate", mock.Anything, 5).Return(purchase(time.Now(), 0), nil)
+		mockRepo.On("MarkRefunded", mock.Anything, 5, 3).Return(nil)
+		mockRepo.On("ReturnStock", mock.Anything, 2, 3).Return(nil)
+		mockRepo.On("TakeGiveCoins", mock.Anything, 1, 60).Return(nil)
+		mockRepo.On("TakeInventory", mock.Anything, 1, 2, 3).Return(ErrNotInInventory)
+
+		_, err := uc.RefundPurchase(context.Background(), employee, 5, entity.RefundRequest{Reason: "mistake"})
+		assert.Equal(t, ErrNotInInventory, err)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid_request", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		_, err := uc.RefundPurchase(context.Background(), employee, 5, entity.RefundRequest{Reason: "  "})
+		assert.ErrorIs(t, err, ErrInvalidRefund)
+
+		_, err = uc.RefundPurchase(context.Background(), employee, 5, entity.RefundRequest{Quantity: -1, Reason: "mistake"})
+		assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestCreateItem(t *testing.T) {
+	name, price := "  sticker  ", 5
+	badPrice, badName, badURL, okURL := 0, "a/b", "ftp://example.com/x.png", "https://example.com/x.png"
+	inactive := false
+	stock, limit, unlimited, badLimit := 50, 1, entity.Unlimited, 0
+
+	cases := []struct {
+		name     string
+		req      entity.ItemRequest
+		mockItem entity.Item
+		mockErr  error
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			req:      entity.ItemRequest{Name: &name, Price: &price, ImageURL: &okURL},
+			mockItem: entity.Item{Name: "sticker", Price: 5, ImageURL: okURL, Active: true},
+		},
+		{
+			name:     "inactive",
+			req:      entity.ItemRequest{Name: &name, Price: &price, Active: &inactive},
+			mockItem: entity.Item{Name: "sticker", Price: 5, Active: false},
+		},
+		{
+			name:     "limited",
+			req:      entity.ItemRequest{Name: &name, Price: &price, Stock: &stock, PerUserLimit: &limit},
+			mockItem: entity.Item{Name: "sticker", Price: 5, Active: true, Stock: &stock, PerUserLimit: &limit},
+		},
+		{
+			name:     "explicitly_unlimited",
+			req:      entity.ItemRequest{Name: &name, Price: &price, Stock: &unlimited, PerUserLimit: &unlimited},
+			mockItem: entity.Item{Name: "sticker", Price: 5, Active: true},
+		},
+		{
+			name:    "bad_limit",
+			req:     entity.ItemRequest{Name: &name, Price: &price, PerUserLimit: &badLimit},
+			wantErr: ErrInvalidItem,
+		},
+		{
+			name:    "no_price",
+			req:     entity.ItemRequest{Name: &name},
+			wantErr: ErrInvalidItem,
+		},
+		{
+			name:    "bad_price",
+			req:     entity.ItemRequest{Name: &name, Price: &badPrice},
+			wantErr: ErrInvalidItem,
+		},
+		{
+			name:    "slash_in_name",
+			req:     entity.ItemRequest{Name: &badName, Price: &price},
+			wantErr: ErrInvalidItem,
+		},
+		{
+			name:    "bad_image_url",
+			req:     entity.ItemRequest{Name: &name, Price: &price, ImageURL: &badURL},
+			wantErr: ErrInvalidItem,
+		},
+		{
+			name:     "name_taken",
+			req:      entity.ItemRequest{Name: &name, Price: &price},
+			mockItem: entity.Item{Name: "sticker", Price: 5, Active: true},
+			mockErr:  ErrItemExists,
+			wantErr:  ErrItemExists,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+			if tc.mockItem.Name != "" {
+				created := tc.mockItem
+				created.Id = 11
 
 				mockRepo.
-					On("MakeRecord", mock.Anything, tc.fromUserId, tc.mockTo.Id, tc.amount).
-					Return(nil)
+					On("CreateItem", mock.Anything, tc.mockItem).
+					Return(created, tc.mockErr)
 			}
 
-			err := uc.SendCoins(context.Background(), tc.toUserName, tc.fromUserId, tc.amount)
+			item, err := uc.CreateItem(context.Background(), tc.req)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("CreateItem() error = %v, want %v", err, tc.wantErr)
+			}
 
-			if (err != nil) != tc.wantErr {
-				t.Errorf("SendCoins() error = %v, wantErr %v", err, tc.wantErr)
+			if tc.wantErr == nil && item.Id != 11 {
+				t.Errorf("CreateItem() = %+v, want created item", item)
 			}
 
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
+
+func TestUpdateDeleteItem(t *testing.T) {
+	price, badPrice := 15, -1
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		req := entity.ItemRequest{Price: &price}
+		mockRepo.
+			On("UpdateItem", mock.Anything, 3, req).
+			Return(entity.Item{Id: 3, Name: "book", Price: 15, Active: true}, nil)
+
+		item, err := uc.UpdateItem(context.Background(), 3, req)
+		if err != nil {
+			t.Fatalf("UpdateItem() unexpected error = %v", err)
+		}
+
+		if item.Price != 15 {
+			t.Errorf("UpdateItem() price = %d, want 15", item.Price)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		_, err := uc.UpdateItem(context.Background(), 3, entity.ItemRequest{Price: &badPrice})
+		if !errors.Is(err, ErrInvalidItem) {
+			t.Errorf("UpdateItem() error = %v, want %v", err, ErrInvalidItem)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("delete_not_found", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.
+			On("DeleteItem", mock.Anything, 404).
+			Return(ErrNoItem)
+
+		err := uc.DeleteItem(context.Background(), 404)
+		if !errors.Is(err, ErrNoItem) {
+			t.Errorf("DeleteItem() error = %v, want %v", err, ErrNoItem)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestSetRole(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("SetUserRole", mock.Anything, "alice", entity.RoleHR).Return(nil)
+
+		if err := uc.SetRole(context.Background(), "alice", entity.RoleHR); err != nil {
+			t.Fatalf("SetRole() unexpected error = %v", err)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid_role", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		err := uc.SetRole(context.Background(), "alice", "root")
+		if !errors.Is(err, ErrInvalidRole) {
+			t.Errorf("SetRole() error = %v, want %v", err, ErrInvalidRole)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no_user", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("SetUserRole", mock.Anything, "bob", entity.RoleAdmin).Return(ErrNoUser)
+
+		err := uc.SetRole(context.Background(), "bob", entity.RoleAdmin)
+		if !errors.Is(err, ErrNoUser) {
+			t.Errorf("SetRole() error = %v, want %v", err, ErrNoUser)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestGrantCoins(t *testing.T) {
+	req := entity.GrantRequest{
+		Reason: " quarterly bonus ",
+		Grants: []entity.GrantLine{{ToUser: "alice", Amount: 100}, {ToUser: "bob", Amount: 50}},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("FindUser", mock.Anything, "alice").Return(entity.User{Id: 3, Username: "alice"}, nil)
+		mockRepo.On("FindUser", mock.Anything, "bob").Return(entity.User{Id: 4, Username: "bob"}, nil)
+		mockRepo.On("GetUserByIdForUpdate", mock.Anything, 3).Return(entity.User{Id: 3, Username: "alice"}, nil)
+		mockRepo.On("GetUserByIdForUpdate", mock.Anything, 4).Return(entity.User{Id: 4, Username: "bob"}, nil)
+		mockRepo.On("TakeGiveCoins", mock.Anything, 3, 100).Return(nil)
+		mockRepo.On("TakeGiveCoins", mock.Anything, 4, 50).Return(nil)
+		mockRepo.
+			On("SaveGrant", mock.Anything, entity.Grant{UserId: 3, ToUser: "alice", Amount: 100, Reason: "quarterly bonus", GrantedBy: 1}).
+			Return(entity.Grant{Id: 1, UserId: 3, ToUser: "alice", Amount: 100, Reason: "quarterly bonus", GrantedBy: 1}, nil)
+		mockRepo.
+			On("SaveGrant", mock.Anything, entity.Grant{UserId: 4, ToUser: "bob", Amount: 50, Reason: "quarterly bonus", GrantedBy: 1}).
+			Return(entity.Grant{Id: 2, UserId: 4, ToUser: "bob", Amount: 50, Reason: "quarterly bonus", GrantedBy: 1}, nil)
+		mockRepo.On("PostEntry", mock.Anything, issue(entity.EntryGrant, 1, 3, 100, "quarterly bonus")).Return(1, nil)
+		mockRepo.On("PostEntry", mock.Anything, issue(entity.EntryGrant, 2, 4, 50, "quarterly bonus")).Return(2, nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:3", "avito_shop:info:4").Return(nil)
+
+		grants, err := uc.GrantCoins(context.Background(), 1, req)
+		if err != nil {
+			t.Fatalf("GrantCoins() unexpected error = %v", err)
+		}
+
+		if len(grants) != 2 || grants[1].Id != 2 {
+			t.Errorf("GrantCoins() = %v, want 2 grants", grants)
+		}
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("merged_in_id_order", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		// bob назван дважды и раньше alice, но блокируются они по возрастанию id, а bob получает одно начисление
+		req := entity.GrantRequest{
+			Reason: "bonus",
+			Grants: []entity.GrantLine{{ToUser: "bob", Amount: 50}, {ToUser: "alice", Amount: 100}, {ToUser: "bob", Amount: 25}},
+		}
+
+		var locked []int
+		lock := func(args mock.Arguments) { locked = append(locked, args.Int(1)) }
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("FindUser", mock.Anything, "bob").Return(entity.User{Id: 4, Username: "bob"}, nil).Once()
+		mockRepo.On("FindUser", mock.Anything, "alice").Return(entity.User{Id: 3, Username: "alice"}, nil).Once()
+		mockRepo.On("GetUserByIdForUpdate", mock.Anything, 3).Return(entity.User{Id: 3}, nil).Run(lock)
+		mockRepo.On("GetUserByIdForUpdate", mock.Anything, 4).Return(entity.User{Id: 4}, nil).Run(lock)
+		mockRepo.On("TakeGiveCoins", mock.Anything, 3, 100).Return(nil)
+		mockRepo.On("TakeGiveCoins", mock.Anything, 4, 75).Return(nil).Once()
+		mockRepo.
+			On("SaveGrant", mock.Anything, entity.Grant{UserId: 3, ToUser: "alice", Amount: 100, Reason: "bonus", GrantedBy: 1}).
+			Return(entity.Grant{Id: 1, UserId: 3, ToUser: "alice", Amount: 100, Reason: "bonus", GrantedBy: 1}, nil)
+		mockRepo.
+			On("SaveGrant", mock.Anything, entity.Grant{UserId: 4, ToUser: "bob", Amount: 75, Reason: "bonus", GrantedBy: 1}).
+			Return(entity.Grant{Id: 2, UserId: 4, ToUser: "bob", Amount: 75, Reason: "bonus", GrantedBy: 1}, nil)
+		mockRepo.On("PostEntry", mock.Anything, issue(entity.EntryGrant, 1, 3, 100, "bonus")).Return(1, nil)
+		mockRepo.On("PostEntry", mock.Anything, issue(entity.EntryGrant, 2, 4, 75, "bonus")).Return(2, nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:3", "avito_shop:info:4").Return(nil)
+
+		grants, err := uc.GrantCoins(context.Background(), 1, req)
+		assert.NoError(t, err)
+		assert.Len(t, grants, 2)
+		assert.Equal(t, []int{3, 4}, locked)
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("unknown_user", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("FindUser", mock.Anything, "alice").Return(entity.User{}, ErrNoUser)
+
+		_, err := uc.GrantCoins(context.Background(), 1, req)
+		if !errors.Is(err, ErrNoUser) {
+			t.Errorf("GrantCoins() error = %v, want %v", err, ErrNoUser)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	invalid := []entity.GrantRequest{
+		{Reason: "  ", Grants: req.Grants},
+		{Reason: "bonus"},
+		{Reason: "bonus", Grants: []entity.GrantLine{{ToUser: "alice", Amount: 0}}},
+		{Reason: "bonus", Grants: []entity.GrantLine{{Amount: 10}}},
+	}
+	for i, bad := range invalid {
+		t.Run(fmt.Sprintf("invalid_%d", i), func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+			_, err := uc.GrantCoins(context.Background(), 1, bad)
+			if !errors.Is(err, ErrInvalidGrant) {
+				t.Errorf("GrantCoins() error = %v, want %v", err, ErrInvalidGrant)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunAllowance(t *testing.T) {
+	allowance := entity.Allowance{Period: entity.AllowanceMonthly, Amount: 100, Reason: "allowance"}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	t.Run("claimed", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("ClaimAllowanceRun", mock.Anything, "monthly:2026-10").Return(true, nil)
+		mockRepo.On("GrantAll", mock.Anything, 100, "allowance (monthly:2026-10)").Return([]int{1, 2}, nil)
+		mockRepo.On("PostEntry", mock.Anything, entity.JournalEntry{
+			Kind:        entity.EntryAllowance,
+			Description: "allowance (monthly:2026-10)",
+			Postings: []entity.Posting{
+				{Account: entity.IssuanceAccount, Amount: -200},
+				{Account: entity.WalletAccount(1), Amount: 100},
+				{Account: entity.WalletAccount(2), Amount: 100},
+			},
+		}).Return(1, nil)
+		mockCache.On("Delete", mock.Anything, "avito_shop:info:1", "avito_shop:info:2").Return(nil)
+
+		granted, err := uc.RunAllowance(context.Background(), allowance, now)
+		if err != nil {
+			t.Fatalf("RunAllowance() unexpected error = %v", err)
+		}
+
+		if granted != 2 {
+			t.Errorf("RunAllowance() = %d, want 2", granted)
+		}
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("already_done", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+		mockRepo.On("ClaimAllowanceRun", mock.Anything, "monthly:2026-10").Return(false, nil)
+
+		granted, err := uc.RunAllowance(context.Background(), allowance, now)
+		if err != nil || granted != 0 {
+			t.Errorf("RunAllowance() = %d, %v, want 0, nil", granted, err)
+		}
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("invalid_period", func(t *testing.T) {
+		uc := NewShopUseCase(new(mocks.IShopRepository), new(mocks.Cache), testTokens)
+
+		_, err := uc.RunAllowance(context.Background(), entity.Allowance{Period: "yearly", Amount: 100}, now)
+		if !errors.Is(err, ErrInvalidGrant) {
+			t.Errorf("RunAllowance() error = %v, want %v", err, ErrInvalidGrant)
+		}
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	mockRepo := new(mocks.IShopRepository)
+	uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+	report := entity.Reconciliation{Users: 2, Drifts: []entity.BalanceDrift{{UserId: 2, Username: "bob", Balance: 900, Ledger: 1000}}}
+	mockRepo.On("Reconcile", mock.Anything).Return(report, nil)
+
+	got, err := uc.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile() unexpected error = %v", err)
+	}
+
+	if got.OK() {
+		t.Errorf("Reconcile() = %v, want drift", got)
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPost_Unbalanced(t *testing.T) {
+	mockRepo := new(mocks.IShopRepository)
+	uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+	err := uc.post(context.Background(), entity.JournalEntry{
+		Kind:     entity.EntryTransfer,
+		Postings: []entity.Posting{{Account: entity.WalletAccount(1), Amount: -10}, {Account: entity.WalletAccount(2), Amount: 5}},
+	})
+	if !errors.Is(err, ErrUnbalancedEntry) {
+		t.Errorf("post() error = %v, want %v", err, ErrUnbalancedEntry)
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendCoins(t *testing.T) {
+	cases := []struct {
+		name       string
+		fromUserId int
+		toUserName string
+		amount     int
+		mockFrom   entity.User
+		mockTo     entity.User
+		mockErr    error
+		wantErr    bool
+	}{
+		{
+			name:       "success",
+			fromUserId: 1,
+			toUserName: "user2",
+			amount:     100,
+			mockFrom:   entity.User{Id: 1, Coins: 200},
+			mockTo:     entity.User{Id: 2, Username: "user2"},
+			mockErr:    nil,
+			wantErr:    false,
+		},
+		{
+			name:       "not_enough_coins",
+			fromUserId: 1,
+			toUserName: "user2",
+			amount:     300,
+			mockFrom:   entity.User{Id: 1, Coins: 200},
+			mockTo:     entity.User{Id: 2, Username: "user2"},
+			mockErr:    ErrNoCoins,
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			mockCache := new(mocks.Cache)
+			uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+			mockRepo.
+				On("FindUser", mock.Anything, tc.toUserName).
+				Return(tc.mockTo, nil)
+
+			mockRepo.
+				On("WithTx", mock.Anything, mock.Anything).
+				Return(runInTx)
+
+			mockRepo.
+				On("GetUserByIdForUpdate", mock.Anything, tc.fromUserId).
+				Return(tc.mockFrom, nil)
+
+			mockRepo.
+				On("GetUserByIdForUpdate", mock.Anything, tc.mockTo.Id).
+				Return(tc.mockTo, nil)
+
+			if tc.mockErr == nil {
+				mockRepo.
+					On("TakeGiveCoins", mock.Anything, tc.mockTo.Id, tc.amount).
+					Return(nil)
+
+				mockRepo.
+					On("TakeGiveCoins", mock.Anything, tc.fromUserId, -tc.amount).
+					Return(nil)
+
+				mockRepo.
+					On("MakeRecord", mock.Anything, tc.fromUserId, tc.mockTo.Id, tc.amount).
+					Return(9, nil)
+
+				mockRepo.
+					On("PostEntry", mock.Anything, entity.JournalEntry{
+						Kind:        entity.EntryTransfer,
+						ReferenceId: 9,
+						Postings: []entity.Posting{
+							{Account: entity.WalletAccount(tc.fromUserId), Amount: -tc.amount},
+							{Account: entity.WalletAccount(tc.mockTo.Id), Amount: tc.amount},
+						},
+					}).
+					Return(1, nil)
+
+				mockCache.
+					On("Delete", mock.Anything, fmt.Sprintf("avito_shop:info:%d", tc.fromUserId), fmt.Sprintf("avito_shop:info:%d", tc.mockTo.Id)).
+					Return(nil)
+			}
+
+			err := uc.SendCoins(context.Background(), tc.toUserName, tc.fromUserId, tc.amount)
+
+			if (err != nil) != tc.wantErr {
+				t.Errorf("SendCoins() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSendCoins_Rejected(t *testing.T) {
+	cases := []struct {
+		name     string
+		mockTo   entity.User
+		mockErr  error
+		wantErr  error
+		wantCode string
+	}{
+		{
+			name:     "self_transfer",
+			mockTo:   entity.User{Id: 1, Username: "user1"},
+			wantErr:  ErrSelfTransfer,
+			wantCode: CodeSelfTransfer,
+		},
+		{
+			name:     "no_user",
+			mockErr:  ErrNoUser,
+			wantErr:  ErrNoUser,
+			wantCode: CodeUserNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			uc := NewShopUseCase(mockRepo, new(mocks.Cache), testTokens)
+
+			mockRepo.
+				On("FindUser", mock.Anything, "user1").
+				Return(tc.mockTo, tc.mockErr)
+
+			err := uc.SendCoins(context.Background(), "user1", 1, 10)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("SendCoins() error = %v, want %v", err, tc.wantErr)
+			}
+
+			var e *Error
+			if !errors.As(err, &e) || e.Code != tc.wantCode {
+				t.Errorf("SendCoins() error code = %v, want %s", e, tc.wantCode)
+			}
+
+			// до блокировок и транзакции дело не доходит
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	err := fmt.Errorf("op: %w", ErrNoItem.WithMessage("item \"pen\" not found"))
+
+	if !errors.Is(err, ErrNoItem) {
+		t.Errorf("errors.Is(%v, ErrNoItem) = false", err)
+	}
+
+	if errors.Is(err, ErrNoUser) {
+		t.Errorf("errors.Is(%v, ErrNoUser) = true", err)
+	}
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("errors.As(%v) = false", err)
+	}
+
+	if e.Code != CodeItemNotFound || e.Message != `item "pen" not found` {
+		t.Errorf("unexpected error %+v", e)
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	info := entity.ResponseInfo{
+		Coins: 900,
+		Inventory: entity.Inventory{
+			Items: []entity.InventoryItem{{Type: "cup", Quantity: 2}},
+		},
+	}
+
+	t.Run("cache_hit", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens, CacheNamespace("test"))
+
+		mockCache.
+			On("Get", mock.Anything, "test:info:1", mock.Anything).
+			Run(func(args mock.Arguments) {
+				*args.Get(2).(*entity.ResponseInfo) = info
+			}).
+			Return(nil)
+
+		res, err := uc.GetInfo(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, info, res)
+
+		// при попадании в кэш база не трогается
+		mockRepo.AssertNotCalled(t, "TakeInfo", mock.Anything, mock.Anything, mock.Anything)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("cache_miss", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens, CacheNamespace("test"), InfoTTL(time.Hour))
+
+		mockRepo.
+			On("TakeInfo", mock.Anything, 1, defaultPageLimit+1).
+			Return(info, nil)
+
+		mockCache.
+			On("Get", mock.Anything, "test:info:1", mock.Anything).
+			Return(ErrCacheMiss)
+		mockCache.
+			On("Set", mock.Anything, "test:info:1", &info, time.Hour).
+			Return(nil)
+
+		res, err := uc.GetInfo(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, info, res)
+
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("history_first_page", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		// репозиторий отдаёт на один перевод больше страницы в каждую сторону, в ответ попадает только первая
+		full := entity.ResponseInfo{Coins: 900}
+		for i := defaultPageLimit + 1; i > 0; i-- {
+			full.CoinHistory.Sent.SentItems = append(full.CoinHistory.Sent.SentItems, entity.SentItem{Id: i})
+			full.CoinHistory.Received.ReceivedItems = append(full.CoinHistory.Received.ReceivedItems, entity.ReceivedItem{Id: i})
+		}
+
+		mockRepo.
+			On("TakeInfo", mock.Anything, 1, defaultPageLimit+1).
+			Return(full, nil)
+
+		mockCache.
+			On("Get", mock.Anything, mock.Anything, mock.Anything).
+			Return(ErrCacheMiss)
+		mockCache.
+			On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		res, err := uc.GetInfo(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Len(t, res.CoinHistory.Sent.SentItems, defaultPageLimit)
+		assert.Len(t, res.CoinHistory.Received.ReceivedItems, defaultPageLimit)
+		assert.Equal(t, defaultPageLimit+1, res.CoinHistory.Sent.SentItems[0].Id)
+		assert.Equal(t, 2, res.CoinHistory.Received.ReceivedItems[defaultPageLimit-1].Id)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("cache_error", func(t *testing.T) {
+		mockRepo := new(mocks.IShopRepository)
+		mockCache := new(mocks.Cache)
+		uc := NewShopUseCase(mockRepo, mockCache, testTokens)
+
+		mockRepo.
+			On("TakeInfo", mock.Anything, 1, defaultPageLimit+1).
+			Return(info, nil)
+
+		mockCache.
+			On("Get", mock.Anything, "avito_shop:info:1", mock.Anything).
+			Return(errors.New("connection refused"))
+		mockCache.
+			On("Set", mock.Anything, "avito_shop:info:1", mock.Anything, defaultInfoTTL).
+			Return(errors.New("connection refused"))
+
+		res, err := uc.GetInfo(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, info, res)
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetPurchases(t *testing.T) {
+	purchases := func(ids ...int) []entity.Purchase {
+		res := make([]entity.Purchase, 0, len(ids))
+		for _, id := range ids {
+			res = append(res, entity.Purchase{Id: id, Type: "cup", Price: 20, Quantity: 1})
+		}
+
+		return res
+	}
+
+	cases := []struct {
+		name          string
+		before        int
+		limit         int
+		repoLimit     int
+		mockPurchases []entity.Purchase
+		wantIds       []int
+		wantNext      int
+	}{
+		{
+			name:          "last_page",
+			before:        0,
+			limit:         3,
+			repoLimit:     4,
+			mockPurchases: purchases(5, 4),
+			wantIds:       []int{5, 4},
+			wantNext:      0,
+		},
+		{
+			name:          "has_next_page",
+			before:        10,
+			limit:         2,
+			repoLimit:     3,
+			mockPurchases: purchases(9, 8, 7),
+			wantIds:       []int{9, 8},
+			wantNext:      8,
+		},
+		{
+			name:          "default_limit",
+			limit:         0,
+			repoLimit:     defaultPageLimit + 1,
+			mockPurchases: purchases(1),
+			wantIds:       []int{1},
+		},
+		{
+			name:          "max_limit",
+			limit:         100500,
+			repoLimit:     maxPageLimit + 1,
+			mockPurchases: purchases(),
+			wantIds:       []int{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			uc := NewShopUseCase(mockRepo, nil, testTokens)
+
+			mockRepo.
+				On("TakePurchases", mock.Anything, 1, tc.before, tc.repoLimit).
+				Return(tc.mockPurchases, nil)
+
+			page, err := uc.GetPurchases(context.Background(), 1, tc.before, tc.limit)
+			if err != nil {
+				t.Fatalf("GetPurchases() error = %v", err)
+			}
+
+			gotIds := make([]int, 0, len(page.Items))
+			for _, p := range page.Items {
+				gotIds = append(gotIds, p.Id)
+			}
+
+			assert.Equal(t, tc.wantIds, gotIds)
+			assert.Equal(t, tc.wantNext, page.NextBefore)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	const refreshToken = "refresh"
+	hash := jwtPkg.HashRefreshToken(refreshToken)
+
+	cases := []struct {
+		name       string
+		mockToken  entity.RefreshToken
+		mockErr    error
+		wantErr    error
+		wantRotate bool
+		wantRevoke bool
+	}{
+		{
+			name:       "success",
+			mockToken:  entity.RefreshToken{Id: 10, UserId: 1, Hash: hash, FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour)},
+			wantRotate: true,
+		},
+		{
+			name:       "reused",
+			mockToken:  entity.RefreshToken{Id: 10, UserId: 1, Hash: hash, FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour), Revoked: true},
+			wantErr:    ErrRefreshTokenReused,
+			wantRevoke: true,
+		},
+		{
+			name:      "expired",
+			mockToken: entity.RefreshToken{Id: 10, UserId: 1, Hash: hash, FamilyId: "family", ExpiresAt: time.Now().Add(-time.Hour)},
+			wantErr:   ErrInvalidRefreshToken,
+		},
+		{
+			name:    "unknown",
+			mockErr: ErrNoRefreshToken,
+			wantErr: ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			uc := NewShopUseCase(mockRepo, nil, testTokens)
+
+			mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+			mockRepo.
+				On("GetRefreshTokenForUpdate", mock.Anything, hash).
+				Return(tc.mockToken, tc.mockErr)
+
+			if tc.wantRotate {
+				mockRepo.On("RevokeRefreshToken", mock.Anything, tc.mockToken.Id).Return(nil)
+				mockRepo.
+					On("GetUserById", mock.Anything, tc.mockToken.UserId).
+					Return(entity.User{Id: tc.mockToken.UserId, Username: "user1"}, nil)
+				// новый токен остаётся в том же семействе
+				mockRepo.
+					On("SaveRefreshToken", mock.Anything, mock.MatchedBy(func(token entity.RefreshToken) bool {
+						return token.FamilyId == tc.mockToken.FamilyId && token.UserId == tc.mockToken.UserId
+					})).
+					Return(nil)
+			}
+
+			if tc.wantRevoke {
+				mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, tc.mockToken.FamilyId).Return(nil)
+			}
+
+			tokens, err := uc.Refresh(context.Background(), refreshToken)
+			assert.ErrorIs(t, err, tc.wantErr)
+
+			if tc.wantErr == nil {
+				userId, err := testTokens.ValidateTokenAndGetUserId(context.Background(), tokens.Token)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.mockToken.UserId, userId)
+				assert.NotEmpty(t, tokens.RefreshToken)
+				assert.NotEqual(t, refreshToken, tokens.RefreshToken)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	denylist := &memDenylist{revoked: make(map[string]time.Duration)}
+
+	tokens, err := jwtPkg.NewManager(
+		jwtPkg.JWTConfig{Algorithm: jwtPkg.AlgorithmHS256, Secret: "secret"},
+		jwtPkg.WithDenylist(denylist),
+	)
+	assert.NoError(t, err)
+
+	accessToken, err := tokens.NewToken(jwtPkg.Claims{UserId: 1, Username: "user1"}, time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo := new(mocks.IShopRepository)
+	uc := NewShopUseCase(mockRepo, nil, tokens)
+
+	mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+	mockRepo.
+		On("GetRefreshTokenForUpdate", mock.Anything, jwtPkg.HashRefreshToken("own")).
+		Return(entity.RefreshToken{Id: 10, UserId: 1, FamilyId: "family"}, nil)
+	mockRepo.
+		On("GetRefreshTokenForUpdate", mock.Anything, jwtPkg.HashRefreshToken("foreign")).
+		Return(entity.RefreshToken{Id: 11, UserId: 2, FamilyId: "other"}, nil)
+	mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, "family").Return(nil).Once()
+
+	_, err = tokens.ValidateTokenAndGetUserId(ctx, accessToken)
+	assert.NoError(t, err)
+
+	assert.NoError(t, uc.Logout(ctx, 1, accessToken, "own"))
+	// чужой refresh-токен не отзывается
+	assert.NoError(t, uc.Logout(ctx, 1, accessToken, "foreign"))
+
+	_, err = tokens.ValidateTokenAndGetUserId(ctx, accessToken)
+	assert.ErrorIs(t, err, jwtPkg.ErrRevoked)
+
+	assert.Len(t, denylist.revoked, 1)
+	for _, ttl := range denylist.revoked {
+		assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotent(t *testing.T) {
+	hash := []byte("hash")
+	fnErr := errors.New("not enough coins")
+
+	cases := []struct {
+		name         string
+		claimed      bool
+		stored       entity.IdempotentResponse
+		fnErr        error
+		wantRes      entity.IdempotentResponse
+		wantReplayed bool
+		wantErr      error
+		wantFnCall   bool
+	}{
+		{
+			name:       "first_request",
+			claimed:    true,
+			wantRes:    entity.IdempotentResponse{RequestHash: hash, StatusCode: 200, Body: []byte("{}")},
+			wantFnCall: true,
+		},
+		{
+			name:         "replay",
+			stored:       entity.IdempotentResponse{RequestHash: hash, StatusCode: 200, Body: []byte("{}")},
+			wantRes:      entity.IdempotentResponse{RequestHash: hash, StatusCode: 200, Body: []byte("{}")},
+			wantReplayed: true,
+		},
+		{
+			name:    "key_reused",
+			stored:  entity.IdempotentResponse{RequestHash: []byte("other"), StatusCode: 200, Body: []byte("{}")},
+			wantErr: ErrIdempotencyKeyReused,
+		},
+		{
+			name:       "fn_error",
+			claimed:    true,
+			fnErr:      fnErr,
+			wantErr:    fnErr,
+			wantFnCall: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.IShopRepository)
+			mockCache := new(mocks.Cache)
+			uc := NewShopUseCase(mockRepo, mockCache, testTokens, IdempotencyTTL(time.Hour))
+
+			mockRepo.On("WithTx", mock.Anything, mock.Anything).Return(runInTx)
+			mockRepo.
+				On("ClaimIdempotencyKey", mock.Anything, 1, "key", hash, time.Hour).
+				Return(tc.claimed, nil)
+
+			if !tc.claimed {
+				mockRepo.On("GetIdempotentResponse", mock.Anything, 1, "key").Return(tc.stored, nil)
+			}
+
+			committed := tc.claimed && tc.fnErr == nil
+			if committed {
+				mockRepo.On("SaveIdempotentResponse", mock.Anything, 1, "key", tc.wantRes).Return(nil)
+				// ключ удаляется только после коммита, при откате кэш не трогается
+				mockCache.On("Delete", mock.Anything, "avito_shop:info:1").Return(nil).Once()
+			}
+
+			fnCalled, hookCalled := false, false
+			res, replayed, err := uc.Idempotent(context.Background(), 1, "key", hash,
+				func(ctx context.Context) (entity.IdempotentResponse, error) {
+					fnCalled = true
+					uc.invalidateInfo(ctx, 1)
+					AfterCommit(ctx, func() { hookCalled = true })
+
+					// до коммита отложенные действия не выполняются
+					assert.False(t, hookCalled)
+
+					return entity.IdempotentResponse{StatusCode: 200, Body: []byte("{}")}, tc.fnErr
+				})
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantRes, res)
+			assert.Equal(t, tc.wantReplayed, replayed)
+			assert.Equal(t, tc.wantFnCall, fnCalled)
+			assert.Equal(t, committed, hookCalled)
+			mockRepo.AssertExpectations(t)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+// memDenylist denylist в памяти, запоминает ttl, с которым отозван токен
+type memDenylist struct {
+	mu      sync.Mutex
+	revoked map[string]time.Duration
+}
+
+func (d *memDenylist) Revoke(_ context.Context, jti string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.revoked[jti] = ttl
+
+	return nil
+}
+
+func (d *memDenylist) IsRevoked(_ context.Context, jti string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, ok := d.revoked[jti]
+
+	return ok, nil
+}
+
+// runInTx подставляется в мок WithTx и просто выполняет переданную функцию
+func runInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// memTx набор строк, заблокированных одной транзакцией memShopRepo
+type memTx struct {
+	locked []*sync.Mutex
+}
+
+type memTxKey struct{}
+
+// memShopRepo хранит балансы в памяти и повторяет семантику SELECT ... FOR UPDATE:
+// строка остаётся заблокированной до конца транзакции. Не реализованные методы паникуют
+type memShopRepo struct {
+	IShopRepository
+
+	mu      sync.Mutex
+	locks   map[int]*sync.Mutex
+	users   map[int]entity.User
+	records int
+	wallets map[int]int
+}
+
+func newMemShopRepo(users ...entity.User) *memShopRepo {
+	r := &memShopRepo{
+		locks:   make(map[int]*sync.Mutex),
+		users:   make(map[int]entity.User),
+		wallets: make(map[int]int),
+	}
+
+	for _, u := range users {
+		r.locks[u.Id] = &sync.Mutex{}
+		r.users[u.Id] = u
+		r.wallets[u.Id] = u.Coins
+	}
+
+	return r
+}
+
+func (r *memShopRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx := &memTx{}
+	defer func() {
+		for _, l := range tx.locked {
+			l.Unlock()
+		}
+	}()
+
+	return fn(context.WithValue(ctx, memTxKey{}, tx))
+}
+
+func (r *memShopRepo) FindUser(_ context.Context, username string) (entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return entity.User{}, ErrNoUser
+}
+
+func (r *memShopRepo) GetUserByIdForUpdate(ctx context.Context, userId int) (entity.User, error) {
+	l, ok := r.locks[userId]
+	if !ok {
+		return entity.User{}, ErrNoUser
+	}
+
+	l.Lock()
+	tx := ctx.Value(memTxKey{}).(*memTx)
+	tx.locked = append(tx.locked, l)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.users[userId], nil
+}
+
+func (r *memShopRepo) TakeGiveCoins(_ context.Context, userId, amount int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.users[userId]
+	u.Coins += amount
+	r.users[userId] = u
+
+	return nil
+}
+
+func (r *memShopRepo) MakeRecord(_ context.Context, _, _, _ int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records++
+
+	return r.records, nil
+}
+
+func (r *memShopRepo) PostEntry(_ context.Context, entry entity.JournalEntry) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range entry.Postings {
+		r.wallets[p.Account.UserId] += p.Amount
+	}
+
+	return entry.ReferenceId, nil
+}
+
+func TestSendCoins_Concurrent(t *testing.T) {
+	const (
+		usersCount   = 5
+		startCoins   = 100
+		transfers    = 2000
+		transferSize = 7
+	)
+
+	users := make([]entity.User, 0, usersCount)
+	for i := 1; i <= usersCount; i++ {
+		users = append(users, entity.User{Id: i, Username: fmt.Sprintf("user%d", i), Coins: startCoins})
+	}
+
+	repo := newMemShopRepo(users...)
+	mockCache := new(mocks.Cache)
+	mockCache.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	uc := NewShopUseCase(repo, mockCache, testTokens)
+
+	var (
+		wg      sync.WaitGroup
+		success atomic.Int64
+	)
+
+	for i := 0; i < transfers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			from := i%usersCount + 1
+			to := (i*3+1)%usersCount + 1
+			if from == to {
+				to = from%usersCount + 1
+			}
+
+			err := uc.SendCoins(context.Background(), fmt.Sprintf("user%d", to), from, transferSize)
+			if err != nil {
+				if !errors.Is(err, ErrNoCoins) {
+					t.Errorf("SendCoins() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			success.Add(1)
+		}(i)
+	}
+
+	wg.Wait()
+
+	total := 0
+	for _, u := range repo.users {
+		if u.Coins < 0 {
+			t.Errorf("user %d has negative balance %d", u.Id, u.Coins)
+		}
+
+		total += u.Coins
+
+		if repo.wallets[u.Id] != u.Coins {
+			t.Errorf("user %d ledger balance = %d, balance = %d", u.Id, repo.wallets[u.Id], u.Coins)
+		}
+	}
+
+	if total != usersCount*startCoins {
+		t.Errorf("total coin supply = %d, want %d", total, usersCount*startCoins)
+	}
+
+	if int64(repo.records) != success.Load() {
+		t.Errorf("history records = %d, successful transfers = %d", repo.records, success.Load())
+	}
+}
+
+// benchRoundTrip имитирует задержку одного похода в базу
+const benchRoundTrip = 100 * time.Microsecond
+
+// latencyRepo отдаёт заранее подготовленные данные, считает запросы и на каждый тратит benchRoundTrip
+type latencyRepo struct {
+	IShopRepository
+
+	queries atomic.Int64
+	info    entity.ResponseInfo
+}
+
+func newLatencyRepo(itemsCount, recordsCount int) *latencyRepo {
+	r := &latencyRepo{info: entity.ResponseInfo{Coins: 1000}}
+
+	for i := 1; i <= itemsCount; i++ {
+		r.info.Inventory.Items = append(r.info.Inventory.Items,
+			entity.InventoryItem{ItemId: i, Type: fmt.Sprintf("item%d", i), Quantity: 1})
+	}
+
+	for i := 1; i <= recordsCount; i++ {
+		other := fmt.Sprintf("user%d", i%10+2)
+		if i%2 == 0 {
+			r.info.CoinHistory.Received.ReceivedItems = append(r.info.CoinHistory.Received.ReceivedItems,
+				entity.ReceivedItem{Id: i, FromUser: other, Amount: i})
+		} else {
+			r.info.CoinHistory.Sent.SentItems = append(r.info.CoinHistory.Sent.SentItems,
+				entity.SentItem{Id: i, ToUser: other, Amount: i})
+		}
+	}
+
+	return r
+}
+
+func (r *latencyRepo) TakeInfo(_ context.Context, _, _ int) (entity.ResponseInfo, error) {
+	r.queries.Add(1)
+	time.Sleep(benchRoundTrip)
+
+	return r.info, nil
+}
+
+func BenchmarkGetInfo(b *testing.B) {
+	const (
+		itemsCount   = 10
+		recordsCount = 200
+	)
+
+	ctx := context.Background()
+	repo := newLatencyRepo(itemsCount, recordsCount)
+
+	mockCache := new(mocks.Cache)
+	mockCache.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(ErrCacheMiss)
+	mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	uc := NewShopUseCase(repo, mockCache, testTokens)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := uc.GetInfo(ctx, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(repo.queries.Load())/float64(b.N), "queries/op")
+}
@@ -0,0 +1,334 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTable = "schema_migrations"
+
+	// defaultLockKey ключ pg_advisory_lock, под которым миграции выполняются на одной реплике за раз
+	defaultLockKey int64 = 0x617669746f5f6d67
+)
+
+var (
+	// ErrInvalidMigrations файлы миграций названы неверно, у версии нет up или версия повторяется
+	ErrInvalidMigrations = errors.New("invalid migrations")
+	// ErrUnknownVersion в базе применена версия, которой нет среди файлов: бинарник старее схемы
+	ErrUnknownVersion = errors.New("database has a migration version unknown to this binary")
+	// ErrNoDown у применённой миграции нет down-файла, откатить её нельзя
+	ErrNoDown = errors.New("migration has no down script")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration одна версия схемы
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status миграция и время её применения, AppliedAt = nil - миграция ещё не применена
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator применяет и откатывает миграции. Каждая миграция выполняется в своей транзакции вместе с отметкой
+// в таблице миграций, а весь запуск - под advisory lock, так что реплики, стартующие одновременно,
+// применяют миграции по очереди, и каждая применяется один раз
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+	table      string
+	lockKey    int64
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{
+		pool:       pool,
+		migrations: migrations,
+		table:      defaultTable,
+		lockKey:    defaultLockKey,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m, nil
+}
+
+// Load читает миграции из корня fsys и сортирует их по версии. Файлы, не похожие на миграции, пропускаются
+func Load(fsys fs.FS) ([]Migration, error) {
+	const op = "migrate.Load"
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		parts := fileName.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, entry.Name(), ErrInvalidMigrations)
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("%s: version %d has names %q and %q: %w", op, version, m.Name, parts[2], ErrInvalidMigrations)
+		}
+
+		if parts[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%s: version %d has no up script: %w", op, m.Version, ErrInvalidMigrations)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up применяет все ещё не применённые миграции и возвращает их
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	const op = "Migrator.Up"
+
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err = m.apply(ctx, conn, migration.Up,
+				"INSERT INTO "+m.table+"(version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return done, nil
+}
+
+// Down откатывает steps последних применённых миграций и возвращает их в порядке отката
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	const op = "Migrator.Down"
+
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, ErrNoDown)
+			}
+
+			err = m.apply(ctx, conn, migration.Down,
+				"DELETE FROM "+m.table+" WHERE version = $1", migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return done, nil
+}
+
+// Status возвращает все известные миграции с временем применения
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	const op = "Migrator.Status"
+
+	var statuses []Status
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]Status, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if at, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &at
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return statuses, nil
+}
+
+// locked выполняет fn на отдельном соединении под advisory lock. Блокировка сессионная, поэтому она
+// держится между транзакциями отдельных миграций и снимается и при обрыве соединения
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.lockKey)
+	if err != nil {
+		return err
+	}
+	// контекст мог быть отменён, а снять блокировку нужно в любом случае
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockKey)
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+m.table+` (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// applied возвращает применённые версии. Версия, которой нет среди файлов, - ошибка:
+// значит, базу уже обновил более новый бинарник, и откатывать или дополнять её этим нельзя
+func (m *Migrator) applied(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM "+m.table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int64]struct{}, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = struct{}{}
+	}
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := known[version]; !ok {
+			return nil, fmt.Errorf("version %d: %w", version, ErrUnknownVersion)
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// apply выполняет скрипт миграции и запись в таблицу миграций одной транзакцией
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	// после Commit откат ничего не делает
+	defer tx.Rollback(ctx)
+
+	// без аргументов pgx выполняет запрос простым протоколом, так что в скрипте может быть несколько команд
+	_, err = tx.Exec(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, record, args...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
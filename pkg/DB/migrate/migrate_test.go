@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"github.com/k1v4/avito_shop/db/migrations"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	file := func(body string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(body)}
+	}
+
+	cases := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int64
+		wantErr  error
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"0010_add_index.up.sql":   file("CREATE INDEX"),
+				"0002_seed.up.sql":        file("INSERT"),
+				"0002_seed.down.sql":      file("DELETE"),
+				"0001_init.up.sql":        file("CREATE TABLE"),
+				"0001_init.down.sql":      file("DROP TABLE"),
+				"README.md":               file("not a migration"),
+				"0003_Bad-Name.up.sql":    file("ignored"),
+				"nested/0004_skip.up.sql": file("ignored"),
+			},
+			versions: []int64{1, 2, 10},
+		},
+		{
+			name:     "empty",
+			fsys:     fstest.MapFS{},
+			versions: []int64{},
+		},
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{
+				"0001_init.down.sql": file("DROP TABLE"),
+			},
+			wantErr: ErrInvalidMigrations,
+		},
+		{
+			name: "version reused with another name",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":  file("CREATE TABLE"),
+				"0001_other.up.sql": file("CREATE TABLE"),
+			},
+			wantErr: ErrInvalidMigrations,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			loaded, err := Load(tc.fsys)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			versions := make([]int64, 0, len(loaded))
+			for _, m := range loaded {
+				versions = append(versions, m.Version)
+			}
+			assert.Equal(t, tc.versions, versions)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loaded)
+
+	// каждую встроенную миграцию можно откатить командой migrate down
+	for _, m := range loaded {
+		assert.NotEmpty(t, m.Down, "migration %d_%s has no down script", m.Version, m.Name)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	m := &Migrator{}
+
+	Table("app_migrations")(m)
+	LockKey(42)(m)
+
+	assert.Equal(t, "app_migrations", m.table)
+	assert.Equal(t, int64(42), m.lockKey)
+}
@@ -0,0 +1,17 @@
+package migrate
+
+type Option func(*Migrator)
+
+// Table задаёт таблицу, в которой отмечаются применённые миграции
+func Table(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// LockKey задаёт ключ advisory lock, нужен, если в одной базе миграции запускают несколько сервисов
+func LockKey(key int64) Option {
+	return func(m *Migrator) {
+		m.lockKey = key
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/k1v4/avito_shop/pkg/logger"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"time"
 )
@@ -17,6 +18,13 @@ type RedisConfig struct {
 	MaxRetries  int           `env:"REDIS_MAX_RETRIES" envDefault:"3"`
 	DialTimeout time.Duration `env:"REDIS_DIAL_TIMEOUT" envDefault:"5s"`
 	Timeout     time.Duration `env:"REDIS_TIMEOUT" envDefault:"5s"`
+	// Namespace префикс всех ключей сервиса, чтобы не пересекаться с другими сервисами в том же redis
+	Namespace string        `env:"REDIS_NAMESPACE" env-default:"avito_shop"`
+	InfoTTL   time.Duration `env:"REDIS_INFO_TTL" env-default:"1m"`
+	// CheckInterval как часто проверять соединение и переподключаться к redis
+	CheckInterval time.Duration `env:"REDIS_CHECK_INTERVAL" env-default:"5s"`
+	// FallbackSize размер LRU-кэша в памяти на время недоступности redis, 0 - работать без кэша
+	FallbackSize int `env:"REDIS_FALLBACK_SIZE" env-default:"10000"`
 }
 
 func NewClient(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
@@ -33,6 +41,13 @@ func NewClient(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
 
 	l := logger.GetLoggerFromContext(ctx)
 
+	// каждая команда redis пишет спан, провайдер берётся глобальный из pkg/telemetry
+	if err := redisotel.InstrumentTracing(db); err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("redis - NewClient - redisotel.InstrumentTracing: %w", err)
+	}
+
 	pong, err := db.Ping(ctx).Result()
 	if err != nil {
 		l.Error(ctx, fmt.Sprintf("failed to connect to redis server: %s\n", err.Error()))
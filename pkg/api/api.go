@@ -0,0 +1,4 @@
+// Package api содержит код, сгенерированный из protobuf-описаний в api/proto
+package api
+
+//go:generate protoc -I ../../api/proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative shop/v1/shop.proto
@@ -0,0 +1,20 @@
+package grpcserver
+
+import (
+	"net"
+	"time"
+)
+
+type Option func(*Server)
+
+func Port(port string) Option {
+	return func(s *Server) {
+		s.addr = net.JoinHostPort("", port)
+	}
+}
+
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
@@ -0,0 +1,58 @@
+package grpcserver
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPort(t *testing.T) {
+	s := &Server{}
+	Port("50052")(s)
+
+	expectedAddr := net.JoinHostPort("", "50052")
+	if s.addr != expectedAddr {
+		t.Errorf("expected server address to be %s, got %s", expectedAddr, s.addr)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	s := &Server{}
+	timeout := 10 * time.Second
+	ShutdownTimeout(timeout)(s)
+
+	if s.shutdownTimeout != timeout {
+		t.Errorf("expected shutdown timeout to be %v, got %v", timeout, s.shutdownTimeout)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+
+	s := New(grpc.NewServer(), Port("0"))
+
+	resp, err := s.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING, got %v", resp.Status)
+	}
+
+	if err = s.Shutdown(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err = s.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING, got %v", resp.Status)
+	}
+}
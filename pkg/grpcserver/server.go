@@ -0,0 +1,95 @@
+package grpcserver
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"net"
+	"time"
+)
+
+const (
+	_defaultAddr            = ":50051"
+	_defaultShutdownTimeout = 3 * time.Second
+)
+
+// Server -.
+type Server struct {
+	server          *grpc.Server
+	health          *health.Server
+	addr            string
+	notify          chan error
+	shutdownTimeout time.Duration
+}
+
+// New регистрирует на server сервисы health и reflection и начинает принимать соединения.
+// Сервисы приложения должны быть зарегистрированы на server до вызова New
+func New(server *grpc.Server, opts ...Option) *Server {
+	s := &Server{
+		server:          server,
+		health:          health.NewServer(),
+		addr:            _defaultAddr,
+		notify:          make(chan error, 1),
+		shutdownTimeout: _defaultShutdownTimeout,
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// статус отдаётся и для сервера целиком, и для каждого зарегистрированного сервиса
+	for name := range server.GetServiceInfo() {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	healthpb.RegisterHealthServer(server, s.health)
+	reflection.Register(server)
+
+	s.start()
+
+	return s
+}
+
+func (s *Server) start() {
+	go func() {
+		lis, err := net.Listen("tcp", s.addr)
+		if err != nil {
+			s.notify <- err
+			close(s.notify)
+
+			return
+		}
+
+		s.notify <- s.server.Serve(lis)
+		close(s.notify)
+	}()
+}
+
+func (s *Server) Notify() <-chan error {
+	return s.notify
+}
+
+// Shutdown переводит health в NOT_SERVING и ждёт завершения текущих вызовов.
+// Если они не укладываются в shutdownTimeout, соединения закрываются принудительно
+func (s *Server) Shutdown() error {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(s.shutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+	case <-timer.C:
+		s.server.Stop()
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTimeout = time.Second
+
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// Check проверяет одну зависимость, ошибка означает, что с ней сервис не может обслуживать запросы
+type Check func(ctx context.Context) error
+
+// DependencyStatus состояние одной зависимости в ответе /readyz
+type DependencyStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Latency время проверки в миллисекундах
+	Latency int64 `json:"latencyMs"`
+	// Optional без этой зависимости сервис работает, её падение не снимает готовность
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Report результат проверки готовности. Ready = false, если не прошла хоть одна обязательная проверка
+// или сервис останавливается
+type Report struct {
+	Ready        bool                        `json:"-"`
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+type namedCheck struct {
+	name     string
+	check    Check
+	optional bool
+}
+
+// Checker проверяет готовность сервиса по зависимостям. Проверки запускаются параллельно,
+// на каждую отводится timeout. После Drain сервис не готов, даже если все зависимости отвечают
+type Checker struct {
+	checks   []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func New(opts ...Option) *Checker {
+	c := &Checker{
+		timeout: defaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Drain переводит сервис в состояние "не готов", чтобы балансировщик перестал слать в него трафик до остановки
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Draining сообщает, что был вызван Drain
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Check проверяет все зависимости. Во время остановки зависимости всё равно проверяются,
+// чтобы в ответе было видно их состояние
+func (c *Checker) Check(ctx context.Context) Report {
+	statuses := make([]DependencyStatus, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, nc := range c.checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			statuses[i] = c.run(ctx, nc.check)
+			statuses[i].Optional = nc.optional
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Ready:        true,
+		Status:       StatusUp,
+		Dependencies: make(map[string]DependencyStatus, len(c.checks)),
+	}
+
+	for i, nc := range c.checks {
+		report.Dependencies[nc.name] = statuses[i]
+
+		if statuses[i].Status != StatusUp && !nc.optional {
+			report.Ready = false
+			report.Status = StatusDown
+		}
+	}
+
+	if c.Draining() {
+		report.Ready = false
+		report.Status = StatusDraining
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+
+	status := DependencyStatus{
+		Status:  StatusUp,
+		Latency: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+
+	return status
+}
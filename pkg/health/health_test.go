@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	ctx := context.Background()
+
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	cases := []struct {
+		name     string
+		opts     []Option
+		drain    bool
+		ready    bool
+		status   string
+		statuses map[string]string
+	}{
+		{
+			name:     "all up",
+			opts:     []Option{WithCheck("postgres", up), WithCheck("redis", up)},
+			ready:    true,
+			status:   StatusUp,
+			statuses: map[string]string{"postgres": StatusUp, "redis": StatusUp},
+		},
+		{
+			name:     "dependency down",
+			opts:     []Option{WithCheck("postgres", up), WithCheck("redis", down)},
+			status:   StatusDown,
+			statuses: map[string]string{"postgres": StatusUp, "redis": StatusDown},
+		},
+		{
+			name:     "optional dependency down",
+			opts:     []Option{WithCheck("postgres", up), WithOptionalCheck("redis", down)},
+			ready:    true,
+			status:   StatusUp,
+			statuses: map[string]string{"postgres": StatusUp, "redis": StatusDown},
+		},
+		{
+			name:     "dependency timeout",
+			opts:     []Option{WithCheck("postgres", hang), Timeout(10 * time.Millisecond)},
+			status:   StatusDown,
+			statuses: map[string]string{"postgres": StatusDown},
+		},
+		{
+			name:     "draining",
+			opts:     []Option{WithCheck("postgres", up)},
+			drain:    true,
+			status:   StatusDraining,
+			statuses: map[string]string{"postgres": StatusUp},
+		},
+		{
+			name:     "no checks",
+			ready:    true,
+			status:   StatusUp,
+			statuses: map[string]string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New(tc.opts...)
+			if tc.drain {
+				c.Drain()
+			}
+
+			report := c.Check(ctx)
+
+			assert.Equal(t, tc.ready, report.Ready)
+			assert.Equal(t, tc.status, report.Status)
+
+			statuses := make(map[string]string, len(report.Dependencies))
+			for name, dep := range report.Dependencies {
+				statuses[name] = dep.Status
+				assert.Equal(t, dep.Status == StatusDown, dep.Error != "")
+			}
+			assert.Equal(t, tc.statuses, statuses)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package health
+
+import "time"
+
+type Option func(*Checker)
+
+// Timeout задаёт, сколько ждать ответа одной зависимости
+func Timeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// WithCheck добавляет проверку зависимости, name - её ключ в ответе /readyz
+func WithCheck(name string, check Check) Option {
+	return func(c *Checker) {
+		c.checks = append(c.checks, namedCheck{name: name, check: check})
+	}
+}
+
+// WithOptionalCheck добавляет проверку зависимости, без которой сервис работает: её состояние попадает
+// в ответ /readyz, но на готовность не влияет
+func WithOptionalCheck(name string, check Check) Option {
+	return func(c *Checker) {
+		c.checks = append(c.checks, namedCheck{name: name, check: check, optional: true})
+	}
+}
@@ -30,3 +30,17 @@ func ShutdownTimeout(timeout time.Duration) Option {
 		s.shutdownTimeout = timeout
 	}
 }
+
+// DrainDelay задаёт, сколько после хуков OnShutdown сервер продолжает принимать запросы перед остановкой
+func DrainDelay(delay time.Duration) Option {
+	return func(s *Server) {
+		s.drainDelay = delay
+	}
+}
+
+// OnShutdown добавляет хук, который вызывается в начале Shutdown, например чтобы /readyz начал отвечать 503
+func OnShutdown(f func()) Option {
+	return func(s *Server) {
+		s.onShutdown = append(s.onShutdown, f)
+	}
+}
@@ -54,3 +54,30 @@ func TestShutdownTimeout(t *testing.T) {
 		t.Errorf("expected shutdown timeout to be %v, got %v", timeout, s.shutdownTimeout)
 	}
 }
+
+func TestDrainDelay(t *testing.T) {
+	s := &Server{
+		server: &http.Server{},
+	}
+	delay := 5 * time.Second
+	DrainDelay(delay)(s)
+
+	if s.drainDelay != delay {
+		t.Errorf("expected drain delay to be %v, got %v", delay, s.drainDelay)
+	}
+}
+
+func TestOnShutdown(t *testing.T) {
+	s := New(http.NotFoundHandler(), Port("0"), DrainDelay(10*time.Millisecond))
+
+	var calls []string
+	OnShutdown(func() { calls = append(calls, "drain") })(s)
+
+	if err := s.Shutdown(); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+
+	if len(calls) != 1 {
+		t.Errorf("expected shutdown hook to be called once, got %d", len(calls))
+	}
+}
@@ -18,6 +18,8 @@ type Server struct {
 	server          *http.Server
 	notify          chan error
 	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	onShutdown      []func()
 }
 
 func New(handler http.Handler, opts ...Option) *Server {
@@ -55,7 +57,15 @@ func (s *Server) Notify() <-chan error {
 	return s.notify
 }
 
+// Shutdown вызывает хуки OnShutdown, ждёт drainDelay, пока балансировщик заметит, что сервис не готов,
+// и только потом перестаёт принимать соединения и дожидается текущих запросов
 func (s *Server) Shutdown() error {
+	for _, f := range s.onShutdown {
+		f()
+	}
+
+	time.Sleep(s.drainDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
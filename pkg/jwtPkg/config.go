@@ -0,0 +1,28 @@
+package jwtPkg
+
+import "time"
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+type JWTConfig struct {
+	// Algorithm алгоритм подписи выдаваемых токенов: EdDSA, RS256 или HS256. С HS256 набор JWKS пуст,
+	// и проверить токен может только тот, кто знает секрет, поэтому по умолчанию ключи асимметричные
+	Algorithm string `env:"JWT_ALGORITHM" env-default:"EdDSA"`
+	// Secret общий секрет для HS256, значения по умолчанию нет
+	Secret string `env:"JWT_SECRET"`
+	// PrivateKeyFile PEM-файл с закрытым ключом для RS256 и EdDSA, без него сервис не стартует
+	PrivateKeyFile string `env:"JWT_PRIVATE_KEY_FILE"`
+	// KeyId значение заголовка kid, если не задано - считается отпечаток ключа по RFC 7638
+	KeyId string `env:"JWT_KEY_ID"`
+	// VerifyKeyFiles дополнительные открытые ключи для проверки в формате kid:path,kid:path.
+	// Во время ротации здесь остаются ключи, которыми подписаны ещё не истёкшие токены
+	VerifyKeyFiles map[string]string `env:"JWT_VERIFY_KEY_FILES"`
+	// TokenTTL время жизни выдаваемых токенов
+	TokenTTL time.Duration `env:"JWT_TOKEN_TTL" env-default:"1h"`
+	// RefreshTokenTTL время жизни refresh-токенов
+	RefreshTokenTTL time.Duration `env:"JWT_REFRESH_TOKEN_TTL" env-default:"720h"`
+}
@@ -1,28 +1,173 @@
 package jwtPkg
 
 import (
+	"context"
+	"crypto"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/k1v4/avito_shop/internal/entity"
 	"github.com/labstack/echo/v4"
+	"sort"
 	"strings"
 	"time"
 )
 
-// TODO to config
-const secret = "secret"
+var (
+	ErrNoSecret     = errors.New("jwt secret is required for HS256")
+	ErrNoPrivateKey = errors.New("jwt private key file is required")
+	ErrUnknownKeyId = errors.New("unknown key id")
+	ErrRevoked      = errors.New("token revoked")
+	ErrNoTokenId    = errors.New("token has no jti")
+)
+
+// Claims пользователь, от имени которого выпущен токен
+type Claims struct {
+	UserId   int
+	Username string
+	Roles    []string
+}
+
+// Denylist хранит jti отозванных токенов, пока те не истекут
+type Denylist interface {
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// Manager выпускает и проверяет токены. Подписывает он всегда текущим ключом,
+// а проверяет любым ключом из набора, выбирая его по заголовку kid
+type Manager struct {
+	method  jwt.SigningMethod
+	kid     string
+	signKey interface{}
+
+	verifyKeys map[string]verificationKey
+	jwks       JWKS
+
+	denylist Denylist
+}
+
+func NewManager(cfg JWTConfig, opts ...Option) (*Manager, error) {
+	const op = "jwtPkg.NewManager"
+
+	m := &Manager{
+		kid:        cfg.KeyId,
+		verifyKeys: make(map[string]verificationKey),
+		jwks:       JWKS{Keys: []JWK{}},
+	}
+
+	switch cfg.Algorithm {
+	case AlgorithmHS256:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("%s: %w", op, ErrNoSecret)
+		}
+
+		m.method = jwt.SigningMethodHS256
+		m.signKey = []byte(cfg.Secret)
+		m.verifyKeys[m.kid] = verificationKey{method: m.method, key: m.signKey}
+	case AlgorithmRS256, AlgorithmEdDSA:
+		if cfg.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("%s: %w", op, ErrNoPrivateKey)
+		}
+
+		signer, err := loadPrivateKey(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		m.method, err = methodForKey(signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if m.method.Alg() != cfg.Algorithm {
+			return nil, fmt.Errorf("%s: key in %s does not match %s", op, cfg.PrivateKeyFile, cfg.Algorithm)
+		}
+
+		m.signKey = signer
+
+		m.kid, err = m.addPublicKey(m.kid, signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnsupportedAlgorithm, cfg.Algorithm)
+	}
+
+	for kid, path := range cfg.VerifyKeyFiles {
+		pub, err := loadPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = m.addPublicKey(kid, pub)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	sort.Slice(m.jwks.Keys, func(i, j int) bool {
+		return m.jwks.Keys[i].Kid < m.jwks.Keys[j].Kid
+	})
+
+	return m, nil
+}
+
+// addPublicKey добавляет ключ в набор для проверки и в JWKS, возвращает его kid
+func (m *Manager) addPublicKey(kid string, pub crypto.PublicKey) (string, error) {
+	method, err := methodForKey(pub)
+	if err != nil {
+		return "", err
+	}
+
+	jwk, err := newJWK(kid, method.Alg(), pub)
+	if err != nil {
+		return "", err
+	}
+
+	if kid == "" {
+		kid = thumbprint(jwk)
+		jwk.Kid = kid
+	}
+
+	if _, ok := m.verifyKeys[kid]; ok {
+		return "", fmt.Errorf("duplicate key id %q", kid)
+	}
+
+	m.verifyKeys[kid] = verificationKey{method: method, key: pub}
+	m.jwks.Keys = append(m.jwks.Keys, jwk)
+
+	return kid, nil
+}
+
+func (m *Manager) NewToken(c Claims, duration time.Duration) (string, error) {
+	jti, err := NewTokenId()
+	if err != nil {
+		return "", err
+	}
 
-func NewToken(user entity.User, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+	token := jwt.New(m.method)
+
+	if m.kid != "" {
+		token.Header["kid"] = m.kid
+	}
 
 	claims := token.Claims.(jwt.MapClaims)
 
-	claims["id"] = user.Id
-	claims["username"] = user.Username
+	claims["id"] = c.UserId
+	claims["username"] = c.Username
 	claims["exp"] = time.Now().Add(duration).Unix()
+	claims["jti"] = jti
+
+	if len(c.Roles) > 0 {
+		claims["roles"] = c.Roles
+	}
 
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := token.SignedString(m.signKey)
 	if err != nil {
 		return "", err
 	}
@@ -30,6 +175,11 @@ func NewToken(user entity.User, duration time.Duration) (string, error) {
 	return tokenString, nil
 }
 
+// JWKS возвращает открытые ключи для проверки токенов другими сервисами. Для HS256 набор пуст
+func (m *Manager) JWKS() JWKS {
+	return m.jwks
+}
+
 func ExtractToken(c echo.Context) string {
 	bearerToken := c.Request().Header.Get("Authorization")
 	if bearerToken == "" {
@@ -38,36 +188,125 @@ func ExtractToken(c echo.Context) string {
 	return strings.TrimPrefix(bearerToken, "Bearer ")
 }
 
-func ValidateTokenAndGetUserId(tokenString string) (int, error) {
-	// парсим токен
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// проверяем метод подписи
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+func (m *Manager) ValidateTokenAndGetUserId(ctx context.Context, tokenString string) (int, error) {
+	p, err := m.ValidateToken(ctx, tokenString)
 	if err != nil {
 		return 0, err
 	}
 
+	return p.UserId, nil
+}
+
+// ValidateToken проверяет подпись, срок действия и отзыв токена и возвращает пользователя из его claims
+func (m *Manager) ValidateToken(ctx context.Context, tokenString string) (Claims, error) {
+	// парсим токен
+	token, err := jwt.Parse(tokenString, m.keyFunc)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	// проверяем, что токен не отозван
+	if m.denylist != nil {
+		if jti, ok := token.Claims.(jwt.MapClaims)["jti"].(string); ok {
+			revoked, err := m.denylist.IsRevoked(ctx, jti)
+			if err != nil {
+				return Claims{}, err
+			}
+
+			if revoked {
+				return Claims{}, ErrRevoked
+			}
+		}
+	}
+
 	// проверяем claims
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		// извлекаем userId
 		userId, okUser := claims["id"].(float64)
 		if !okUser {
-			return 0, fmt.Errorf("userId not found in token")
+			return Claims{}, fmt.Errorf("userId not found in token")
 		}
 
 		// проверяем срок действия токена
 		if exp, ok := claims["exp"].(float64); ok {
 			if time.Now().Unix() > int64(exp) {
-				return 0, fmt.Errorf("token expired")
+				return Claims{}, fmt.Errorf("token expired")
 			}
 		}
 
-		return int(userId), nil
+		username, _ := claims["username"].(string)
+
+		var roles []string
+		if rawRoles, ok := claims["roles"].([]interface{}); ok {
+			for _, role := range rawRoles {
+				if r, ok := role.(string); ok {
+					roles = append(roles, r)
+				}
+			}
+		}
+
+		return Claims{
+			UserId:   int(userId),
+			Username: username,
+			Roles:    roles,
+		}, nil
+	}
+
+	return Claims{}, errors.New("invalid token")
+}
+
+// Revoke отзывает токен до конца его срока действия. Токен должен быть валидным,
+// истёкший отзывать незачем
+func (m *Manager) Revoke(ctx context.Context, tokenString string) error {
+	if m.denylist == nil {
+		return nil
+	}
+
+	token, err := jwt.Parse(tokenString, m.keyFunc)
+	if err != nil {
+		return err
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return ErrNoTokenId
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Hour
+	if exp != nil {
+		ttl = time.Until(exp.Time)
+	}
+
+	if ttl <= 0 {
+		return nil
+	}
+
+	return m.denylist.Revoke(ctx, jti, ttl)
+}
+
+// keyFunc выбирает ключ по kid, токены без kid проверяются текущим ключом
+func (m *Manager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = m.kid
+	}
+
+	key, ok := m.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyId, kid)
+	}
+
+	// проверяем метод подписи
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
 
-	return 0, errors.New("invalid token")
+	return key.key, nil
 }
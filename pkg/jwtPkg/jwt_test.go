@@ -0,0 +1,189 @@
+package jwtPkg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeys(t *testing.T, name string, priv interface{}, pub interface{}) (string, string) {
+	t.Helper()
+
+	privDer, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+
+	pubDer, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, name+".pem")
+	pubPath := filepath.Join(dir, name+".pub.pem")
+
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer}), 0o600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}), 0o600))
+
+	return privPath, pubPath
+}
+
+func rsaKeys(t *testing.T, name string) (string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return writeKeys(t, name, key, &key.PublicKey)
+}
+
+func TestManager_HS256(t *testing.T) {
+	m, err := NewManager(JWTConfig{Algorithm: AlgorithmHS256, Secret: "secret"})
+	require.NoError(t, err)
+
+	token, err := m.NewToken(Claims{UserId: 7, Username: "user"}, time.Hour)
+	require.NoError(t, err)
+
+	userId, err := m.ValidateTokenAndGetUserId(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, userId)
+
+	assert.Empty(t, m.JWKS().Keys)
+
+	withRole, err := m.NewToken(Claims{UserId: 7, Username: "user", Roles: []string{"hr"}}, time.Hour)
+	require.NoError(t, err)
+
+	p, err := m.ValidateToken(context.Background(), withRole)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hr"}, p.Roles)
+
+	other, err := NewManager(JWTConfig{Algorithm: AlgorithmHS256, Secret: "other"})
+	require.NoError(t, err)
+
+	_, err = other.ValidateTokenAndGetUserId(context.Background(), token)
+	assert.Error(t, err)
+
+	expired, err := m.NewToken(Claims{UserId: 7, Username: "user"}, -time.Minute)
+	require.NoError(t, err)
+
+	_, err = m.ValidateTokenAndGetUserId(context.Background(), expired)
+	assert.Error(t, err)
+
+	_, err = NewManager(JWTConfig{Algorithm: AlgorithmHS256})
+	assert.ErrorIs(t, err, ErrNoSecret)
+}
+
+func TestManager_RS256Rotation(t *testing.T) {
+	oldPriv, oldPub := rsaKeys(t, "old")
+	newPriv, _ := rsaKeys(t, "new")
+
+	oldManager, err := NewManager(JWTConfig{Algorithm: AlgorithmRS256, PrivateKeyFile: oldPriv, KeyId: "old"})
+	require.NoError(t, err)
+
+	oldToken, err := oldManager.NewToken(Claims{UserId: 1, Username: "user"}, time.Hour)
+	require.NoError(t, err)
+
+	// после ротации подписываем новым ключом, а старый остаётся только для проверки
+	m, err := NewManager(JWTConfig{
+		Algorithm:      AlgorithmRS256,
+		PrivateKeyFile: newPriv,
+		KeyId:          "new",
+		VerifyKeyFiles: map[string]string{"old": oldPub},
+	})
+	require.NoError(t, err)
+
+	newToken, err := m.NewToken(Claims{UserId: 2, Username: "user"}, time.Hour)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Header["alg"])
+
+	userId, err := m.ValidateTokenAndGetUserId(context.Background(), oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userId)
+
+	userId, err = m.ValidateTokenAndGetUserId(context.Background(), newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, userId)
+
+	// старый ключ убран из набора: его токены больше не принимаются
+	_, err = oldManager.ValidateTokenAndGetUserId(context.Background(), newToken)
+	assert.ErrorIs(t, err, ErrUnknownKeyId)
+
+	jwks := m.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "new", jwks.Keys[0].Kid)
+	assert.Equal(t, "old", jwks.Keys[1].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.NotEmpty(t, jwks.Keys[0].N)
+}
+
+func TestManager_EdDSA(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privPath, _ := writeKeys(t, "ed", priv, pub)
+
+	m, err := NewManager(JWTConfig{Algorithm: AlgorithmEdDSA, PrivateKeyFile: privPath})
+	require.NoError(t, err)
+
+	jwks := m.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	// без KeyId в конфиге kid считается как отпечаток ключа
+	assert.Equal(t, thumbprint(jwks.Keys[0]), jwks.Keys[0].Kid)
+
+	token, err := m.NewToken(Claims{UserId: 3, Username: "user"}, time.Hour)
+	require.NoError(t, err)
+
+	userId, err := m.ValidateTokenAndGetUserId(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, userId)
+
+	// токен с подменённым алгоритмом не проходит проверку
+	hs, err := NewManager(JWTConfig{Algorithm: AlgorithmHS256, Secret: "secret", KeyId: jwks.Keys[0].Kid})
+	require.NoError(t, err)
+
+	forged, err := hs.NewToken(Claims{UserId: 3, Username: "user"}, time.Hour)
+	require.NoError(t, err)
+
+	_, err = m.ValidateTokenAndGetUserId(context.Background(), forged)
+	assert.Error(t, err)
+}
+
+func TestManager_Config(t *testing.T) {
+	rsaPriv, _ := rsaKeys(t, "rsa")
+
+	_, err := NewManager(JWTConfig{Algorithm: "none"})
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = NewManager(JWTConfig{Algorithm: AlgorithmRS256})
+	assert.ErrorIs(t, err, ErrNoPrivateKey)
+
+	_, err = NewManager(JWTConfig{Algorithm: AlgorithmEdDSA, PrivateKeyFile: rsaPriv})
+	assert.Error(t, err)
+
+	_, err = NewManager(JWTConfig{Algorithm: AlgorithmRS256, PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+
+	// по умолчанию ключи асимметричные, и без ключа сервис не стартует
+	var defaults JWTConfig
+	require.NoError(t, cleanenv.ReadEnv(&defaults))
+	assert.Equal(t, AlgorithmEdDSA, defaults.Algorithm)
+
+	_, err = NewManager(defaults)
+	assert.ErrorIs(t, err, ErrNoPrivateKey)
+}
@@ -0,0 +1,10 @@
+package jwtPkg
+
+type Option func(*Manager)
+
+// WithDenylist включает проверку отозванных токенов по jti
+func WithDenylist(d Denylist) Option {
+	return func(m *Manager) {
+		m.denylist = d
+	}
+}
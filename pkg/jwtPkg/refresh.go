@@ -0,0 +1,47 @@
+package jwtPkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const (
+	refreshTokenSize = 32
+	tokenIdSize      = 16
+)
+
+// NewRefreshToken генерирует непрозрачный refresh-токен. Клиенту отдаётся сам токен, в базе хранится только его хэш
+func NewRefreshToken() (string, []byte, error) {
+	b := make([]byte, refreshTokenSize)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken хэш, по которому refresh-токен ищется в базе. У токена 256 бит случайности,
+// поэтому соль и медленный хэш здесь не нужны
+func HashRefreshToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+
+	return sum[:]
+}
+
+// NewTokenId генерирует случайный идентификатор, используется для jti и семейств refresh-токенов
+func NewTokenId() (string, error) {
+	b := make([]byte, tokenIdSize)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -2,12 +2,15 @@ package logger
 
 import (
 	"context"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 const (
 	LoggerKey   = "logger"
 	RequestID   = "requestID"
+	TraceID     = "trace_id"
+	SpanID      = "span_id"
 	ServiceName = "backend"
 )
 
@@ -18,32 +21,75 @@ type logger struct {
 
 //go:generate go run github.com/vektra/mockery/v2@v2.43.2 --name=Logger
 type Logger interface {
+	Debug(ctx context.Context, msg string, fields ...zap.Field)
 	Info(ctx context.Context, msg string, fields ...zap.Field)
+	Warn(ctx context.Context, msg string, fields ...zap.Field)
 	Error(ctx context.Context, msg string, fields ...zap.Field)
+	// With возвращает логгер, который добавляет fields к каждой записи
+	With(fields ...zap.Field) Logger
 }
 
-func (l *logger) Info(ctx context.Context, msg string, fields ...zap.Field) {
-	fields = append(fields, zap.String(ServiceName, l.serviceName))
+func (l *logger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Debug(msg, l.fields(ctx, fields)...)
+}
 
-	if ctx.Value(RequestID) != nil {
-		fields = append(fields, zap.String(RequestID, ctx.Value(RequestID).(string)))
-	}
+func (l *logger) Info(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Info(msg, l.fields(ctx, fields)...)
+}
 
-	l.logger.Info(msg, fields...)
+func (l *logger) Warn(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Warn(msg, l.fields(ctx, fields)...)
 }
 
 func (l *logger) Error(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Error(msg, l.fields(ctx, fields)...)
+}
+
+func (l *logger) With(fields ...zap.Field) Logger {
+	return &logger{
+		serviceName: l.serviceName,
+		logger:      l.logger.With(fields...),
+	}
+}
+
+// fields дополняет поля записи именем сервиса, id запроса и id трассы и спана из контекста
+func (l *logger) fields(ctx context.Context, fields []zap.Field) []zap.Field {
 	fields = append(fields, zap.String(ServiceName, l.serviceName))
 
-	if ctx.Value(RequestID) != nil {
-		fields = append(fields, zap.String(RequestID, ctx.Value(RequestID).(string)))
+	if id := GetRequestID(ctx); id != "" {
+		fields = append(fields, zap.String(RequestID, id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, zap.String(TraceID, sc.TraceID().String()), zap.String(SpanID, sc.SpanID().String()))
 	}
 
-	l.logger.Error(msg, fields...)
+	return fields
 }
 
+// WithLogger кладёт логгер в контекст, например логгер запроса с его полями
+func WithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, LoggerKey, l)
+}
+
+// GetLoggerFromContext возвращает логгер из контекста, а если его там нет - логгер, который ничего не пишет
 func GetLoggerFromContext(ctx context.Context) Logger {
-	return ctx.Value(LoggerKey).(Logger)
+	if l, ok := ctx.Value(LoggerKey).(Logger); ok {
+		return l
+	}
+
+	return NewNop()
+}
+
+// WithRequestID кладёт в контекст id запроса, логгер добавляет его к каждой записи
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestID, id)
+}
+
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestID).(string)
+
+	return id
 }
 
 func NewLogger() Logger {
@@ -58,3 +104,11 @@ func NewLogger() Logger {
 		logger:      l,
 	}
 }
+
+// NewNop логгер, который ничего не пишет
+func NewNop() Logger {
+	return &logger{
+		serviceName: ServiceName,
+		logger:      zap.NewNop(),
+	}
+}
@@ -0,0 +1,14 @@
+package scheduler
+
+import "time"
+
+type Option func(*Scheduler)
+
+// Interval задаёт, как часто запускается задача
+func Interval(interval time.Duration) Option {
+	return func(s *Scheduler) {
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/k1v4/avito_shop/pkg/logger"
+	"time"
+)
+
+const defaultInterval = time.Minute
+
+// Job задача планировщика. Ошибка задачи логируется, следующий запуск идёт по расписанию
+type Job func(ctx context.Context) error
+
+// Scheduler запускает задачу сразу после создания и затем раз в interval, пока не вызван Close.
+// Сам планировщик не следит за тем, чтобы задача выполнилась один раз на несколько реплик,
+// это забота задачи
+type Scheduler struct {
+	name     string
+	job      Job
+	l        logger.Logger
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func New(ctx context.Context, name string, job Job, l logger.Logger, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		name:     name,
+		job:      job,
+		l:        l,
+		interval: defaultInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	go s.run(ctx)
+
+	return s
+}
+
+// Close останавливает планировщик и ждёт завершения текущего запуска задачи
+func (s *Scheduler) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.runJob(ctx)
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.runJob(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context) {
+	if err := s.job(ctx); err != nil {
+		s.l.Error(ctx, fmt.Sprintf("scheduler %s: %s", s.name, err))
+	}
+}
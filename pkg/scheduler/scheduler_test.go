@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	loggermocks "github.com/k1v4/avito_shop/pkg/logger/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInterval(t *testing.T) {
+	s := &Scheduler{interval: defaultInterval}
+
+	Interval(time.Second)(s)
+	assert.Equal(t, time.Second, s.interval)
+
+	Interval(0)(s)
+	assert.Equal(t, time.Second, s.interval)
+}
+
+func TestScheduler(t *testing.T) {
+	l := loggermocks.NewLogger(t)
+	l.On("Error", mock.Anything, "scheduler test: boom").Maybe()
+
+	var runs atomic.Int32
+	s := New(context.Background(), "test", func(ctx context.Context) error {
+		runs.Add(1)
+
+		return errors.New("boom")
+	}, l, Interval(10*time.Millisecond))
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+
+	s.Close()
+	stopped := runs.Load()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
@@ -0,0 +1,103 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// ErrUnknownExporter неизвестное значение TRACING_EXPORTER
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+type TracingConfig struct {
+	// Exporter куда отправлять спаны: otlp, stdout или none. Адрес коллектора для otlp задаётся
+	// стандартными переменными OTEL_EXPORTER_OTLP_ENDPOINT и OTEL_EXPORTER_OTLP_INSECURE
+	Exporter    string  `env:"TRACING_EXPORTER" env-description:"span exporter: otlp, stdout or none" env-default:"none"`
+	ServiceName string  `env:"TRACING_SERVICE_NAME" env-default:"avito_shop"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" env-description:"share of new traces that are recorded, from 0 to 1" env-default:"1"`
+}
+
+// Shutdown отправляет накопленные спаны и останавливает экспортёр
+type Shutdown func(ctx context.Context) error
+
+// New настраивает глобальные TracerProvider и W3C-пропагатор (traceparent, tracestate, baggage).
+// Пропагатор ставится и при exporter = none, чтобы id трассы из входящего запроса попадали в логи
+// и передавались дальше, даже если сервис сам спаны не пишет
+func New(ctx context.Context, cfg TracingConfig) (Shutdown, error) {
+	const op = "telemetry.New"
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownExporter, cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// решение о записи берётся у родителя, чтобы трасса не обрывалась на этом сервисе
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer возвращает трассировщик глобального провайдера. Трассировщики, полученные до New,
+// после него тоже начинают писать спаны
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// End завершает спан, ошибка записывается в него и помечает спан неуспешным
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
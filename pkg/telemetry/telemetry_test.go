@@ -0,0 +1,40 @@
+package telemetry
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name     string
+		exporter string
+		wantErr  error
+	}{
+		{name: "none", exporter: ExporterNone},
+		{name: "empty", exporter: ""},
+		{name: "stdout", exporter: ExporterStdout},
+		{name: "unknown", exporter: "jaeger", wantErr: ErrUnknownExporter},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			shutdown, err := New(ctx, TracingConfig{Exporter: tc.exporter, ServiceName: "test", SampleRatio: 1})
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+
+				return
+			}
+			assert.NoError(t, err)
+
+			// W3C-пропагатор ставится при любом экспортёре
+			assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+
+			assert.NoError(t, shutdown(ctx))
+		})
+	}
+}